@@ -5,11 +5,14 @@ go 1.18
 require (
 	github.com/Tnze/go-mc v1.17.1
 	github.com/fatih/color v1.13.0
+	github.com/google/uuid v1.1.1
+	github.com/yuin/gopher-lua v1.1.1
+	golang.org/x/term v0.5.0
 )
 
 require (
-	github.com/google/uuid v1.1.1 // indirect
+	github.com/iancoleman/strcase v0.1.3 // indirect
 	github.com/mattn/go-colorable v0.1.9 // indirect
 	github.com/mattn/go-isatty v0.0.14 // indirect
-	golang.org/x/sys v0.0.0-20210630005230-0f9fa26af87c // indirect
+	golang.org/x/sys v0.5.0 // indirect
 )
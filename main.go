@@ -1,13 +1,19 @@
 package main
 
 import (
-	"fmt"
+	"flag"
 	"github.com/OCharnyshevich/proxycraft/proxy"
+	"github.com/OCharnyshevich/proxycraft/proxy/channel"
+	"github.com/OCharnyshevich/proxycraft/proxy/moderation"
 	"github.com/OCharnyshevich/proxycraft/proxy/network"
-	"github.com/Tnze/go-mc/chat"
-	"github.com/Tnze/go-mc/data/packetid"
+	"github.com/OCharnyshevich/proxycraft/proxy/network/protocol"
+	"github.com/OCharnyshevich/proxycraft/proxy/player/chat"
+	"github.com/OCharnyshevich/proxycraft/proxy/rcon"
+	"github.com/OCharnyshevich/proxycraft/proxy/script"
+	"github.com/OCharnyshevich/proxycraft/proxy/stream"
+	"github.com/OCharnyshevich/proxycraft/proxy/trace"
+	mcChat "github.com/Tnze/go-mc/chat"
 	mcNet "github.com/Tnze/go-mc/net"
-	pk "github.com/Tnze/go-mc/net/packet"
 	"github.com/fatih/color"
 	"github.com/google/uuid"
 	"log"
@@ -17,11 +23,16 @@ func main() {
 	color.NoColor = false
 	config := &proxy.NewConfig
 
+	flag.BoolVar(&config.Dashboard, "dashboard", false, "show the full-screen dashboard instead of the console")
+	flag.Parse()
+
 	p, err := proxy.New(config)
 	if err != nil {
 		panic(err)
 	}
 
+	p.Register(script.New(), stream.New(), rcon.New(), trace.New(), moderation.New(), channel.New())
+
 	network.EventsListener{
 		GameStart:      onGameStart,
 		ChatMsg:        onChatMsg,
@@ -31,104 +42,11 @@ func main() {
 
 	events := p.Network().Events().(*network.Events)
 	events.AddListener(
-		network.PacketHandler{Priority: 64, ID: packetid.Camera, F: func(client *mcNet.Conn, server *mcNet.Conn, packet pk.Packet) error {
+		network.PacketHandler{Priority: 64, Name: protocol.Camera, F: func(client *mcNet.Conn, server *mcNet.Conn, packet network.Packet) error {
 			p.Logging().InfoF("Hit")
 			return nil
 		}},
 	)
-	events.AddGeneric(
-		network.PacketHandler{Priority: 64, F: func(client *mcNet.Conn, server *mcNet.Conn, packet pk.Packet) error {
-
-			switch packet.ID {
-			case 0,
-				packetid.EntityHeadRotation,
-				packetid.EntityVelocity,
-				packetid.UpdateJigsawBlock,
-				packetid.UpdateStructureBlock,
-				packetid.DestroyEntity,
-				packetid.EntityMetadata,
-				packetid.KeepAliveClientbound,
-				packetid.MapChunk,
-				packetid.UpdateLight,
-				packetid.EntityUpdateAttributes,
-				packetid.BlockChange,
-				packetid.SpawnEntityLiving,
-				packetid.EntityLook,
-				packetid.UnloadChunk,
-				packetid.UpdateViewPosition,
-				packetid.PlayerInfo,
-				packetid.ChatClientbound,
-				packetid.MultiBlockChange,
-				packetid.CustomPayloadClientbound,
-				packetid.PositionClientbound,
-				packetid.EntityTeleport:
-			case packetid.OpenWindow:
-				p.Logging().InfoF("OpenWindow")
-			case packetid.WindowItems:
-				p.Logging().InfoF("WindowItems")
-			case packetid.CloseWindowClientbound:
-				p.Logging().InfoF("CloseWindowClientbound")
-			case packetid.SetSlot:
-				p.Logging().InfoF("SetSlot")
-			case packetid.Animation:
-				p.Logging().InfoF("Animation")
-			case packetid.PickItem:
-				p.Logging().InfoF("PickItem")
-			case packetid.UpdateTime:
-				var (
-					wordAge   pk.Long
-					timeOfDay pk.Long
-				)
-				_ = packet.Scan(&wordAge, &timeOfDay)
-				//p.Logging().InfoF("UpdateTime; Word age: %v, time of day: %v", wordAge, timeOfDay)
-				p.Broadcast(fmt.Sprintf("UpdateTime; Word age: %v, time of day: %v", wordAge, timeOfDay))
-				err := client.WritePacket(pk.Marshal(
-					packetid.UpdateTime,
-					pk.Long(0), pk.Long(0),
-				))
-				if err != nil {
-					p.Logging().Fail(err)
-				}
-			case packetid.GameStateChange:
-				var (
-					reason pk.VarInt
-					value  pk.Float
-				)
-				_ = packet.Scan(&reason, &value)
-				p.Logging().InfoF("GameStateChange; Reason: %d, value: %f", reason, value)
-			case packetid.SoundEffect:
-				var (
-					id       pk.VarInt
-					category pk.VarInt
-					entityId pk.VarInt
-					volume   pk.Float
-					pitch    pk.Float
-				)
-				_ = packet.Scan(&id, &category, &entityId, &volume, &pitch)
-				p.Logging().InfoF("SoundEffect; Id: %d | bookOpen: %d | filterActive: %d | volume: %f | pitch: %f", id, category, entityId, volume, pitch)
-			case packetid.BlockAction:
-				p.Logging().InfoF("BlockAction")
-				err := client.WritePacket(pk.Marshal(
-					packetid.UpdateTime,
-					pk.Long(0), pk.Long(0),
-				))
-				if err != nil {
-					p.Logging().Fail(err)
-				}
-			case packetid.UpdateHealth:
-				var (
-					health         pk.Float
-					food           pk.VarInt
-					foodSaturation pk.Float
-				)
-				_ = packet.Scan(&health, &food, &foodSaturation)
-				p.Logging().InfoF("UpdateHealth; Health: %.0f, food: %d, saturation: %.0f", health, food, foodSaturation)
-			default:
-				p.Logging().InfoF("Read packet: 0x%X", packet.ID)
-			}
-			return nil
-		}},
-	)
 
 	p.Load()
 }
@@ -145,12 +63,12 @@ func onGameStart() error {
 	return nil
 }
 
-func onChatMsg(c chat.Message, pos byte, uuid uuid.UUID) error {
-	log.Println("Chat:", c, pos, uuid)
+func onChatMsg(c mcChat.Message, pos byte, uuid uuid.UUID) error {
+	log.Println("Chat:", chat.FromMessage(c).ANSI(), pos, uuid)
 	return nil
 }
 
-func onKickDisconnect(c chat.Message) error {
-	log.Println("KickDisconnect:", c)
+func onKickDisconnect(c mcChat.Message) error {
+	log.Println("KickDisconnect:", chat.FromMessage(c).ANSI())
 	return nil
 }
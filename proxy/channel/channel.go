@@ -0,0 +1,332 @@
+// Package channel delivers proxy-wide chat channels, like global, staff and party,
+// to their members whichever backend they are on.
+//
+// Players join channels with the channel command and talk in one of them, or to
+// their backend. A chat line for a channel, the one the player talks in or one
+// starting with the prefix of a channel, is formatted with the markup template of
+// the channel and sent to its members by the proxy, it never reaches the backend.
+package channel
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/OCharnyshevich/proxycraft/proxy/chatlog"
+	"github.com/OCharnyshevich/proxycraft/proxy/helper"
+	"github.com/OCharnyshevich/proxycraft/proxy/log"
+	"github.com/OCharnyshevich/proxycraft/proxy/network"
+	"github.com/OCharnyshevich/proxycraft/proxy/network/protocol"
+	"github.com/OCharnyshevich/proxycraft/proxy/player/chat"
+	"github.com/OCharnyshevich/proxycraft/proxy/plugin"
+	mcNet "github.com/Tnze/go-mc/net"
+	pk "github.com/Tnze/go-mc/net/packet"
+	"github.com/google/uuid"
+)
+
+// Backend is the name taking the chat of a player back to the backend.
+const Backend = "backend"
+
+// DefaultFormat is the template of the channels without one.
+const DefaultFormat = "<gray>[<channel>]</gray> <player><gray>:</gray> <message>"
+
+type Config struct {
+	// Channels by name, global, staff and party unless the config replaces them.
+	Channels map[string]Channel `json:"channels"`
+}
+
+type Channel struct {
+	// Format is the chat.Parse markup of the messages, with the <player>, <message>,
+	// <channel> and <backend> placeholders.
+	Format string `json:"format"`
+	// Prefix sends a chat line starting with it to the channel, like "!" for "!hello".
+	Prefix string `json:"prefix"`
+	// Permission is the node needed to join the channel, anyone can when empty.
+	Permission string `json:"permission"`
+	// AutoJoin joins the players allowed to when they log in.
+	AutoJoin bool `json:"auto_join"`
+	// Party sends the messages to the party of the player only.
+	Party bool `json:"party"`
+}
+
+// Channels is the plugin delivering the channels.
+type Channels struct {
+	ctx    *plugin.Context
+	config Config
+
+	mu      sync.Mutex
+	members map[uuid.UUID]*member
+	parties map[uuid.UUID]*party
+	invites map[uuid.UUID]uuid.UUID
+}
+
+// member is the channels of a player.
+type member struct {
+	joined map[string]bool
+	// talk is the channel the chat lines go to, empty for the backend.
+	talk string
+}
+
+func New() *Channels {
+	return &Channels{
+		config: Config{
+			Channels: map[string]Channel{
+				"global": {
+					Format:   "<dark_aqua>[G]</dark_aqua> <gray><player></gray><dark_gray>:</dark_gray> <message>",
+					Prefix:   "!",
+					AutoJoin: true,
+				},
+				"staff": {
+					Format:     "<red>[Staff]</red> <hover:show_text:'<gray>on <backend>'><gold><player></gold></hover><dark_gray>:</dark_gray> <yellow><message>",
+					Prefix:     "#",
+					Permission: "proxy.channel.staff",
+					AutoJoin:   true,
+				},
+				"party": {
+					Format:   "<light_purple>[Party]</light_purple> <player><dark_gray>:</dark_gray> <message>",
+					Prefix:   "@",
+					AutoJoin: true,
+					Party:    true,
+				},
+			},
+		},
+	}
+}
+
+func (c *Channels) Info() plugin.Info {
+	return plugin.Info{Name: "channels", Version: "1.0.0"}
+}
+
+func (c *Channels) Attach(ctx *plugin.Context) {
+	c.ctx = ctx
+}
+
+func (c *Channels) Load() {
+	var config Config
+	if err := c.ctx.Config.Decode(&config); err != nil {
+		c.ctx.Logger.FailF("invalid config: %v", err)
+		return
+	}
+	if config.Channels != nil {
+		c.config.Channels = config.Channels
+	}
+
+	c.mu.Lock()
+	c.members = make(map[uuid.UUID]*member)
+	c.parties = make(map[uuid.UUID]*party)
+	c.invites = make(map[uuid.UUID]uuid.UUID)
+	c.mu.Unlock()
+
+	for _, session := range c.ctx.Network.Sessions() {
+		c.autoJoin(session)
+	}
+
+	// before the moderation, which is asked to check the lines of the channels
+	c.ctx.Listen(network.PacketHandler{Priority: 8, Name: protocol.ChatServerbound, F: c.onChat})
+	c.ctx.ListenSessions(network.SessionHandler{F: c.onSession})
+	if err := c.ctx.RegisterCommand(c.commands()...); err != nil {
+		c.ctx.Logger.Fail(err)
+	}
+}
+
+func (c *Channels) Kill() {}
+
+func (c *Channels) onSession(event network.SessionEvent, session helper.Sessionable) {
+	switch event {
+	case network.SessionJoined:
+		c.autoJoin(session)
+	case network.SessionClosed:
+		c.mu.Lock()
+		delete(c.members, session.UUID())
+		delete(c.invites, session.UUID())
+		c.leaveParty(session.UUID())
+		c.mu.Unlock()
+	}
+}
+
+// autoJoin joins the player to the auto join channels the player is allowed in.
+func (c *Channels) autoJoin(session helper.Sessionable) {
+	for name, ch := range c.config.Channels {
+		if ch.AutoJoin && c.allowed(session, ch) {
+			c.mu.Lock()
+			c.member(session.UUID()).joined[name] = true
+			c.mu.Unlock()
+		}
+	}
+}
+
+func (c *Channels) allowed(session helper.Sessionable, ch Channel) bool {
+	return ch.Permission == "" || c.ctx.Permitted(session, ch.Permission)
+}
+
+// member returns the channels of the player, c.mu has to be held.
+func (c *Channels) member(id uuid.UUID) *member {
+	m := c.members[id]
+	if m == nil {
+		m = &member{joined: make(map[string]bool)}
+		c.members[id] = m
+	}
+	return m
+}
+
+// names lists the channels ordered by name.
+func (c *Channels) names() []string {
+	names := make([]string, 0, len(c.config.Channels))
+	for name := range c.config.Channels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (c *Channels) onChat(_ *mcNet.Conn, _ *mcNet.Conn, packet network.Packet) error {
+	var message pk.String
+	if err := packet.Scan(&message); err != nil {
+		return err
+	}
+
+	text := string(message)
+	if strings.HasPrefix(text, "/") {
+		return nil
+	}
+
+	name, text := c.target(packet.Session, text)
+	if name == "" {
+		return nil
+	}
+
+	if err := c.Send(packet.Session, name, text); err != nil {
+		packet.Session.SendMessage(chat.Red, err.Error())
+	}
+	return network.Drop
+}
+
+// target is the channel of a chat line, by its prefix or the channel the player talks in,
+// with the line without the prefix. The name is empty for the lines of the backend. A prefix
+// only counts for the channels the player joined, and for party channels while the player
+// is in a party, other lines starting with it are ordinary chat.
+func (c *Channels) target(session helper.Sessionable, text string) (string, string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	m := c.members[session.UUID()]
+	if m == nil {
+		return "", text
+	}
+
+	for _, name := range c.names() {
+		ch := c.config.Channels[name]
+		if ch.Prefix == "" || !strings.HasPrefix(text, ch.Prefix) || len(text) == len(ch.Prefix) {
+			continue
+		}
+		if !m.joined[name] || (ch.Party && c.parties[session.UUID()] == nil) {
+			continue
+		}
+		return name, strings.TrimSpace(strings.TrimPrefix(text, ch.Prefix))
+	}
+
+	return m.talk, text
+}
+
+// checker is the moderation plugin, which checks the lines of the channels too.
+type checker interface {
+	Check(session helper.Sessionable, text string) (string, string)
+}
+
+// Send delivers a chat line of the player to the members of the channel.
+func (c *Channels) Send(sender helper.Sessionable, name, text string) error {
+	ch, ok := c.config.Channels[name]
+	if !ok {
+		return unknownChannel(name)
+	}
+
+	c.mu.Lock()
+	joined := c.member(sender.UUID()).joined[name]
+	c.mu.Unlock()
+	if !joined {
+		return notJoined(name)
+	}
+
+	entry := chatlog.Typed(sender, text, chatlog.Channel)
+	entry.Channel = name
+	if p, ok := c.ctx.Plugin("moderation"); ok {
+		if moderation, ok := p.(checker); ok {
+			var reason string
+			if text, reason = moderation.Check(sender, text); reason != "" {
+				entry.Outcome = chatlog.Blocked
+				c.ctx.ArchiveChat(entry)
+				return errors.New(reason)
+			}
+		}
+	}
+	if text != entry.Text {
+		entry.Forwarded = text
+	}
+
+	recipients, err := c.recipients(sender, name, ch)
+	if err != nil {
+		return err
+	}
+
+	format := ch.Format
+	if format == "" {
+		format = DefaultFormat
+	}
+	component := chat.Parse(format, chat.Placeholders{
+		"player":  sender.Name(),
+		"message": text,
+		"channel": name,
+		"backend": sender.Server(),
+	})
+
+	for _, session := range recipients {
+		c.deliver(session, component, sender.UUID())
+	}
+
+	c.ctx.Logger.With(log.Session(sender.ID()), log.Player(sender.Name())).InfoF("[%s] %s: %s", name, sender.Name(), text)
+	c.ctx.ArchiveChat(entry)
+	return nil
+}
+
+// deliver sends the component to the player as a chat message of the sender.
+func (c *Channels) deliver(session helper.Sessionable, component chat.Component, sender uuid.UUID) {
+	version := session.Backend()
+	if version == nil {
+		version = protocol.Default()
+	}
+
+	packet, err := network.ChatPacket(version, component, sender)
+	if err == nil {
+		err = session.WritePacket(packet)
+	}
+	if err != nil {
+		c.ctx.Logger.With(log.Player(session.Name())).FailF("unable to deliver a chat message: %v", err)
+	}
+}
+
+// recipients are the online members of the channel, the members of the party of the
+// sender for party channels.
+func (c *Channels) recipients(sender helper.Sessionable, name string, ch Channel) ([]helper.Sessionable, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var party *party
+	if ch.Party {
+		if party = c.parties[sender.UUID()]; party == nil {
+			return nil, noParty
+		}
+	}
+
+	var recipients []helper.Sessionable
+	for _, session := range c.ctx.Network.Sessions() {
+		if m := c.members[session.UUID()]; m == nil || !m.joined[name] {
+			continue
+		}
+		if party != nil && !party.members[session.UUID()] {
+			continue
+		}
+		recipients = append(recipients, session)
+	}
+	return recipients, nil
+}
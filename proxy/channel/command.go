@@ -0,0 +1,150 @@
+package channel
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/OCharnyshevich/proxycraft/proxy/command"
+	"github.com/OCharnyshevich/proxycraft/proxy/helper"
+	"github.com/OCharnyshevich/proxycraft/proxy/player/chat"
+)
+
+func unknownChannel(name string) error {
+	return fmt.Errorf("there is no %s channel", name)
+}
+
+func notJoined(name string) error {
+	return fmt.Errorf("you are not in the %s channel, join it with channel join %s", name, name)
+}
+
+func (c *Channels) commands() []command.Command {
+	return []command.Command{
+		{
+			Name:        "channel",
+			Aliases:     []string{"ch"},
+			Description: "lists, joins, leaves or talks in the chat channels",
+			Scope:       command.GameOnly,
+			Complete:    c.complete,
+			Run:         c.channel,
+		},
+		{
+			Name:        "party",
+			Description: "invites players to a party sharing the party channel",
+			Scope:       command.GameOnly,
+			Complete: func(_ command.Sender, args []string, _ string) []string {
+				if len(args) == 0 {
+					return []string{"invite", "accept", "leave"}
+				}
+				if len(args) == 1 && args[0] == "invite" {
+					var names []string
+					for _, session := range c.ctx.Network.Sessions() {
+						names = append(names, session.Name())
+					}
+					return names
+				}
+				return nil
+			},
+			Run: c.party,
+		},
+	}
+}
+
+// channel runs the channel command: list, join, leave and talk.
+func (c *Channels) channel(sender command.Sender, args command.Args) error {
+	session := sender.(helper.Sessionable)
+	if len(args.Raw) == 0 {
+		c.list(session)
+		return nil
+	}
+	if len(args.Raw) != 2 {
+		return errors.New("usage: channel [join|leave|talk <channel>]")
+	}
+
+	name := strings.ToLower(args.Raw[1])
+	ch, ok := c.config.Channels[name]
+	if !ok && !(args.Raw[0] == "talk" && name == Backend) {
+		return unknownChannel(name)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	m := c.member(session.UUID())
+	switch args.Raw[0] {
+	case "join":
+		if !c.allowed(session, ch) {
+			return fmt.Errorf("you are not allowed in the %s channel", name)
+		}
+		m.joined[name] = true
+		m.talk = name
+		session.SendMessage("You joined and talk in the ", chat.Gold, name, chat.Reset, " channel")
+	case "leave":
+		if !m.joined[name] {
+			return notJoined(name)
+		}
+		delete(m.joined, name)
+		if m.talk == name {
+			m.talk = ""
+		}
+		session.SendMessage("You left the ", chat.Gold, name, chat.Reset, " channel")
+	case "talk":
+		if name == Backend {
+			m.talk = ""
+			session.SendMessage("You talk to the backend")
+			return nil
+		}
+		if !m.joined[name] {
+			return notJoined(name)
+		}
+		m.talk = name
+		session.SendMessage("You talk in the ", chat.Gold, name, chat.Reset, " channel")
+	default:
+		return errors.New("usage: channel [join|leave|talk <channel>]")
+	}
+	return nil
+}
+
+// list shows the channels the player is allowed in, marking the joined one and the one
+// the player talks in.
+func (c *Channels) list(session helper.Sessionable) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	m := c.member(session.UUID())
+	for _, name := range c.names() {
+		ch := c.config.Channels[name]
+		if !c.allowed(session, ch) {
+			continue
+		}
+
+		var notes []string
+		if m.joined[name] {
+			notes = append(notes, "joined")
+		}
+		if m.talk == name {
+			notes = append(notes, "talking")
+		}
+		if ch.Prefix != "" {
+			notes = append(notes, "prefix "+ch.Prefix)
+		}
+		session.SendMessage(chat.Gold, name, chat.Reset, " ", strings.Join(notes, ", "))
+	}
+	if m.talk == "" {
+		session.SendMessage("You talk to the backend")
+	}
+}
+
+func (c *Channels) complete(sender command.Sender, args []string, _ string) []string {
+	switch len(args) {
+	case 0:
+		return []string{"join", "leave", "talk"}
+	case 1:
+		names := c.names()
+		if args[0] == "talk" {
+			names = append(names, Backend)
+		}
+		return names
+	}
+	return nil
+}
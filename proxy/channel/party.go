@@ -0,0 +1,192 @@
+package channel
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/OCharnyshevich/proxycraft/proxy/command"
+	"github.com/OCharnyshevich/proxycraft/proxy/helper"
+	"github.com/OCharnyshevich/proxycraft/proxy/player/chat"
+	"github.com/google/uuid"
+)
+
+// party is a group of players sharing the party channels, it lasts while they are online.
+type party struct {
+	leader  uuid.UUID
+	members map[uuid.UUID]bool
+}
+
+var noParty = errors.New("you are not in a party, invite a player with party invite <player>")
+
+// leaveParty removes the player from its party, the party ends with its last member and
+// another member leads it when the leader leaves. c.mu has to be held.
+func (c *Channels) leaveParty(id uuid.UUID) *party {
+	p := c.parties[id]
+	if p == nil {
+		return nil
+	}
+
+	delete(c.parties, id)
+	delete(p.members, id)
+	if p.leader == id {
+		for member := range p.members {
+			p.leader = member
+			break
+		}
+	}
+	if len(p.members) == 1 {
+		// a party of one is no party
+		for member := range p.members {
+			delete(c.parties, member)
+		}
+	}
+	return p
+}
+
+// party runs the party command: list, invite, accept and leave.
+func (c *Channels) party(sender command.Sender, args command.Args) error {
+	session := sender.(helper.Sessionable)
+	if len(args.Raw) == 0 {
+		return c.listParty(session)
+	}
+
+	switch args.Raw[0] {
+	case "invite":
+		if len(args.Raw) != 2 {
+			return errors.New("usage: party invite <player>")
+		}
+		return c.invite(session, args.Raw[1])
+	case "accept":
+		return c.accept(session)
+	case "leave":
+		c.mu.Lock()
+		p := c.leaveParty(session.UUID())
+		c.mu.Unlock()
+		if p == nil {
+			return noParty
+		}
+
+		c.tell(p, fmt.Sprintf("%s left the party", session.Name()))
+		session.SendMessage("You left the party")
+		return nil
+	}
+	return errors.New("usage: party [invite <player>|accept|leave]")
+}
+
+func (c *Channels) listParty(session helper.Sessionable) error {
+	c.mu.Lock()
+	p := c.parties[session.UUID()]
+	var members []string
+	if p != nil {
+		for _, s := range c.ctx.Network.Sessions() {
+			if p.members[s.UUID()] {
+				name := s.Name()
+				if s.UUID() == p.leader {
+					name += " (leader)"
+				}
+				members = append(members, name)
+			}
+		}
+	}
+	c.mu.Unlock()
+
+	if p == nil {
+		return noParty
+	}
+	sort.Strings(members)
+	session.SendMessage("Party: ", strings.Join(members, ", "))
+	return nil
+}
+
+// invite invites a player to the party of the sender, which starts one without a party.
+func (c *Channels) invite(session helper.Sessionable, name string) error {
+	var invited helper.Sessionable
+	for _, s := range c.ctx.Network.Sessions() {
+		if strings.EqualFold(s.Name(), name) {
+			invited = s
+		}
+	}
+	if invited == nil {
+		return fmt.Errorf("player %s is not online", name)
+	}
+	if invited.UUID() == session.UUID() {
+		return errors.New("you cannot invite yourself")
+	}
+
+	c.mu.Lock()
+	if p := c.parties[session.UUID()]; p != nil && p.leader != session.UUID() {
+		c.mu.Unlock()
+		return errors.New("only the leader of the party invites players")
+	}
+	if c.parties[invited.UUID()] != nil {
+		c.mu.Unlock()
+		return fmt.Errorf("%s is in a party already", invited.Name())
+	}
+	c.invites[invited.UUID()] = session.UUID()
+	c.mu.Unlock()
+
+	c.deliver(invited, chat.Parse(
+		"<light_purple><player> invites you to a party, accept with the <white>party accept</white> command",
+		chat.Placeholders{"player": session.Name()},
+	), uuid.Nil)
+	session.SendMessage("Invited ", invited.Name(), " to the party")
+	return nil
+}
+
+// accept joins the party of the last invite.
+func (c *Channels) accept(session helper.Sessionable) error {
+	c.mu.Lock()
+	leader, ok := c.invites[session.UUID()]
+	delete(c.invites, session.UUID())
+	if !ok {
+		c.mu.Unlock()
+		return errors.New("you have no party invite")
+	}
+	if c.parties[session.UUID()] != nil {
+		c.mu.Unlock()
+		return errors.New("you are in a party already, leave it first")
+	}
+
+	online := false
+	for _, s := range c.ctx.Network.Sessions() {
+		online = online || s.UUID() == leader
+	}
+	if !online {
+		c.mu.Unlock()
+		return errors.New("the invite expired")
+	}
+
+	p := c.parties[leader]
+	if p == nil {
+		p = &party{leader: leader, members: map[uuid.UUID]bool{leader: true}}
+		c.parties[leader] = p
+	}
+	if p.leader != leader {
+		c.mu.Unlock()
+		return errors.New("the invite expired")
+	}
+	p.members[session.UUID()] = true
+	c.parties[session.UUID()] = p
+	c.mu.Unlock()
+
+	c.tell(p, fmt.Sprintf("%s joined the party", session.Name()))
+	return nil
+}
+
+// tell sends a notice to the online members of the party.
+func (c *Channels) tell(p *party, notice string) {
+	c.mu.Lock()
+	var members []helper.Sessionable
+	for _, s := range c.ctx.Network.Sessions() {
+		if p.members[s.UUID()] {
+			members = append(members, s)
+		}
+	}
+	c.mu.Unlock()
+
+	for _, s := range members {
+		s.SendMessage(chat.Purple, notice)
+	}
+}
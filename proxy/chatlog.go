@@ -0,0 +1,200 @@
+package proxy
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OCharnyshevich/proxycraft/proxy/chatlog"
+	"github.com/OCharnyshevich/proxycraft/proxy/command"
+	"github.com/OCharnyshevich/proxycraft/proxy/helper"
+	"github.com/OCharnyshevich/proxycraft/proxy/network"
+	"github.com/OCharnyshevich/proxycraft/proxy/network/protocol"
+	"github.com/OCharnyshevich/proxycraft/proxy/player/chat"
+	mcChat "github.com/Tnze/go-mc/chat"
+	mcNet "github.com/Tnze/go-mc/net"
+	pk "github.com/Tnze/go-mc/net/packet"
+	"github.com/google/uuid"
+)
+
+// listenChat archives the chat messages the backends send to the players, and the lines
+// of the players which reach the backend. The lines are kept as typed before the plugins
+// see them and archived after them, the plugins archive the lines they block or send to a
+// channel themselves.
+func (p *proxy) listenChat() {
+	if p.chat == nil {
+		return
+	}
+
+	p.typed.lines = make(map[int32]string)
+	events := p.network.Events().(*network.Events)
+	events.AddListener(
+		network.PacketHandler{Priority: 0, Owner: "proxy", Name: protocol.ChatServerbound, F: p.onChatTyped},
+		network.PacketHandler{Priority: 64, Owner: "proxy", Name: protocol.ChatClientbound, F: p.onChatMsg},
+		network.PacketHandler{Priority: 64, Owner: "proxy", Name: protocol.ChatServerbound, F: p.onChatLine},
+	)
+	events.AddSessionListener(network.SessionHandler{Owner: "proxy", F: func(e network.SessionEvent, session helper.Sessionable) {
+		if e == network.SessionClosed {
+			p.typed.take(session.ID())
+		}
+	}})
+}
+
+// typedLines keeps the last chat line of the sessions as typed, until it reaches the backend.
+type typedLines struct {
+	mu    sync.Mutex
+	lines map[int32]string
+}
+
+func (t *typedLines) put(session int32, line string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.lines[session] = line
+}
+
+func (t *typedLines) take(session int32) (string, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	line, ok := t.lines[session]
+	delete(t.lines, session)
+	return line, ok
+}
+
+func (p *proxy) onChatMsg(_ *mcNet.Conn, _ *mcNet.Conn, packet network.Packet) error {
+	var (
+		message mcChat.Message
+		pos     pk.Byte
+		sender  pk.UUID
+	)
+	if err := packet.Scan(&message, &pos, &sender); err != nil {
+		return err
+	}
+
+	msg := chat.FromMessage(message)
+	entry := chatlog.Entry{
+		Time:      time.Now(),
+		Direction: chatlog.Clientbound,
+		UUID:      uuid.UUID(sender),
+		Name:      p.senderName(uuid.UUID(sender), msg),
+		Position:  chatlog.Position(byte(pos)),
+		Backend:   packet.Session.Server(),
+		Text:      msg.Plain(),
+	}
+	if err := p.chat.AppendReceived(entry, packet.Session.ID(), packet.Data); err != nil {
+		p.logging.FailF("unable to archive a chat message: %v", err)
+	}
+	return nil
+}
+
+func (p *proxy) onChatTyped(_ *mcNet.Conn, _ *mcNet.Conn, packet network.Packet) error {
+	var message pk.String
+	if err := packet.Scan(&message); err != nil {
+		return err
+	}
+
+	p.typed.put(packet.Session.ID(), string(message))
+	return nil
+}
+
+// onChatLine archives a chat line the backend receives, with the line as typed when a
+// plugin replaced it.
+func (p *proxy) onChatLine(_ *mcNet.Conn, _ *mcNet.Conn, packet network.Packet) error {
+	var message pk.String
+	if err := packet.Scan(&message); err != nil {
+		return err
+	}
+
+	typed, ok := p.typed.take(packet.Session.ID())
+	if !ok {
+		typed = string(message)
+	}
+	if strings.HasPrefix(typed, "/") {
+		return nil
+	}
+
+	entry := chatlog.Typed(packet.Session, typed, chatlog.Sent)
+	if typed != string(message) {
+		entry.Outcome = chatlog.Replaced
+		entry.Forwarded = string(message)
+	}
+	if err := p.chat.Append(entry); err != nil {
+		p.logging.FailF("unable to archive a chat message: %v", err)
+	}
+	return nil
+}
+
+// senderName is the name of an online sender, or the name in a player chat message.
+func (p *proxy) senderName(sender uuid.UUID, msg chat.Component) string {
+	if sender == uuid.Nil {
+		return ""
+	}
+	for _, session := range p.network.Sessions() {
+		if session.UUID() == sender {
+			return session.Name()
+		}
+	}
+
+	if strings.HasPrefix(msg.Translate, "chat.type.") && len(msg.With) > 0 {
+		return msg.With[0].Plain()
+	}
+	return ""
+}
+
+// searchChat prints the archived messages matching the key=value filters, the other
+// words are the searched text.
+func (p *proxy) searchChat(sender command.Sender, args command.Args) error {
+	if p.chat == nil {
+		return errors.New("the chat archive is off, set Config.ChatLog")
+	}
+
+	query := chatlog.Query{Limit: 20}
+	var text []string
+	for _, arg := range args.Raw {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok {
+			text = append(text, arg)
+			continue
+		}
+
+		var err error
+		switch key {
+		case "player":
+			query.Player = value
+		case "since":
+			query.Since, err = parseTime(value)
+		case "until":
+			query.Until, err = parseTime(value)
+		case "limit":
+			query.Limit, err = strconv.Atoi(value)
+		default:
+			text = append(text, arg)
+		}
+		if err != nil {
+			return fmt.Errorf("bad %s: %w", key, err)
+		}
+	}
+	query.Text = strings.Join(text, " ")
+
+	entries, err := p.chat.Search(query)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		arrow := "←"
+		if e.Direction == chatlog.Serverbound {
+			arrow = "→"
+		}
+		name := e.Name
+		if name == "" {
+			name = e.Position
+		}
+		sender.SendMessage(fmt.Sprintf("[%s] %s %s %s: %s", e.Time.Format("2006-01-02 15:04:05"), e.Backend, arrow, name, e.Text))
+	}
+	sender.SendMessage(fmt.Sprintf("%d messages", len(entries)))
+	return nil
+}
@@ -0,0 +1,273 @@
+// Package chatlog keeps every chat message seen by the proxy in append-only JSON
+// lines files, one per day, and searches them.
+package chatlog
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OCharnyshevich/proxycraft/proxy/helper"
+	"github.com/google/uuid"
+)
+
+const dayLayout = "2006-01-02"
+
+// Directions of the archived messages.
+const (
+	// Clientbound messages are sent by a backend to the players.
+	Clientbound = "clientbound"
+	// Serverbound messages are typed by a player.
+	Serverbound = "serverbound"
+)
+
+// Outcomes of the serverbound lines.
+const (
+	// Sent lines reached the backend as typed.
+	Sent = "sent"
+	// Replaced lines reached the backend after a plugin, like the moderation, changed them.
+	Replaced = "replaced"
+	// Blocked lines were kept from the backend.
+	Blocked = "blocked"
+	// Channel lines went to the members of a channel instead of the backend.
+	Channel = "channel"
+)
+
+// Positions of the chat messages, as sent in the chat packets.
+var Positions = []string{"chat", "system", "game_info"}
+
+// Entry is an archived chat message.
+type Entry struct {
+	Time      time.Time `json:"time"`
+	Direction string    `json:"direction"`
+	// UUID and Name of the sender, empty for system messages.
+	UUID     uuid.UUID `json:"uuid"`
+	Name     string    `json:"name,omitempty"`
+	Position string    `json:"position"`
+	Backend  string    `json:"backend,omitempty"`
+	// Text is the message without colors.
+	Text string `json:"text"`
+	// Outcome is what happened to a serverbound line, Forwarded the line received by the
+	// backend or the channel when it was changed on the way, and Channel the channel it went to.
+	Outcome   string `json:"outcome,omitempty"`
+	Forwarded string `json:"forwarded,omitempty"`
+	Channel   string `json:"channel,omitempty"`
+}
+
+// Typed is the entry of a chat line typed by the player of the session.
+func Typed(session helper.Sessionable, line, outcome string) Entry {
+	return Entry{
+		Time:      time.Now(),
+		Direction: Serverbound,
+		UUID:      session.UUID(),
+		Name:      session.Name(),
+		Position:  Positions[0],
+		Backend:   session.Server(),
+		Text:      line,
+		Outcome:   outcome,
+	}
+}
+
+// Position names the position byte of a chat packet.
+func Position(pos byte) string {
+	if int(pos) < len(Positions) {
+		return Positions[pos]
+	}
+	return "unknown"
+}
+
+// Archive appends the entries to DIRECTORY/YYYY-MM-DD.jsonl.
+type Archive struct {
+	directory string
+
+	mu   sync.Mutex
+	file *os.File
+	day  string
+	// recent holds the clientbound packets of the last seconds, which every
+	// player of a backend receives once but are archived once.
+	recent map[string]*received
+}
+
+// received is a clientbound packet and the sessions which got a copy of it.
+type received struct {
+	time     time.Time
+	sessions map[int32]bool
+}
+
+// dedupe is how long the copies of a clientbound packet are considered the same message.
+const dedupe = 2 * time.Second
+
+func Open(directory string) (*Archive, error) {
+	if err := os.MkdirAll(directory, 0755); err != nil {
+		return nil, err
+	}
+	return &Archive{directory: directory, recent: make(map[string]*received)}, nil
+}
+
+// Append writes the entry to the file of its day.
+func (a *Archive) Append(entry Entry) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.append(entry)
+}
+
+// AppendReceived writes the entry of a chat packet the session received from the backend.
+// The copies of the packet the other players of the backend receive are written once, a
+// packet the session receives again is a repeated message and written again.
+func (a *Archive) AppendReceived(entry Entry, session int32, packet []byte) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	key := entry.Backend + "\x00" + string(packet)
+	if r, ok := a.recent[key]; ok && entry.Time.Sub(r.time) < dedupe && !r.sessions[session] {
+		r.sessions[session] = true
+		return nil
+	}
+	for k, r := range a.recent {
+		if entry.Time.Sub(r.time) >= dedupe {
+			delete(a.recent, k)
+		}
+	}
+	a.recent[key] = &received{time: entry.Time, sessions: map[int32]bool{session: true}}
+
+	return a.append(entry)
+}
+
+func (a *Archive) append(entry Entry) error {
+	day := entry.Time.Format(dayLayout)
+	if a.file == nil || a.day != day {
+		if a.file != nil {
+			_ = a.file.Close()
+		}
+		file, err := os.OpenFile(filepath.Join(a.directory, day+".jsonl"), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			a.file = nil
+			return err
+		}
+		a.file, a.day = file, day
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	_, err = a.file.Write(append(line, '\n'))
+	return err
+}
+
+func (a *Archive) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.file == nil {
+		return nil
+	}
+	err := a.file.Close()
+	a.file = nil
+	return err
+}
+
+// Query selects archived entries, the zero value matches every entry.
+type Query struct {
+	// Player is the name or the UUID of the sender.
+	Player string
+	// Since and Until bound the time of the entries when set, Until is exclusive.
+	Since time.Time
+	Until time.Time
+	// Text is found in the message, ignoring case.
+	Text string
+	// Limit keeps the newest entries, all of them when zero.
+	Limit int
+}
+
+func (q Query) matches(entry Entry) bool {
+	if q.Player != "" && !strings.EqualFold(entry.Name, q.Player) && entry.UUID.String() != strings.ToLower(q.Player) {
+		return false
+	}
+	if !q.Since.IsZero() && entry.Time.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && !entry.Time.Before(q.Until) {
+		return false
+	}
+	return q.Text == "" || strings.Contains(strings.ToLower(entry.Text), strings.ToLower(q.Text))
+}
+
+// Search reads the files of the days in the time range and returns the matching entries, oldest first.
+func (a *Archive) Search(q Query) ([]Entry, error) {
+	days, err := a.days(q)
+	if err != nil {
+		return nil, err
+	}
+
+	var found []Entry
+	for _, day := range days {
+		entries, err := a.read(day, q)
+		if err != nil {
+			return nil, err
+		}
+		found = append(found, entries...)
+	}
+
+	if q.Limit > 0 && len(found) > q.Limit {
+		found = found[len(found)-q.Limit:]
+	}
+	return found, nil
+}
+
+// days lists the archived days which may hold entries of the time range, oldest first.
+func (a *Archive) days(q Query) ([]string, error) {
+	files, err := os.ReadDir(a.directory)
+	if err != nil {
+		return nil, err
+	}
+
+	var days []string
+	for _, f := range files {
+		day := strings.TrimSuffix(f.Name(), ".jsonl")
+		date, err := time.ParseInLocation(dayLayout, day, time.Local)
+		if day == f.Name() || err != nil {
+			continue
+		}
+		// the file of a day may hold entries up to a day later in other time zones
+		if !q.Since.IsZero() && date.AddDate(0, 0, 2).Before(q.Since) {
+			continue
+		}
+		if !q.Until.IsZero() && date.AddDate(0, 0, -1).After(q.Until) {
+			continue
+		}
+		days = append(days, day)
+	}
+
+	sort.Strings(days)
+	return days, nil
+}
+
+func (a *Archive) read(day string, q Query) ([]Entry, error) {
+	file, err := os.Open(filepath.Join(a.directory, day+".jsonl"))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			// a line cut short by a crash
+			continue
+		}
+		if q.matches(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, scanner.Err()
+}
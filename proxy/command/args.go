@@ -0,0 +1,187 @@
+package command
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Type int
+
+const (
+	String Type = iota
+	Int
+	Float
+	Bool
+	Duration
+	// Player is the name of an online player, completed through Registry.AddCompleter.
+	Player
+	// Text takes the rest of the line, it has to be the last param.
+	Text
+)
+
+func (t Type) String() string {
+	return [...]string{"string", "int", "float", "bool", "duration", "player", "text"}[t]
+}
+
+// parse converts a raw argument into the Go value of the type.
+func (t Type) parse(raw string) (interface{}, error) {
+	switch t {
+	case Int:
+		return strconv.Atoi(raw)
+	case Float:
+		return strconv.ParseFloat(raw, 64)
+	case Bool:
+		return strconv.ParseBool(raw)
+	case Duration:
+		return time.ParseDuration(raw)
+	}
+	return raw, nil
+}
+
+type Param struct {
+	Name     string
+	Type     Type
+	Optional bool
+	Complete Completer
+}
+
+func (p Param) usage() string {
+	name := p.Name
+	if p.Type == Text {
+		name += "..."
+	}
+	if p.Optional {
+		return "[" + name + "]"
+	}
+	return "<" + name + ">"
+}
+
+// Args are the arguments of a command, parsed by the types of its params.
+type Args struct {
+	Raw    []string
+	values map[string]interface{}
+}
+
+// parse matches the raw arguments with the params, commands without params take any arguments.
+// rest holds the line as typed from every raw argument on, a Text param takes it with its
+// quotes and backslashes.
+func parse(c *Command, raw, rest []string) (Args, error) {
+	args := Args{Raw: raw, values: make(map[string]interface{}, len(c.Params))}
+	if len(c.Params) == 0 {
+		return args, nil
+	}
+
+	for i, p := range c.Params {
+		if i >= len(raw) {
+			if !p.Optional {
+				return args, UsageError{Command: c, Err: fmt.Errorf("missing %s", p.Name)}
+			}
+			break
+		}
+
+		if p.Type == Text {
+			args.values[p.Name] = rest[i]
+			return args, nil
+		}
+
+		v, err := p.Type.parse(raw[i])
+		if err != nil {
+			return args, UsageError{Command: c, Err: fmt.Errorf("%s is not a valid %s: %q", p.Name, p.Type, raw[i])}
+		}
+		args.values[p.Name] = v
+	}
+
+	if len(raw) > len(c.Params) {
+		return args, UsageError{Command: c, Err: fmt.Errorf("too many arguments")}
+	}
+
+	return args, nil
+}
+
+// Has reports whether an optional param was given.
+func (a Args) Has(name string) bool {
+	_, ok := a.values[name]
+	return ok
+}
+
+func (a Args) String(name string) string {
+	v, _ := a.values[name].(string)
+	return v
+}
+
+func (a Args) Int(name string) int {
+	v, _ := a.values[name].(int)
+	return v
+}
+
+func (a Args) Float(name string) float64 {
+	v, _ := a.values[name].(float64)
+	return v
+}
+
+func (a Args) Bool(name string) bool {
+	v, _ := a.values[name].(bool)
+	return v
+}
+
+func (a Args) Duration(name string) time.Duration {
+	v, _ := a.values[name].(time.Duration)
+	return v
+}
+
+// Split breaks a command line into arguments, double quotes group words and a
+// backslash escapes the next character.
+func Split(line string) []string {
+	args, _ := split(line)
+	return args
+}
+
+// split is Split returning the line as typed from every argument on too.
+func split(line string) ([]string, []string) {
+	var (
+		args    []string
+		rest    []string
+		current strings.Builder
+		quoted  bool
+		escaped bool
+		started bool
+	)
+
+	start := func(i int) {
+		if !started {
+			rest = append(rest, strings.TrimRight(line[i:], " "))
+			started = true
+		}
+	}
+
+	for i, r := range line {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			start(i)
+			escaped = true
+		case r == '"':
+			start(i)
+			quoted = !quoted
+		case r == ' ' && !quoted:
+			if started {
+				args = append(args, current.String())
+				current.Reset()
+				started = false
+			}
+		default:
+			start(i)
+			current.WriteRune(r)
+		}
+	}
+
+	if started {
+		args = append(args, current.String())
+	}
+
+	return args, rest
+}
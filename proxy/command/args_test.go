@@ -0,0 +1,100 @@
+package command
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		line string
+		args []string
+		rest []string
+	}{
+		{"", nil, nil},
+		{"   ", nil, nil},
+		{"kick Steve", []string{"kick", "Steve"}, []string{"kick Steve", "Steve"}},
+		{"  kick   Steve  ", []string{"kick", "Steve"}, []string{"kick   Steve", "Steve"}},
+		{`say "hello world"`, []string{"say", "hello world"}, []string{`say "hello world"`, `"hello world"`}},
+		{`say a"b c"d`, []string{"say", "ab cd"}, []string{`say a"b c"d`, `a"b c"d`}},
+		{`say ""`, []string{"say", ""}, []string{`say ""`, `""`}},
+		{`say \"hi\"`, []string{"say", `"hi"`}, []string{`say \"hi\"`, `\"hi\"`}},
+		{`say a\ b c`, []string{"say", "a b", "c"}, []string{`say a\ b c`, `a\ b c`, "c"}},
+		{`say \\`, []string{"say", `\`}, []string{`say \\`, `\\`}},
+		{`say "unclosed quote`, []string{"say", "unclosed quote"}, []string{`say "unclosed quote`, `"unclosed quote`}},
+		{`say trailing\`, []string{"say", "trailing"}, []string{`say trailing\`, `trailing\`}},
+		{"say héllo wörld", []string{"say", "héllo", "wörld"}, []string{"say héllo wörld", "héllo wörld", "wörld"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			args, rest := split(tt.line)
+			if !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("args of %q = %q, want %q", tt.line, args, tt.args)
+			}
+			if !reflect.DeepEqual(rest, tt.rest) {
+				t.Errorf("rest of %q = %q, want %q", tt.line, rest, tt.rest)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	kick := &Command{Name: "kick", Params: []Param{
+		{Name: "player", Type: Player},
+		{Name: "reason", Type: Text, Optional: true},
+	}}
+	ban := &Command{Name: "ban", Params: []Param{
+		{Name: "player", Type: Player},
+		{Name: "time", Type: Duration},
+		{Name: "count", Type: Int, Optional: true},
+		{Name: "silent", Type: Bool, Optional: true},
+	}}
+	free := &Command{Name: "free"}
+
+	tests := []struct {
+		name    string
+		command *Command
+		line    string
+		values  map[string]interface{}
+		err     string
+	}{
+		{"no params take anything", free, `a "b c" d`, map[string]interface{}{}, ""},
+		{"text", kick, "Steve spamming the chat", map[string]interface{}{"player": "Steve", "reason": "spamming the chat"}, ""},
+		{"text keeps quotes and escapes", kick, `Steve said "hi"  twice\!`, map[string]interface{}{"player": "Steve", "reason": `said "hi"  twice\!`}, ""},
+		{"text of one quoted word", kick, `Steve "bye"`, map[string]interface{}{"player": "Steve", "reason": `"bye"`}, ""},
+		{"quoted player", kick, `"Steve" bye`, map[string]interface{}{"player": "Steve", "reason": "bye"}, ""},
+		{"optional text missing", kick, "Steve", map[string]interface{}{"player": "Steve"}, ""},
+		{"missing", kick, "", nil, "missing player"},
+		{"typed", ban, "Steve 1h 3 true", map[string]interface{}{"player": "Steve", "time": time.Hour, "count": 3, "silent": true}, ""},
+		{"optional missing", ban, "Steve 1h", map[string]interface{}{"player": "Steve", "time": time.Hour}, ""},
+		{"missing required", ban, "Steve", nil, "missing time"},
+		{"invalid", ban, "Steve soon", nil, `time is not a valid duration: "soon"`},
+		{"invalid int", ban, "Steve 1h three", nil, `count is not a valid int: "three"`},
+		{"too many", ban, "Steve 1h 3 true extra", nil, "too many arguments"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw, rest := split(tt.line)
+			args, err := parse(tt.command, raw, rest)
+
+			if tt.err != "" {
+				var usage UsageError
+				if !errors.As(err, &usage) || usage.Err.Error() != tt.err {
+					t.Fatalf("parse(%q) = %v, want %q", tt.line, err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parse(%q) = %v", tt.line, err)
+			}
+			if !reflect.DeepEqual(args.values, tt.values) {
+				t.Errorf("parse(%q) = %v, want %v", tt.line, args.values, tt.values)
+			}
+			if !reflect.DeepEqual(args.Raw, raw) {
+				t.Errorf("raw of %q = %q, want %q", tt.line, args.Raw, raw)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/OCharnyshevich/proxycraft/proxy/helper"
+)
+
+// Sender is whoever runs a command, the console or a player.
+type Sender interface {
+	Name() string
+	SendMessage(message ...interface{})
+}
+
+// IsPlayer reports whether the sender is a player in game, anything else counts as the console.
+func IsPlayer(sender Sender) bool {
+	_, ok := sender.(helper.Sessionable)
+	return ok
+}
+
+// Scope tells where a command can be run from.
+type Scope int
+
+const (
+	Everywhere Scope = iota
+	ConsoleOnly
+	GameOnly
+)
+
+func (s Scope) allows(sender Sender) bool {
+	switch s {
+	case ConsoleOnly:
+		return !IsPlayer(sender)
+	case GameOnly:
+		return IsPlayer(sender)
+	}
+	return true
+}
+
+// Completer suggests values for the argument being typed, args holds the arguments
+// before it and prefix the part typed so far.
+type Completer func(sender Sender, args []string, prefix string) []string
+
+type Command struct {
+	Name        string
+	Aliases     []string
+	Description string
+	Scope       Scope
+	// Permission is the node a player needs to run the command, "proxy.command.<name>" when empty.
+	Permission string
+	Params     []Param
+	// Complete suggests arguments for params without a completer, or for every
+	// argument of a command without params.
+	Complete Completer
+	Run      func(sender Sender, args Args) error
+}
+
+// Node returns the permission node of the command.
+func (c *Command) Node() string {
+	if c.Permission != "" {
+		return c.Permission
+	}
+	return "proxy.command." + c.Name
+}
+
+// Usage is the command line with the params, like "kick <player> [reason...]".
+func (c *Command) Usage() string {
+	parts := []string{c.Name}
+	for _, p := range c.Params {
+		parts = append(parts, p.usage())
+	}
+	return strings.Join(parts, " ")
+}
+
+// UsageError is returned for arguments which do not match the params.
+type UsageError struct {
+	Command *Command
+	Err     error
+}
+
+func (e UsageError) Error() string {
+	return fmt.Sprintf("%v, usage: %s", e.Err, e.Command.Usage())
+}
+
+func (e UsageError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,47 @@
+package command
+
+import (
+	"fmt"
+
+	"github.com/OCharnyshevich/proxycraft/proxy/player/chat"
+)
+
+// Values completes one of the given values.
+func Values(values ...string) Completer {
+	return func(Sender, []string, string) []string {
+		return values
+	}
+}
+
+// Help builds the help command, listing the commands the sender can run or describing one of them.
+func Help(r *Registry) Command {
+	return Command{
+		Name:        "help",
+		Aliases:     []string{"?"},
+		Description: "lists the commands or shows the usage of one",
+		Params: []Param{
+			{Name: "command", Optional: true, Complete: func(sender Sender, _ []string, prefix string) []string {
+				return r.completeName(sender, prefix)
+			}},
+		},
+		Run: func(sender Sender, args Args) error {
+			if args.Has("command") {
+				c, ok := r.Get(args.String("command"))
+				if !ok || !r.Allowed(sender, c) {
+					return fmt.Errorf("unknown command %q", args.String("command"))
+				}
+
+				sender.SendMessage(chat.Gold, c.Usage(), chat.Reset, " - ", c.Description)
+				if len(c.Aliases) > 0 {
+					sender.SendMessage("aliases: ", fmt.Sprint(c.Aliases))
+				}
+				return nil
+			}
+
+			for _, c := range r.Available(sender) {
+				sender.SendMessage(chat.Gold, c.Usage(), chat.Reset, " - ", c.Description)
+			}
+			return nil
+		},
+	}
+}
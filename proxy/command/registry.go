@@ -0,0 +1,246 @@
+package command
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type Registry struct {
+	mu         sync.RWMutex
+	commands   map[string]*Command
+	aliases    map[string]string
+	completers map[Type]Completer
+	permitted  func(sender Sender, node string) bool
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		commands:   make(map[string]*Command),
+		aliases:    make(map[string]string),
+		completers: make(map[Type]Completer),
+	}
+}
+
+// Register adds commands, none of them is added when a name or an alias is already taken.
+func (r *Registry) Register(commands ...Command) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	taken := make(map[string]bool)
+	for _, c := range commands {
+		for _, name := range append([]string{c.Name}, c.Aliases...) {
+			name = strings.ToLower(name)
+			if r.commands[name] != nil || r.aliases[name] != "" || taken[name] {
+				return fmt.Errorf("command %q is already registered", name)
+			}
+			taken[name] = true
+		}
+	}
+
+	for i := range commands {
+		c := commands[i]
+		c.Name = strings.ToLower(c.Name)
+		r.commands[c.Name] = &c
+
+		for _, alias := range c.Aliases {
+			r.aliases[strings.ToLower(alias)] = c.Name
+		}
+	}
+
+	return nil
+}
+
+// Unregister removes commands together with their aliases.
+func (r *Registry) Unregister(names ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, name := range names {
+		c := r.commands[strings.ToLower(name)]
+		if c == nil {
+			continue
+		}
+
+		for _, alias := range c.Aliases {
+			delete(r.aliases, strings.ToLower(alias))
+		}
+		delete(r.commands, c.Name)
+	}
+}
+
+// Get finds a command by its name or an alias.
+func (r *Registry) Get(name string) (*Command, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.get(name)
+}
+
+func (r *Registry) get(name string) (*Command, bool) {
+	name = strings.ToLower(name)
+	if alias, ok := r.aliases[name]; ok {
+		name = alias
+	}
+
+	c, ok := r.commands[name]
+	return c, ok
+}
+
+// Commands lists the registered commands ordered by name.
+func (r *Registry) Commands() []*Command {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	list := make([]*Command, 0, len(r.commands))
+	for _, c := range r.commands {
+		list = append(list, c)
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+
+	return list
+}
+
+// Available lists the commands the sender can run, ordered by name.
+func (r *Registry) Available(sender Sender) []*Command {
+	var list []*Command
+	for _, c := range r.Commands() {
+		if r.Allowed(sender, c) {
+			list = append(list, c)
+		}
+	}
+	return list
+}
+
+// SetPermissions installs the permission check, every sender may run every command without one.
+func (r *Registry) SetPermissions(permitted func(sender Sender, node string) bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.permitted = permitted
+}
+
+// Allowed reports whether the sender can run the command, by its scope and its permission.
+func (r *Registry) Allowed(sender Sender, c *Command) bool {
+	if !c.Scope.allows(sender) {
+		return false
+	}
+
+	return r.Permitted(sender, c.Node())
+}
+
+// Permitted reports whether the sender holds the permission node, every sender does
+// without a permission check.
+func (r *Registry) Permitted(sender Sender, node string) bool {
+	r.mu.RLock()
+	permitted := r.permitted
+	r.mu.RUnlock()
+
+	return permitted == nil || permitted(sender, node)
+}
+
+// AddCompleter registers the completion of every param of the given type without its own completer.
+func (r *Registry) AddCompleter(t Type, c Completer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.completers[t] = c
+}
+
+// Execute parses a command line and runs the command on behalf of the sender.
+func (r *Registry) Execute(sender Sender, line string) error {
+	raw, rest := split(line)
+	if len(raw) == 0 {
+		return nil
+	}
+
+	c, ok := r.Get(raw[0])
+	if !ok || !c.Scope.allows(sender) {
+		return fmt.Errorf("unknown command %q, type help for a list of commands", raw[0])
+	}
+	if !r.Allowed(sender, c) {
+		return fmt.Errorf("you do not have the permission %s", c.Node())
+	}
+
+	args, err := parse(c, raw[1:], rest[1:])
+	if err != nil {
+		return err
+	}
+
+	return c.Run(sender, args)
+}
+
+// Complete suggests replacements for the last word of a partial command line.
+func (r *Registry) Complete(sender Sender, line string) []string {
+	raw := Split(line)
+	if len(raw) == 0 || strings.HasSuffix(line, " ") {
+		raw = append(raw, "")
+	}
+	prefix := raw[len(raw)-1]
+
+	if len(raw) == 1 {
+		return r.completeName(sender, prefix)
+	}
+
+	c, ok := r.Get(raw[0])
+	if !ok || !r.Allowed(sender, c) {
+		return nil
+	}
+
+	r.mu.RLock()
+	completer := r.completer(c, len(raw)-2)
+	r.mu.RUnlock()
+
+	if completer == nil {
+		return nil
+	}
+
+	return filter(completer(sender, raw[1:len(raw)-1], prefix), prefix)
+}
+
+func (r *Registry) completer(c *Command, index int) Completer {
+	if len(c.Params) == 0 {
+		return c.Complete
+	}
+
+	if index >= len(c.Params) {
+		// the words of a text param share its completion
+		last := c.Params[len(c.Params)-1]
+		if last.Type != Text {
+			return nil
+		}
+		index = len(c.Params) - 1
+	}
+
+	p := c.Params[index]
+	switch {
+	case p.Complete != nil:
+		return p.Complete
+	case r.completers[p.Type] != nil:
+		return r.completers[p.Type]
+	}
+	return c.Complete
+}
+
+func (r *Registry) completeName(sender Sender, prefix string) []string {
+	var names []string
+	for _, c := range r.Available(sender) {
+		names = append(names, c.Name)
+		names = append(names, c.Aliases...)
+	}
+
+	sort.Strings(names)
+	return filter(names, prefix)
+}
+
+func filter(values []string, prefix string) []string {
+	var matches []string
+	for _, v := range values {
+		if strings.HasPrefix(strings.ToLower(v), strings.ToLower(prefix)) {
+			matches = append(matches, v)
+		}
+	}
+	return matches
+}
@@ -0,0 +1,409 @@
+package proxy
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/OCharnyshevich/proxycraft/proxy/command"
+	"github.com/OCharnyshevich/proxycraft/proxy/dashboard"
+	"github.com/OCharnyshevich/proxycraft/proxy/helper"
+	"github.com/OCharnyshevich/proxycraft/proxy/log"
+	"github.com/OCharnyshevich/proxycraft/proxy/network"
+	"github.com/OCharnyshevich/proxycraft/proxy/player/chat"
+	"github.com/google/uuid"
+)
+
+func (p *proxy) registerCommands() {
+	players := func(command.Sender, []string, string) []string {
+		var names []string
+		for _, session := range p.network.Sessions() {
+			if name := session.Name(); name != "" {
+				names = append(names, name)
+			}
+		}
+		return names
+	}
+	p.commands.AddCompleter(command.Player, players)
+
+	err := p.commands.Register(
+		command.Help(p.commands),
+		command.Command{
+			Name:        "list",
+			Aliases:     []string{"players"},
+			Description: "lists the connected players",
+			Run:         p.list,
+		},
+		command.Command{
+			Name:        "kick",
+			Description: "disconnects a player",
+			Params: []command.Param{
+				{Name: "player", Type: command.Player},
+				{Name: "reason", Type: command.Text, Optional: true},
+			},
+			Run: p.kick,
+		},
+		command.Command{
+			Name:        "broadcast",
+			Aliases:     []string{"say"},
+			Description: "sends a chat message to every player",
+			Params:      []command.Param{{Name: "message", Type: command.Text}},
+			Run: func(_ command.Sender, args command.Args) error {
+				p.Broadcast(chat.Translate(args.String("message")))
+				return nil
+			},
+		},
+		command.Command{
+			Name:        "send",
+			Description: "moves a player to another backend",
+			Params: []command.Param{
+				{Name: "player", Type: command.Player},
+				{Name: "backend", Complete: func(command.Sender, []string, string) []string { return p.servers() }},
+			},
+			Run: p.send,
+		},
+		command.Command{
+			Name:        "stop",
+			Aliases:     []string{"end"},
+			Description: "disconnects every player and stops the proxy",
+			Scope:       command.ConsoleOnly,
+			Run: func(sender command.Sender, _ command.Args) error {
+				sender.SendMessage("stopping the proxy")
+				p.Kill()
+				return nil
+			},
+		},
+		command.Command{
+			Name:        "reload",
+			Description: "reloads the plugins",
+			Scope:       command.ConsoleOnly,
+			Run: func(sender command.Sender, _ command.Args) error {
+				p.plugins.Kill()
+				p.plugins.Load()
+				sender.SendMessage(fmt.Sprintf("reloaded %d plugins", len(p.plugins.Plugins())))
+				return nil
+			},
+		},
+		command.Command{
+			Name:        "loglevel",
+			Description: "shows, changes or resets the level of a logger, patterns like session.* change every matching logger",
+			Scope:       command.ConsoleOnly,
+			Params: []command.Param{
+				{Name: "logger", Optional: true, Complete: func(command.Sender, []string, string) []string { return loggers() }},
+				{Name: "level", Optional: true, Complete: command.Values("data", "info", "warn", "fail", "off", "reset")},
+			},
+			Run: p.logLevel,
+		},
+		command.Command{
+			Name:        "logs",
+			Description: "searches the latest log entries, like logs logger=session.* level=warn since=10m until=12:30 limit=50 text...",
+			Scope:       command.ConsoleOnly,
+			Complete: func(_ command.Sender, _ []string, prefix string) []string {
+				if strings.HasPrefix(prefix, "logger=") {
+					var values []string
+					for _, name := range loggers() {
+						values = append(values, "logger="+name)
+					}
+					return values
+				}
+				return []string{"logger=", "level=", "since=", "until=", "limit="}
+			},
+			Run: p.searchLogs,
+		},
+		command.Command{
+			Name:        "chatlog",
+			Description: "searches the chat archive, like chatlog player=Steve since=2h until=12:30 limit=50 text...",
+			Scope:       command.ConsoleOnly,
+			Complete: func(sender command.Sender, args []string, prefix string) []string {
+				if strings.HasPrefix(prefix, "player=") {
+					var values []string
+					for _, name := range players(sender, args, "") {
+						values = append(values, "player="+name)
+					}
+					return values
+				}
+				return []string{"player=", "since=", "until=", "limit="}
+			},
+			Run: p.searchChat,
+		},
+		p.permissions.Command(p.lookup, players),
+	)
+	if err != nil {
+		p.logging.Fail(err)
+	}
+}
+
+// dispatch runs the lines typed into the console as commands, until the console is killed.
+func (p *proxy) dispatch() {
+	for line := range p.console.IChannel {
+		line = strings.TrimPrefix(strings.TrimSpace(line), "/")
+
+		var err error
+		if attempt := helper.Attempt(func() { err = p.commands.Execute(p.console, line) }); attempt != nil {
+			err = attempt
+		}
+		if err != nil {
+			p.console.Logger().Fail(err)
+		}
+	}
+}
+
+// player finds an online player by name.
+func (p *proxy) player(name string) (helper.Sessionable, error) {
+	for _, session := range p.network.Sessions() {
+		if strings.EqualFold(session.Name(), name) {
+			return session, nil
+		}
+	}
+	return nil, fmt.Errorf("player %s is not online", name)
+}
+
+// lookup resolves an online player by name, or any player by UUID.
+func (p *proxy) lookup(player string) (uuid.UUID, string, error) {
+	if id, err := uuid.Parse(player); err == nil {
+		for _, session := range p.network.Sessions() {
+			if session.UUID() == id {
+				return id, session.Name(), nil
+			}
+		}
+		if u, ok := p.permissions.User(id); ok && u.Name != "" {
+			return id, u.Name, nil
+		}
+		return id, player, nil
+	}
+
+	session, err := p.player(player)
+	if err != nil {
+		return uuid.Nil, "", fmt.Errorf("%w, use the UUID of offline players", err)
+	}
+	return session.UUID(), session.Name(), nil
+}
+
+// backends lists the backends with their addresses, the default one first.
+func (p *proxy) backends() []dashboard.Backend {
+	backends := []dashboard.Backend{{Name: network.DefaultServer, Host: p.config.Remote.Host, Port: p.config.Remote.Port}}
+	for _, name := range p.servers() {
+		if server, ok := p.config.Servers[name]; ok {
+			backends = append(backends, dashboard.Backend{Name: name, Host: server.Host, Port: server.Port})
+		}
+	}
+	return backends
+}
+
+// servers lists the names of the backends players can be sent to.
+func (p *proxy) servers() []string {
+	names := []string{network.DefaultServer}
+	for name := range p.config.Servers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (p *proxy) list(sender command.Sender, _ command.Args) error {
+	sessions := p.network.Sessions()
+	sender.SendMessage(chat.Gold, len(sessions), " connected", chat.Reset)
+
+	for _, session := range sessions {
+		name := session.Name()
+		if name == "" {
+			name = "-"
+		}
+
+		backend := "unknown version"
+		if v := session.Backend(); v != nil {
+			backend = v.String()
+		}
+
+		uptime := time.Since(session.StartTime()).Round(time.Second)
+		sender.SendMessage(fmt.Sprintf("%s %s on %s, %s, for %v", name, session.Address(), session.Server(), backend, uptime))
+	}
+
+	return nil
+}
+
+func (p *proxy) kick(sender command.Sender, args command.Args) error {
+	session, err := p.player(args.String("player"))
+	if err != nil {
+		return err
+	}
+
+	reason := "Kicked from the proxy"
+	if args.Has("reason") {
+		reason = chat.Translate(args.String("reason"))
+	}
+
+	session.Disconnect(reason)
+	p.logging.InfoF("%s kicked %s: %s", sender.Name(), session.Name(), reason)
+	return nil
+}
+
+func (p *proxy) send(sender command.Sender, args command.Args) error {
+	session, err := p.player(args.String("player"))
+	if err != nil {
+		return err
+	}
+
+	name := args.String("backend")
+	backend := p.config.Remote
+	if name != network.DefaultServer {
+		var ok bool
+		if backend, ok = p.config.Servers[name]; !ok {
+			return fmt.Errorf("unknown backend %q, backends are %s", name, strings.Join(p.servers(), ", "))
+		}
+	}
+
+	if session.Server() == name {
+		return errors.New(session.Name() + " is already on " + name)
+	}
+
+	if err := session.Connect(name, backend.Host, backend.Port); err != nil {
+		return fmt.Errorf("unable to send %s to %s: %v", session.Name(), name, err)
+	}
+
+	sender.SendMessage("sent ", session.Name(), " to ", name)
+	return nil
+}
+
+func (p *proxy) logLevel(sender command.Sender, args command.Args) error {
+	if !args.Has("logger") {
+		names := log.Names()
+		for _, name := range names {
+			sender.SendMessage(name, ": ", level(name))
+		}
+		// rules of patterns and of loggers not created yet
+		levels := log.Levels()
+		for _, name := range names {
+			delete(levels, name)
+		}
+		patterns := make([]string, 0, len(levels))
+		for pattern := range levels {
+			patterns = append(patterns, pattern)
+		}
+		sort.Strings(patterns)
+		for _, pattern := range patterns {
+			sender.SendMessage(pattern, ": ", levels[pattern])
+		}
+		return nil
+	}
+
+	pattern := args.String("logger")
+	if !args.Has("level") {
+		if min, ok := log.Level(pattern); ok {
+			sender.SendMessage(pattern, ": ", min)
+		} else {
+			sender.SendMessage(pattern, ": ", level(pattern))
+		}
+		return nil
+	}
+
+	if strings.EqualFold(args.String("level"), "reset") {
+		if !log.UnsetLevel(pattern) {
+			return fmt.Errorf("no level is set for %s", pattern)
+		}
+		sender.SendMessage(pattern, ": reset")
+		return nil
+	}
+
+	min, err := log.ParseLevel(args.String("level"))
+	if err != nil {
+		return err
+	}
+	if err := log.SetLevel(pattern, min); err != nil {
+		return err
+	}
+
+	sender.SendMessage(pattern, ": ", min)
+	return nil
+}
+
+// searchLogs prints the entries of the log buffer matching the key=value filters, the other
+// words are the searched text.
+func (p *proxy) searchLogs(sender command.Sender, args command.Args) error {
+	if p.buffer == nil {
+		return errors.New("the log buffer is off, set Config.LogBuffer")
+	}
+
+	query := log.Query{Limit: 20}
+	var text []string
+	for _, arg := range args.Raw {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok {
+			text = append(text, arg)
+			continue
+		}
+
+		var err error
+		switch key {
+		case "logger":
+			query.Logger = value
+		case "level":
+			query.Level, err = log.ParseLevel(value)
+		case "since":
+			query.Since, err = parseTime(value)
+		case "until":
+			query.Until, err = parseTime(value)
+		case "limit":
+			query.Limit, err = strconv.Atoi(value)
+		default:
+			text = append(text, arg)
+		}
+		if err != nil {
+			return fmt.Errorf("bad %s: %w", key, err)
+		}
+	}
+	query.Text = strings.Join(text, " ")
+
+	entries := p.buffer.Search(query)
+	for _, entry := range entries {
+		sender.SendMessage(strings.TrimSuffix(string(log.Text.Encode(entry)), "\n"))
+	}
+	sender.SendMessage(fmt.Sprintf("%d of %d entries", len(entries), p.buffer.Len()))
+	return nil
+}
+
+// parseTime reads a duration before now like 10m, a time of today like 12:30 or 12:30:05,
+// or an RFC 3339 time.
+func parseTime(value string) (time.Time, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	for _, layout := range []string{"15:04", "15:04:05"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			now := time.Now()
+			return time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.Local), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%q is not a duration, a time of day or an RFC 3339 time", value)
+}
+
+// loggers lists the logger names and the patterns with a level.
+func loggers() []string {
+	names := append(log.Names(), "*")
+	for pattern := range log.Levels() {
+		if pattern != "*" && strings.ContainsAny(pattern, "*?[") {
+			names = append(names, pattern)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// level describes the threshold of the loggers with the name and the rule it comes from.
+func level(name string) string {
+	rule, min, ok := log.Rule(name)
+	switch {
+	case !ok:
+		return "info (default)"
+	case rule != name:
+		return min.String() + " (" + rule + ")"
+	}
+	return min.String()
+}
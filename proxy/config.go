@@ -1,22 +1,84 @@
 package proxy
 
+import (
+	"github.com/OCharnyshevich/proxycraft/proxy/log"
+	"github.com/OCharnyshevich/proxycraft/proxy/plugin"
+)
+
 var NewConfig = Config{
 	Local: Network{
 		Host: "0.0.0.0",
 		Port: 25566,
 	},
 	Remote: Network{
-		Host: "127.0.0.1",
-		Port: 25565,
+		Host:     "127.0.0.1",
+		Port:     25565,
+		Protocol: 757,
+	},
+	Commands: Commands{
+		Prefixes: []string{"/proxy", "/p"},
+	},
+	Permissions: "permissions.json",
+	Console: Console{
+		History: ".console_history",
+	},
+	Logs: log.FileConfig{
+		Directory: "logs",
+		MaxSize:   10 << 20,
+		MaxAge:    30,
+		Encoding:  log.Text,
 	},
+	LogBuffer: 5000,
+	ChatLog:   "chatlog",
 }
 
 type Config struct {
 	Local  Network
 	Remote Network
+	// Servers are further backends players can be sent to by name, they have to
+	// speak the protocol of Remote and run in offline mode.
+	Servers  map[string]Network
+	Commands Commands
+	// Permissions is the file keeping the permission groups and players.
+	Permissions string
+	Console     Console
+	// Logs configures logs/latest.log and its archives.
+	Logs log.FileConfig
+	// LogLevels sets the level of the loggers by name or by pattern, like "session.*": "data".
+	// Loggers no pattern matches show info and above.
+	LogLevels map[string]string
+	// LogBuffer is the number of the latest log entries kept in memory for the logs command
+	// and the event stream, zero keeps none.
+	LogBuffer int
+	// ChatLog is the directory archiving the chat messages in a YYYY-MM-DD.jsonl file per day,
+	// nothing is archived when empty.
+	ChatLog string
+	// Language is a vanilla language file, like "lang/de_de.json", resolving the translated
+	// texts shown in the console and the logs. The bundled en_us table is used when empty.
+	Language string
+	// Dashboard replaces the console with a full-screen view of the sessions and the traffic.
+	Dashboard bool
+	// Plugins holds the config section of every plugin, keyed by the plugin name.
+	Plugins map[string]plugin.Config
 }
 
 type Network struct {
 	Host string
 	Port int
+	// Protocol of the backend, clients on other versions are translated to it.
+	// Zero passes every client through as is.
+	Protocol int32
+}
+
+type Commands struct {
+	// Prefixes start proxy commands in game chat, like "/proxy list". The chat message
+	// is handled by the proxy and not forwarded to the backend.
+	Prefixes []string
+}
+
+type Console struct {
+	// History is the file keeping the lines entered into the console.
+	History string
+	// Encoding of the log lines printed by the console, log.Console when empty.
+	Encoding log.Encoding
 }
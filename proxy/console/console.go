@@ -2,10 +2,13 @@ package console
 
 import (
 	"bufio"
-	"github.com/OCharnyshevich/proxycraft/proxy/helper"
-	"github.com/OCharnyshevich/proxycraft/proxy/log"
+	"errors"
 	"io"
 	"os"
+
+	"github.com/OCharnyshevich/proxycraft/proxy/helper"
+	"github.com/OCharnyshevich/proxycraft/proxy/log"
+	"golang.org/x/term"
 )
 
 type Console struct {
@@ -18,6 +21,11 @@ type Console struct {
 	OChannel chan string
 
 	report chan helper.Message
+
+	history  string
+	complete func(line string) []string
+	// state is the terminal mode before the line editor switched it to raw
+	state *term.State
 }
 
 func New(report chan helper.Message) *Console {
@@ -31,26 +39,36 @@ func New(report chan helper.Message) *Console {
 	console.i = io.MultiReader(os.Stdin)
 	console.o = io.MultiWriter(os.Stdout)
 
-	console.logger = log.NewWith("console", console.o, log.EveryLevel...)
+	console.logger = log.New("console")
 
 	return console
 }
 
-func (c *Console) Load() {
-	// handle i channel
-	go func() {
-		scanner := bufio.NewScanner(c.i)
+// SetHistory sets the file keeping the entered lines between runs, call it before Load.
+func (c *Console) SetHistory(path string) {
+	c.history = path
+}
+
+// SetInput sets where the lines are read from, nil reads no lines. Call it before Load.
+func (c *Console) SetInput(r io.Reader) {
+	c.i = r
+}
 
-		for scanner.Scan() {
-			err := helper.Attempt(func() {
-				c.IChannel <- scanner.Text()
-			})
+// SetCompleter sets the tab completion of the line editor, it returns the
+// replacements for the last word of the line. Call it before Load.
+func (c *Console) SetCompleter(complete func(line string) []string) {
+	c.complete = complete
+}
 
-			if err != nil {
-				c.report <- helper.Make(helper.FAIL, err)
-			}
-		}
-	}()
+func (c *Console) Load() {
+	// handle i channel
+	switch {
+	case c.i == nil:
+	case c.interactive():
+		go c.edit()
+	default:
+		go c.scan()
+	}
 
 	// handle o channel
 	go func() {
@@ -60,17 +78,89 @@ func (c *Console) Load() {
 	}()
 }
 
+// interactive switches the terminal to raw mode for the line editor, false when
+// stdin or stdout is not a terminal and the lines are read as they come.
+func (c *Console) interactive() bool {
+	in, out := int(os.Stdin.Fd()), int(os.Stdout.Fd())
+	if !term.IsTerminal(in) || !term.IsTerminal(out) {
+		return false
+	}
+
+	state, err := term.MakeRaw(in)
+	if err != nil {
+		c.logger.WarnF("unable to start the line editor: %v", err)
+		return false
+	}
+	c.state = state
+
+	return true
+}
+
+func (c *Console) scan() {
+	scanner := bufio.NewScanner(c.i)
+
+	for scanner.Scan() {
+		c.Input(scanner.Text())
+	}
+}
+
+func (c *Console) edit() {
+	e := newEditor(c.i, c.o, loadHistory(c.history), c.complete)
+	// log lines are printed above the line being typed
+	log.SetOutput(e)
+
+	for {
+		line, err := e.readLine()
+		if errors.Is(err, errInterrupt) {
+			// the raw terminal sends no SIGINT, Ctrl-C on an empty line stops the proxy instead
+			c.Input("stop")
+			return
+		}
+		if err != nil {
+			return
+		}
+
+		c.Input(line)
+	}
+}
+
+// Input runs a line as if it was typed into the console.
+func (c *Console) Input(line string) {
+	err := helper.Attempt(func() {
+		c.IChannel <- line
+	})
+
+	if err != nil {
+		c.report <- helper.Make(helper.FAIL, err)
+	}
+}
+
 func (c *Console) Kill() {
 	defer func() {
 		_ = recover() // ignore panic with closing closed channel
 	}()
 
-	// save the log file as YYYY-MM-DD-{index}.log{.gz optionally compressed}
+	c.restore()
 
 	close(c.IChannel)
 	close(c.OChannel)
 }
 
+// restore leaves the raw mode of the terminal.
+func (c *Console) restore() {
+	if c.state == nil {
+		return
+	}
+
+	log.SetOutput(c.o)
+	_ = term.Restore(int(os.Stdin.Fd()), c.state)
+	c.state = nil
+}
+
+func (c *Console) Logger() *log.Logging {
+	return c.logger
+}
+
 func (c *Console) Name() string {
 	return "ConsoleSender"
 }
@@ -0,0 +1,345 @@
+package console
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// errInterrupt is returned by readLine for Ctrl-C and Ctrl-D on an empty line.
+var errInterrupt = errors.New("interrupted")
+
+const prompt = "> "
+
+// editor reads lines from a terminal in raw mode. It moves the cursor, browses the
+// history and completes words, and output written through it is printed above the line.
+type editor struct {
+	in  *bufio.Reader
+	out io.Writer
+
+	complete func(line string) []string
+	history  *history
+
+	mu      sync.Mutex
+	reading bool
+	line    []rune
+	pos     int
+	// index in the history while browsing it, draft keeps the line typed before
+	index int
+	draft []rune
+}
+
+func newEditor(in io.Reader, out io.Writer, history *history, complete func(line string) []string) *editor {
+	return &editor{
+		in:       bufio.NewReader(in),
+		out:      out,
+		history:  history,
+		complete: complete,
+	}
+}
+
+// Write prints log output above the line being edited.
+func (e *editor) Write(p []byte) (int, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var buf bytes.Buffer
+	buf.WriteString("\r\x1b[K")
+	// the terminal does not return the carriage in raw mode
+	buf.Write(bytes.ReplaceAll(p, []byte("\n"), []byte("\r\n")))
+	if !bytes.HasSuffix(p, []byte("\n")) {
+		buf.WriteString("\r\n")
+	}
+	if e.reading {
+		e.render(&buf)
+	}
+
+	if _, err := e.out.Write(buf.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// readLine edits a line until it is entered.
+func (e *editor) readLine() (string, error) {
+	e.mu.Lock()
+	e.reading = true
+	e.line, e.pos = nil, 0
+	e.index = len(e.history.lines)
+	e.refresh()
+	e.mu.Unlock()
+
+	for {
+		// the key is read without the lock, logging goes on while waiting for it
+		key, err := e.readKey()
+		if err != nil {
+			return "", err
+		}
+
+		if key == "\t" {
+			e.tab()
+			continue
+		}
+
+		e.mu.Lock()
+		line, done, err := e.key(key)
+		e.mu.Unlock()
+
+		if done || err != nil {
+			return line, err
+		}
+	}
+}
+
+// readKey reads a key press, the escape sequences of the arrow, home, end and delete
+// keys are read whole and named like "up".
+func (e *editor) readKey() (string, error) {
+	r, _, err := e.in.ReadRune()
+	if err != nil {
+		return "", err
+	}
+	if r == 0x1b {
+		return e.escape(), nil
+	}
+	return string(r), nil
+}
+
+// escape reads the rest of an escape sequence and names its key, "esc" for the Escape
+// key alone and empty for the sequences of other keys.
+func (e *editor) escape() string {
+	// the terminal writes a sequence at once, nothing buffered is a lone Escape
+	if e.in.Buffered() == 0 {
+		return "esc"
+	}
+
+	r, _, err := e.in.ReadRune()
+	if err != nil || (r != '[' && r != 'O') {
+		return "esc"
+	}
+
+	var params strings.Builder
+	for {
+		r, _, err = e.in.ReadRune()
+		if err != nil {
+			return ""
+		}
+		if r >= 0x40 && r <= 0x7e {
+			break
+		}
+		params.WriteRune(r)
+	}
+
+	switch {
+	case r == 'A':
+		return "up"
+	case r == 'B':
+		return "down"
+	case r == 'C':
+		return "right"
+	case r == 'D':
+		return "left"
+	case r == 'H' || (r == '~' && (params.String() == "1" || params.String() == "7")):
+		return "home"
+	case r == 'F' || (r == '~' && (params.String() == "4" || params.String() == "8")):
+		return "end"
+	case r == '~' && params.String() == "3":
+		return "delete"
+	}
+	return ""
+}
+
+// key handles a key press, done is true when the line is finished. e.mu has to be held.
+func (e *editor) key(key string) (string, bool, error) {
+	switch key {
+	case "\r", "\n":
+		line := string(e.line)
+		e.reading = false
+		e.write("\r\n")
+		e.history.add(line)
+		return line, true, nil
+	case "\x03": // Ctrl-C
+		if len(e.line) == 0 {
+			e.reading = false
+			e.write("^C\r\n")
+			return "", true, errInterrupt
+		}
+		e.line, e.pos = nil, 0
+	case "\x04": // Ctrl-D
+		if len(e.line) == 0 {
+			e.reading = false
+			e.write("\r\n")
+			return "", true, errInterrupt
+		}
+		e.delete(e.pos)
+	case "\x7f", "\x08": // Backspace
+		if e.pos > 0 {
+			e.pos--
+			e.delete(e.pos)
+		}
+	case "\x01", "home": // Ctrl-A
+		e.pos = 0
+	case "\x05", "end": // Ctrl-E
+		e.pos = len(e.line)
+	case "\x02", "left": // Ctrl-B
+		e.left()
+	case "\x06", "right": // Ctrl-F
+		e.right()
+	case "\x10", "up": // Ctrl-P
+		e.previous()
+	case "\x0e", "down": // Ctrl-N
+		e.next()
+	case "delete":
+		e.delete(e.pos)
+	case "\x0b": // Ctrl-K
+		e.line = e.line[:e.pos]
+	case "\x15": // Ctrl-U
+		e.line = append([]rune{}, e.line[e.pos:]...)
+		e.pos = 0
+	case "\x17": // Ctrl-W
+		start := e.pos
+		for start > 0 && e.line[start-1] == ' ' {
+			start--
+		}
+		for start > 0 && e.line[start-1] != ' ' {
+			start--
+		}
+		e.line = append(e.line[:start], e.line[e.pos:]...)
+		e.pos = start
+	case "\x0c": // Ctrl-L
+		e.write("\x1b[H\x1b[2J")
+	default:
+		// named keys like "up" are longer than one rune
+		r := []rune(key)
+		if len(r) != 1 || r[0] < 0x20 {
+			return "", false, nil
+		}
+		e.line = append(e.line[:e.pos], append(r, e.line[e.pos:]...)...)
+		e.pos++
+	}
+
+	e.refresh()
+	return "", false, nil
+}
+
+func (e *editor) left() {
+	if e.pos > 0 {
+		e.pos--
+	}
+}
+
+func (e *editor) right() {
+	if e.pos < len(e.line) {
+		e.pos++
+	}
+}
+
+func (e *editor) delete(at int) {
+	if at < len(e.line) {
+		e.line = append(e.line[:at], e.line[at+1:]...)
+	}
+}
+
+func (e *editor) previous() {
+	if e.index == 0 {
+		return
+	}
+	if e.index == len(e.history.lines) {
+		e.draft = e.line
+	}
+
+	e.index--
+	e.line = []rune(e.history.lines[e.index])
+	e.pos = len(e.line)
+}
+
+func (e *editor) next() {
+	if e.index >= len(e.history.lines) {
+		return
+	}
+
+	e.index++
+	if e.index == len(e.history.lines) {
+		e.line = e.draft
+	} else {
+		e.line = []rune(e.history.lines[e.index])
+	}
+	e.pos = len(e.line)
+}
+
+// tab replaces the word before the cursor with the only match or the common part of
+// the matches, and lists the matches when there is nothing to add. The completer runs
+// without e.mu, only readLine changes the line so it is the same afterwards.
+func (e *editor) tab() {
+	if e.complete == nil {
+		return
+	}
+
+	e.mu.Lock()
+	before := string(e.line[:e.pos])
+	e.mu.Unlock()
+
+	matches := e.complete(before)
+	if len(matches) == 0 {
+		return
+	}
+
+	start := strings.LastIndex(before, " ") + 1
+	word := before[start:]
+
+	replacement := matches[0]
+	if len(matches) == 1 {
+		replacement += " "
+	} else {
+		for _, m := range matches[1:] {
+			replacement = commonPrefix(replacement, m)
+		}
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if len(matches) > 1 && len([]rune(replacement)) <= len([]rune(word)) {
+		e.write("\r\x1b[K" + strings.Join(matches, "  ") + "\r\n")
+		e.refresh()
+		return
+	}
+
+	head := []rune(before[:start] + replacement)
+	e.line = append(head, e.line[e.pos:]...)
+	e.pos = len(head)
+	e.refresh()
+}
+
+func commonPrefix(a, b string) string {
+	ra, rb := []rune(a), []rune(b)
+	i := 0
+	for i < len(ra) && i < len(rb) && strings.EqualFold(string(ra[i]), string(rb[i])) {
+		i++
+	}
+	return string(ra[:i])
+}
+
+// refresh redraws the prompt and the line.
+func (e *editor) refresh() {
+	var buf bytes.Buffer
+	buf.WriteString("\r")
+	e.render(&buf)
+	_, _ = e.out.Write(buf.Bytes())
+}
+
+func (e *editor) render(buf *bytes.Buffer) {
+	buf.WriteString(prompt)
+	buf.WriteString(string(e.line))
+	buf.WriteString("\x1b[K")
+	if back := len(e.line) - e.pos; back > 0 {
+		_, _ = fmt.Fprintf(buf, "\x1b[%dD", back)
+	}
+}
+
+func (e *editor) write(s string) {
+	_, _ = io.WriteString(e.out, s)
+}
@@ -0,0 +1,67 @@
+package console
+
+import (
+	"bufio"
+	"os"
+	"strings"
+)
+
+// historySize is the number of lines kept in the history file.
+const historySize = 500
+
+// history holds the entered lines, the newest last, and appends them to a file.
+type history struct {
+	path  string
+	lines []string
+}
+
+// loadHistory reads the history file, a missing file starts an empty history.
+func loadHistory(path string) *history {
+	h := &history{path: path}
+	if path == "" {
+		return h
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return h
+	}
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			h.lines = append(h.lines, line)
+		}
+	}
+	_ = file.Close()
+
+	if len(h.lines) > historySize {
+		h.lines = h.lines[len(h.lines)-historySize:]
+		// shrink the file to the kept lines
+		_ = os.WriteFile(path, []byte(strings.Join(h.lines, "\n")+"\n"), 0600)
+	}
+
+	return h
+}
+
+// add appends a line unless it repeats the last one.
+func (h *history) add(line string) {
+	if strings.TrimSpace(line) == "" || (len(h.lines) > 0 && h.lines[len(h.lines)-1] == line) {
+		return
+	}
+
+	h.lines = append(h.lines, line)
+	if len(h.lines) > historySize {
+		h.lines = h.lines[1:]
+	}
+
+	if h.path == "" {
+		return
+	}
+	file, err := os.OpenFile(h.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return
+	}
+	_, _ = file.WriteString(line + "\n")
+	_ = file.Close()
+}
@@ -0,0 +1,280 @@
+// Package dashboard shows the proxy as a full-screen terminal view: the sessions
+// with their traffic, the log, the packet rates and the health of the backends.
+//
+// The dashboard takes over the terminal in place of the console. Sessions are
+// selected with the arrow keys and kicked or messaged with k and m, and lines typed
+// after : run as console commands.
+package dashboard
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OCharnyshevich/proxycraft/proxy/helper"
+	"github.com/OCharnyshevich/proxycraft/proxy/log"
+	"github.com/OCharnyshevich/proxycraft/proxy/network"
+	mcNet "github.com/Tnze/go-mc/net"
+	"golang.org/x/term"
+)
+
+const (
+	// logSize is the number of log lines kept for scrolling.
+	logSize = 1000
+	// refresh is the interval of the packet rates and the redraw.
+	refresh = time.Second
+	// healthInterval is the interval of the backend status pings.
+	healthInterval = 10 * time.Second
+)
+
+// owner tags the packet listeners of the dashboard.
+const owner = "dashboard"
+
+type Dashboard struct {
+	network  helper.Network
+	backends []Backend
+	run      func(line string)
+	logger   *log.Logging
+
+	state  *term.State
+	screen sync.Mutex // held while a frame is written
+	stop   chan struct{}
+	dirty  chan struct{}
+	once   sync.Once
+
+	mu       sync.Mutex
+	logs     []string
+	partial  []byte
+	scroll   int
+	selected int
+	sessions []helper.Sessionable
+	counts   map[string]uint64
+	rates    []rate
+	health   map[string]health
+	prompt   *prompt
+}
+
+// rate is the number of packets per second of a packet name and direction.
+type rate struct {
+	key   string
+	count uint64
+}
+
+// New creates the dashboard, run executes the lines typed as console commands.
+func New(n helper.Network, backends []Backend, run func(line string)) *Dashboard {
+	return &Dashboard{
+		network:  n,
+		backends: backends,
+		run:      run,
+		logger:   log.New("dashboard"),
+		stop:     make(chan struct{}),
+		dirty:    make(chan struct{}, 1),
+		counts:   make(map[string]uint64),
+		health:   make(map[string]health),
+	}
+}
+
+// Supported reports whether stdin and stdout are a terminal the dashboard can take over.
+func Supported() bool {
+	return term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+func (d *Dashboard) Load() {
+	state, err := term.MakeRaw(int(os.Stdin.Fd()))
+	if err != nil {
+		d.logger.FailF("unable to start the dashboard: %v", err)
+		return
+	}
+	d.state = state
+
+	// alternate screen without a cursor
+	_, _ = os.Stdout.WriteString("\x1b[?1049h\x1b[?25l")
+	log.SetOutput(d)
+
+	events := d.network.Events().(*network.Events)
+	events.AddGeneric(network.PacketHandler{Priority: 0, Owner: owner, F: d.count(true)})
+	events.AddGenericServerbound(network.PacketHandler{Priority: 0, Owner: owner, F: d.count(false)})
+
+	go d.input()
+	go d.tick()
+	go d.checkHealth()
+	go d.draw()
+}
+
+func (d *Dashboard) Kill() {
+	d.once.Do(func() {
+		close(d.stop)
+
+		d.network.Events().(*network.Events).RemoveListeners(owner)
+
+		if d.state == nil {
+			return
+		}
+
+		d.screen.Lock()
+		defer d.screen.Unlock()
+
+		log.SetOutput(os.Stdout)
+		_, _ = os.Stdout.WriteString("\x1b[?25h\x1b[?1049l")
+		_ = term.Restore(int(os.Stdin.Fd()), d.state)
+	})
+}
+
+// Write keeps the log output for the log panel.
+func (d *Dashboard) Write(p []byte) (int, error) {
+	d.mu.Lock()
+	d.partial = append(d.partial, p...)
+	for {
+		i := bytes.IndexByte(d.partial, '\n')
+		if i < 0 {
+			break
+		}
+		d.logs = append(d.logs, string(d.partial[:i]))
+		d.partial = d.partial[i+1:]
+		if d.scroll > 0 {
+			// keep the scrolled view in place
+			d.scroll++
+		}
+	}
+	if len(d.logs) > logSize {
+		d.logs = d.logs[len(d.logs)-logSize:]
+	}
+	d.mu.Unlock()
+
+	d.redraw()
+	return len(p), nil
+}
+
+// count returns a generic listener counting the packets of a direction.
+func (d *Dashboard) count(clientbound bool) func(*mcNet.Conn, *mcNet.Conn, network.Packet) error {
+	arrow := "→ "
+	if clientbound {
+		arrow = "← "
+	}
+
+	return func(_ *mcNet.Conn, _ *mcNet.Conn, packet network.Packet) error {
+		name := string(packet.Name)
+		if name == "" {
+			name = unknown(packet.ID)
+		}
+
+		d.mu.Lock()
+		d.counts[arrow+name]++
+		d.mu.Unlock()
+		return nil
+	}
+}
+
+// tick turns the packet counts into rates and refreshes the sessions every second.
+func (d *Dashboard) tick() {
+	ticker := time.NewTicker(refresh)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-d.stop:
+			return
+		case <-ticker.C:
+		}
+
+		sessions := d.network.Sessions()
+		sort.Slice(sessions, func(i, j int) bool { return sessions[i].ID() < sessions[j].ID() })
+
+		d.mu.Lock()
+		d.rates = d.rates[:0]
+		for key, count := range d.counts {
+			d.rates = append(d.rates, rate{key: key, count: count})
+		}
+		sort.Slice(d.rates, func(i, j int) bool {
+			if d.rates[i].count != d.rates[j].count {
+				return d.rates[i].count > d.rates[j].count
+			}
+			return d.rates[i].key < d.rates[j].key
+		})
+		d.counts = make(map[string]uint64)
+
+		d.sessions = sessions
+		if d.selected >= len(sessions) {
+			d.selected = len(sessions) - 1
+		}
+		if d.selected < 0 {
+			d.selected = 0
+		}
+		d.mu.Unlock()
+
+		d.redraw()
+	}
+}
+
+func (d *Dashboard) checkHealth() {
+	for {
+		for _, b := range d.backends {
+			h := ping(b)
+
+			d.mu.Lock()
+			d.health[b.Name] = h
+			d.mu.Unlock()
+		}
+		d.redraw()
+
+		select {
+		case <-d.stop:
+			return
+		case <-time.After(healthInterval):
+		}
+	}
+}
+
+// redraw asks for a new frame, frames are drawn one at a time.
+func (d *Dashboard) redraw() {
+	select {
+	case d.dirty <- struct{}{}:
+	default:
+	}
+}
+
+func (d *Dashboard) draw() {
+	for {
+		select {
+		case <-d.stop:
+			return
+		case <-d.dirty:
+		}
+
+		width, height, err := term.GetSize(int(os.Stdout.Fd()))
+		if err != nil {
+			continue
+		}
+
+		d.mu.Lock()
+		frame := d.render(width, height)
+		d.mu.Unlock()
+
+		d.screen.Lock()
+		select {
+		case <-d.stop:
+		default:
+			_, _ = os.Stdout.WriteString(frame)
+		}
+		d.screen.Unlock()
+	}
+}
+
+// selectedSession returns the session under the cursor.
+func (d *Dashboard) selectedSession() (helper.Sessionable, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.selected < 0 || d.selected >= len(d.sessions) {
+		return nil, errors.New("no session is selected")
+	}
+	session := d.sessions[d.selected]
+	if strings.TrimSpace(session.Name()) == "" {
+		return nil, errors.New("the selected session has not joined yet")
+	}
+	return session, nil
+}
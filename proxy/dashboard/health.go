@@ -0,0 +1,81 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/OCharnyshevich/proxycraft/proxy/network/protocol"
+	"github.com/Tnze/go-mc/data/packetid"
+	mcNet "github.com/Tnze/go-mc/net"
+	pk "github.com/Tnze/go-mc/net/packet"
+)
+
+// healthTimeout limits a status ping of a backend.
+const healthTimeout = 3 * time.Second
+
+type Backend struct {
+	Name string
+	Host string
+	Port int
+}
+
+// health is the result of the last status ping of a backend.
+type health struct {
+	up      bool
+	latency time.Duration
+	online  int
+	max     int
+	err     string
+}
+
+// ping asks the backend for its status like the server list does.
+func ping(b Backend) health {
+	address := net.JoinHostPort(b.Host, strconv.Itoa(b.Port))
+
+	conn, err := mcNet.DialMCTimeout(address, healthTimeout)
+	if err != nil {
+		return health{err: err.Error()}
+	}
+	defer func() { _ = conn.Close() }()
+	_ = conn.Socket.SetDeadline(time.Now().Add(healthTimeout))
+
+	handshake := pk.Marshal(
+		protocol.HandshakeID,
+		pk.VarInt(-1), // any version may ask for the status
+		pk.String(b.Host),
+		pk.UnsignedShort(b.Port),
+		pk.VarInt(1), // status
+	)
+	if err := conn.WritePacket(handshake); err != nil {
+		return health{err: err.Error()}
+	}
+
+	start := time.Now()
+	if err := conn.WritePacket(pk.Marshal(packetid.PingStart)); err != nil {
+		return health{err: err.Error()}
+	}
+
+	var (
+		packet   pk.Packet
+		response pk.String
+	)
+	if err := conn.ReadPacket(&packet); err != nil {
+		return health{err: err.Error()}
+	}
+	latency := time.Since(start)
+	if err := packet.Scan(&response); err != nil {
+		return health{err: err.Error()}
+	}
+
+	var status struct {
+		Players struct {
+			Max    int `json:"max"`
+			Online int `json:"online"`
+		} `json:"players"`
+	}
+	_ = json.Unmarshal([]byte(response), &status)
+
+	return health{up: true, latency: latency, online: status.Players.Online, max: status.Players.Max}
+}
@@ -0,0 +1,198 @@
+package dashboard
+
+import (
+	"bufio"
+	"os"
+	"strings"
+
+	"github.com/OCharnyshevich/proxycraft/proxy/player/chat"
+)
+
+// prompt is a line typed at the bottom of the screen, done gets the line on Enter.
+type prompt struct {
+	label string
+	text  []rune
+	done  func(line string)
+}
+
+// input reads the keys until the dashboard is killed.
+func (d *Dashboard) input() {
+	in := bufio.NewReader(os.Stdin)
+
+	for {
+		r, _, err := in.ReadRune()
+		if err != nil {
+			return
+		}
+
+		select {
+		case <-d.stop:
+			return
+		default:
+		}
+
+		if r == 0x1b {
+			d.key(escape(in))
+		} else {
+			d.key(string(r))
+		}
+		d.redraw()
+	}
+}
+
+// escape reads an escape sequence and names the arrow and page keys.
+func escape(in *bufio.Reader) string {
+	if in.Buffered() == 0 {
+		return "esc"
+	}
+
+	r, _, err := in.ReadRune()
+	if err != nil || (r != '[' && r != 'O') {
+		return "esc"
+	}
+
+	var params strings.Builder
+	for {
+		r, _, err = in.ReadRune()
+		if err != nil {
+			return ""
+		}
+		if r >= 0x40 && r <= 0x7e {
+			break
+		}
+		params.WriteRune(r)
+	}
+
+	switch {
+	case r == 'A':
+		return "up"
+	case r == 'B':
+		return "down"
+	case r == '~' && params.String() == "5":
+		return "pgup"
+	case r == '~' && params.String() == "6":
+		return "pgdown"
+	}
+	return ""
+}
+
+func (d *Dashboard) key(key string) {
+	d.mu.Lock()
+	p := d.prompt
+	d.mu.Unlock()
+
+	if p != nil {
+		d.edit(p, key)
+		return
+	}
+
+	switch key {
+	case "up":
+		d.move(-1)
+	case "down":
+		d.move(1)
+	case "pgup":
+		d.scrollBy(10)
+	case "pgdown":
+		d.scrollBy(-10)
+	case "K", "k":
+		d.kick()
+	case "m", "M":
+		d.message()
+	case ":", "/":
+		d.ask("command: ", d.run)
+	case "q", "Q", "\x03":
+		d.ask("stop the proxy? (y/n) ", func(line string) {
+			if strings.EqualFold(strings.TrimSpace(line), "y") {
+				d.run("stop")
+			}
+		})
+	}
+}
+
+// edit types into the prompt, Enter finishes it and Escape cancels it.
+func (d *Dashboard) edit(p *prompt, key string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	switch key {
+	case "\r", "\n":
+		d.prompt = nil
+		line := string(p.text)
+		// the prompt may run a command which logs, and logging takes the lock
+		go p.done(line)
+	case "esc", "\x03":
+		d.prompt = nil
+	case "\x7f", "\x08":
+		if len(p.text) > 0 {
+			p.text = p.text[:len(p.text)-1]
+		}
+	default:
+		// named keys like "up" are longer than one rune
+		if r := []rune(key); len(r) == 1 && r[0] >= 0x20 {
+			p.text = append(p.text, r[0])
+		}
+	}
+}
+
+func (d *Dashboard) ask(label string, done func(line string)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.prompt = &prompt{label: label, done: done}
+}
+
+func (d *Dashboard) move(by int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.selected += by
+	if d.selected >= len(d.sessions) {
+		d.selected = len(d.sessions) - 1
+	}
+	if d.selected < 0 {
+		d.selected = 0
+	}
+}
+
+func (d *Dashboard) scrollBy(lines int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.scroll += lines
+	if d.scroll > len(d.logs) {
+		d.scroll = len(d.logs)
+	}
+	if d.scroll < 0 {
+		d.scroll = 0
+	}
+}
+
+func (d *Dashboard) kick() {
+	session, err := d.selectedSession()
+	if err != nil {
+		d.logger.Warn(err)
+		return
+	}
+
+	name := session.Name()
+	d.ask("kick "+name+", reason: ", func(reason string) {
+		d.run(strings.TrimSpace("kick " + name + " " + reason))
+	})
+}
+
+func (d *Dashboard) message() {
+	session, err := d.selectedSession()
+	if err != nil {
+		d.logger.Warn(err)
+		return
+	}
+
+	d.ask("message to "+session.Name()+": ", func(text string) {
+		if strings.TrimSpace(text) == "" {
+			return
+		}
+		session.SendMessage(chat.Translate(text))
+		d.logger.InfoF("message to %s: %s", session.Name(), text)
+	})
+}
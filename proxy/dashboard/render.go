@@ -0,0 +1,214 @@
+package dashboard
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/OCharnyshevich/proxycraft/proxy/helper"
+)
+
+const help = "↑↓ select  k kick  m message  : command  PgUp/PgDn scroll  q quit"
+
+// render builds a frame of the whole screen, the lock is held by the caller.
+func (d *Dashboard) render(width, height int) string {
+	if width < 40 || height < 12 {
+		return "\x1b[H\x1b[2J" + fit("terminal too small", width)
+	}
+
+	var lines []string
+
+	var total uint64
+	for _, r := range d.rates {
+		total += r.count
+	}
+	title := fmt.Sprintf(" proxycraft  %d sessions  %d packets/s  %s", len(d.sessions), total, time.Now().Format("15:04:05"))
+	lines = append(lines, "\x1b[7m"+fit(title, width)+"\x1b[0m")
+
+	top := (height - 3) * 2 / 5
+	if top < 6 {
+		top = 6
+	}
+	left := width * 3 / 5
+	right := width - left - 1
+
+	sessions := d.renderSessions(top)
+	traffic := d.renderTraffic(top)
+	for i := 0; i < top; i++ {
+		lines = append(lines, fit(sessions[i], left)+"\x1b[90m│\x1b[0m"+fit(traffic[i], right))
+	}
+
+	label := " log "
+	if d.scroll > 0 {
+		label = fmt.Sprintf(" log, %d lines back ", d.scroll)
+	}
+	lines = append(lines, "\x1b[90m──"+label+strings.Repeat("─", max(0, width-len(label)-2))+"\x1b[0m")
+
+	rows := height - top - 3
+	end := len(d.logs) - d.scroll
+	start := end - rows
+	if start < 0 {
+		start = 0
+	}
+	for i := start; i < start+rows; i++ {
+		line := ""
+		if i < end {
+			line = d.logs[i]
+		}
+		lines = append(lines, fit(line, width))
+	}
+
+	if d.prompt != nil {
+		lines = append(lines, fit("\x1b[1m"+d.prompt.label+"\x1b[0m"+string(d.prompt.text)+"█", width))
+	} else {
+		lines = append(lines, "\x1b[7m"+fit(" "+help, width)+"\x1b[0m")
+	}
+
+	return "\x1b[H" + strings.Join(lines, "\r\n")
+}
+
+func (d *Dashboard) renderSessions(rows int) []string {
+	lines := make([]string, rows)
+	lines[0] = "\x1b[1m" + fmt.Sprintf("  %-4s %-16s %-10s %6s %9s %9s", "ID", "PLAYER", "BACKEND", "PING", "IN", "OUT") + "\x1b[0m"
+
+	// keep the selected session in view
+	first := 0
+	if d.selected >= rows-1 {
+		first = d.selected - rows + 2
+	}
+
+	for i := 1; i < rows && first+i-1 < len(d.sessions); i++ {
+		index := first + i - 1
+		lines[i] = sessionLine(d.sessions[index])
+		if index == d.selected {
+			lines[i] = "\x1b[7m> " + lines[i] + "\x1b[0m"
+		} else {
+			lines[i] = "  " + lines[i]
+		}
+	}
+
+	if len(d.sessions) == 0 {
+		lines[1] = "  \x1b[90mno sessions\x1b[0m"
+	}
+	return lines
+}
+
+func sessionLine(s helper.Sessionable) string {
+	name := s.Name()
+	if name == "" {
+		name = s.Address()
+	}
+	ping := "-"
+	if p := s.Ping(); p > 0 {
+		ping = fmt.Sprintf("%dms", p.Milliseconds())
+	}
+	in, out := s.Traffic()
+
+	return fmt.Sprintf("%-4d %-16s %-10s %6s %9s %9s", s.ID(), cut(name, 16), cut(s.Server(), 10), ping, size(in), size(out))
+}
+
+// renderTraffic lists the packet rates above the health of the backends.
+func (d *Dashboard) renderTraffic(rows int) []string {
+	lines := make([]string, rows)
+	backends := len(d.backends) + 1
+	packets := rows - backends
+	if packets < 2 {
+		packets = 2
+	}
+
+	lines[0] = "\x1b[1m PACKETS/S\x1b[0m"
+	for i := 1; i < packets && i <= len(d.rates); i++ {
+		r := d.rates[i-1]
+		lines[i] = fmt.Sprintf(" %6d %s", r.count, r.key)
+	}
+
+	if packets >= rows {
+		return lines
+	}
+	lines[packets] = "\x1b[1m BACKENDS\x1b[0m"
+	for i, b := range d.backends {
+		row := packets + 1 + i
+		if row >= rows {
+			break
+		}
+
+		h, checked := d.health[b.Name]
+		switch {
+		case !checked:
+			lines[row] = fmt.Sprintf(" \x1b[90m●\x1b[0m %-10s checking", cut(b.Name, 10))
+		case h.up:
+			lines[row] = fmt.Sprintf(" \x1b[32m●\x1b[0m %-10s %dms %d/%d", cut(b.Name, 10), h.latency.Milliseconds(), h.online, h.max)
+		default:
+			lines[row] = fmt.Sprintf(" \x1b[31m●\x1b[0m %-10s %s", cut(b.Name, 10), h.err)
+		}
+	}
+	return lines
+}
+
+// fit cuts or pads the text to the width, escape sequences take no room.
+func fit(text string, width int) string {
+	var b strings.Builder
+	visible := 0
+	styled := false
+	runes := []rune(text)
+
+	for i := 0; i < len(runes); i++ {
+		if runes[i] == 0x1b {
+			// copy the sequence up to its final byte
+			j := i + 1
+			for j < len(runes) && !(runes[j] >= 0x40 && runes[j] <= 0x7e && j > i+1) {
+				j++
+			}
+			if j < len(runes) {
+				b.WriteString(string(runes[i : j+1]))
+				styled = true
+			}
+			i = j
+			continue
+		}
+		if visible == width {
+			continue
+		}
+		if runes[i] == '\t' {
+			runes[i] = ' '
+		}
+		b.WriteRune(runes[i])
+		visible++
+	}
+
+	if styled {
+		b.WriteString("\x1b[0m")
+	}
+	b.WriteString(strings.Repeat(" ", width-visible))
+	return b.String()
+}
+
+func cut(text string, width int) string {
+	if r := []rune(text); len(r) > width {
+		return string(r[:width-1]) + "…"
+	}
+	return text
+}
+
+func size(bytes uint64) string {
+	switch {
+	case bytes >= 1<<30:
+		return fmt.Sprintf("%.1f GiB", float64(bytes)/(1<<30))
+	case bytes >= 1<<20:
+		return fmt.Sprintf("%.1f MiB", float64(bytes)/(1<<20))
+	case bytes >= 1<<10:
+		return fmt.Sprintf("%.1f KiB", float64(bytes)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", bytes)
+}
+
+func unknown(id int32) string {
+	return fmt.Sprintf("0x%02X", id)
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,207 @@
+package proxy
+
+import (
+	"strings"
+	"unicode/utf16"
+
+	"github.com/OCharnyshevich/proxycraft/proxy/helper"
+	"github.com/OCharnyshevich/proxycraft/proxy/network"
+	"github.com/OCharnyshevich/proxycraft/proxy/network/protocol"
+	"github.com/OCharnyshevich/proxycraft/proxy/player/chat"
+	mcNet "github.com/Tnze/go-mc/net"
+	pk "github.com/Tnze/go-mc/net/packet"
+)
+
+// listenGame runs the proxy commands typed in game chat, instead of forwarding them to the backend.
+func (p *proxy) listenGame() {
+	events := p.network.Events().(*network.Events)
+	events.AddListener(
+		network.PacketHandler{Priority: 0, Owner: "proxy", Name: protocol.ChatServerbound, F: p.onGameChat},
+		network.PacketHandler{Priority: 0, Owner: "proxy", Name: protocol.ChatCommand, F: p.onGameCommand},
+		network.PacketHandler{Priority: 0, Owner: "proxy", Name: protocol.TabCompleteServerbound, F: p.onTabComplete},
+		network.PacketHandler{Priority: 0, Owner: "proxy", Name: protocol.DeclareCommands, F: p.onDeclareCommands},
+	)
+}
+
+// gameCommand cuts one of the command prefixes from a chat line, false when the line has none.
+func (p *proxy) gameCommand(line string) (string, bool) {
+	for _, prefix := range p.config.Commands.Prefixes {
+		if line == prefix {
+			return "", true
+		}
+		if strings.HasPrefix(line, prefix+" ") {
+			return strings.TrimPrefix(line, prefix+" "), true
+		}
+	}
+	return "", false
+}
+
+func (p *proxy) onGameChat(_ *mcNet.Conn, _ *mcNet.Conn, packet network.Packet) error {
+	var message pk.String
+	if err := packet.Scan(&message); err != nil {
+		return err
+	}
+
+	line, ok := p.gameCommand(string(message))
+	if !ok {
+		return nil
+	}
+
+	p.runGameCommand(packet.Session, line)
+	return network.Drop
+}
+
+// onGameCommand handles the command packet of 1.19 backends, which comes without the slash.
+func (p *proxy) onGameCommand(_ *mcNet.Conn, _ *mcNet.Conn, packet network.Packet) error {
+	var message pk.String
+	if err := packet.Scan(&message); err != nil {
+		return err
+	}
+
+	line, ok := p.gameCommand("/" + string(message))
+	if !ok {
+		return nil
+	}
+
+	p.runGameCommand(packet.Session, line)
+	return network.Drop
+}
+
+func (p *proxy) runGameCommand(session helper.Sessionable, line string) {
+	if strings.TrimSpace(line) == "" {
+		line = "help"
+	}
+
+	p.logging.InfoF("%s issued proxy command: %s", session.Name(), line)
+
+	var err error
+	if attempt := helper.Attempt(func() { err = p.commands.Execute(session, line) }); attempt != nil {
+		err = attempt
+	}
+	if err != nil {
+		session.SendMessage(chat.Red, err.Error())
+	}
+}
+
+func (p *proxy) onTabComplete(_ *mcNet.Conn, _ *mcNet.Conn, packet network.Packet) error {
+	var (
+		transaction pk.VarInt
+		text        pk.String
+	)
+	if err := packet.Scan(&transaction, &text); err != nil {
+		return err
+	}
+
+	line, ok := p.gameCommand(string(text))
+	if !ok {
+		return nil
+	}
+
+	matches := p.commands.Complete(packet.Session, line)
+	// the client counts the characters of the line in UTF-16 like Java strings
+	before := string(text)[:strings.LastIndex(string(text), " ")+1]
+	start := len(utf16.Encode([]rune(before)))
+	length := len(utf16.Encode([]rune(string(text)))) - start
+
+	suggestions := make([]pk.FieldEncoder, 0, len(matches)*2)
+	for _, m := range matches {
+		suggestions = append(suggestions, pk.String(m), pk.Boolean(false))
+	}
+
+	fields := append([]pk.FieldEncoder{
+		transaction,
+		pk.VarInt(start),
+		pk.VarInt(length),
+		pk.VarInt(len(matches)),
+	}, suggestions...)
+
+	response, err := packet.Version.Marshal(protocol.TabCompleteClientbound, fields...)
+	if err == nil {
+		err = packet.Session.WritePacket(response)
+	}
+	if err != nil {
+		return err
+	}
+
+	return network.Drop
+}
+
+// onDeclareCommands adds the proxy commands to the command tree of the backend,
+// so the client knows and completes them.
+func (p *proxy) onDeclareCommands(_ *mcNet.Conn, _ *mcNet.Conn, packet network.Packet) error {
+	nodes, root, err := packet.Version.ReadCommands(packet.Packet)
+	if err != nil {
+		p.logging.DataF("Commands of %v are not augmented: %v", packet.Version, err)
+		return nil
+	}
+
+	taken := make(map[string]bool)
+	for _, child := range nodes[root].Children {
+		taken[nodes[child].Name] = true
+	}
+
+	var main int32 = -1
+	for _, prefix := range p.config.Commands.Prefixes {
+		name := strings.TrimPrefix(prefix, "/")
+		if !strings.HasPrefix(prefix, "/") || strings.Contains(name, " ") || taken[name] {
+			continue
+		}
+
+		index := int32(len(nodes))
+		if main < 0 {
+			main = index
+			nodes = append(nodes, protocol.CommandNode{Flags: protocol.NodeLiteral | protocol.NodeExecutable, Name: name})
+			children := p.commandNodes(&nodes, packet.Session)
+			nodes[main].Children = children
+		} else {
+			// further prefixes are aliases of the first one
+			nodes = append(nodes, protocol.CommandNode{
+				Flags:    protocol.NodeLiteral | protocol.NodeExecutable | protocol.NodeRedirect,
+				Name:     name,
+				Redirect: main,
+			})
+		}
+		nodes[root].Children = append(nodes[root].Children, index)
+	}
+
+	augmented, err := packet.Version.MarshalCommands(nodes, root)
+	if err != nil {
+		return err
+	}
+	if err := packet.Session.WritePacket(augmented); err != nil {
+		return err
+	}
+
+	return network.Drop
+}
+
+// commandNodes appends a literal for every command the player can run, followed by an
+// argument taking the rest of the line, which the client completes by asking the proxy.
+func (p *proxy) commandNodes(nodes *[]protocol.CommandNode, session helper.Sessionable) []int32 {
+	var children []int32
+
+	for _, c := range p.commands.Available(session) {
+		var args []int32
+		if len(c.Params) > 0 || c.Complete != nil {
+			args = []int32{int32(len(*nodes))}
+			*nodes = append(*nodes, protocol.CommandNode{
+				Flags:       protocol.NodeArgument | protocol.NodeExecutable | protocol.NodeSuggestions,
+				Name:        "args",
+				Parser:      "brigadier:string",
+				Properties:  []byte{2}, // greedy phrase
+				Suggestions: "minecraft:ask_server",
+			})
+		}
+
+		for _, name := range append([]string{c.Name}, c.Aliases...) {
+			children = append(children, int32(len(*nodes)))
+			*nodes = append(*nodes, protocol.CommandNode{
+				Flags:    protocol.NodeLiteral | protocol.NodeExecutable,
+				Name:     name,
+				Children: args,
+			})
+		}
+	}
+
+	return children
+}
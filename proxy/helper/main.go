@@ -2,7 +2,11 @@ package helper
 
 import (
 	"fmt"
+	"github.com/OCharnyshevich/proxycraft/proxy/network/protocol"
+	pk "github.com/Tnze/go-mc/net/packet"
+	"github.com/google/uuid"
 	"strings"
+	"time"
 )
 
 type Loads interface {
@@ -27,6 +31,27 @@ type Network interface {
 type Sessionable interface {
 	Kills
 	SendMessage(message ...interface{})
+	WritePacket(packet pk.Packet) error
+	// Backend is the protocol version of packets passed to WritePacket, nil before the handshake.
+	Backend() *protocol.Version
+	// Disconnect kicks the player with the given reason and closes the session.
+	Disconnect(reason string)
+
+	ID() int32
+	// Name and UUID of the player, empty until the login succeeded.
+	Name() string
+	UUID() uuid.UUID
+	Address() string
+	StartTime() time.Time
+	// Ping is the time the client took to answer the last keep alive.
+	Ping() time.Duration
+	// Traffic returns the bytes received from and sent to the client.
+	Traffic() (received, sent uint64)
+
+	// Server is the name of the backend the player is connected to.
+	Server() string
+	// Connect moves the player to another backend.
+	Connect(name, host string, port int) error
 }
 
 func ConvertToString(data ...interface{}) string {
@@ -0,0 +1,163 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/OCharnyshevich/proxycraft/proxy/player/chat"
+	"github.com/fatih/color"
+)
+
+// Field is a key/value pair attached to the lines of a logger.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+func Any(key string, value interface{}) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Session tags the lines of a proxy session by its ID.
+func Session(id int32) Field {
+	return Field{Key: "session", Value: id}
+}
+
+func Player(name string) Field {
+	return Field{Key: "player", Value: name}
+}
+
+// Packet tags the lines about a packet by its ID.
+func Packet(id int32) Field {
+	return Field{Key: "packet", Value: fmt.Sprintf("0x%02X", id)}
+}
+
+func Backend(name string) Field {
+	return Field{Key: "backend", Value: name}
+}
+
+// Entry is a logged line before it is encoded.
+type Entry struct {
+	Time    time.Time
+	Level   LogLevel
+	Logger  string
+	Message string
+	Fields  []Field
+}
+
+// Encoding is the format of the logged lines.
+type Encoding string
+
+const (
+	// Console is the colored format of the terminal, the fields follow the message.
+	Console Encoding = "console"
+	// Text is the console format without colors.
+	Text Encoding = "text"
+	// Logfmt writes key=value pairs.
+	Logfmt Encoding = "logfmt"
+	// JSON writes a JSON object per line.
+	JSON Encoding = "json"
+)
+
+var levelPaint = map[LogLevel]func(format string, a ...interface{}) string{
+	Info: color.CyanString,
+	Warn: color.YellowString,
+	Fail: color.RedString,
+	Data: color.MagentaString,
+}
+
+// Encode formats the entry as a line, unknown encodings use Console.
+func (e Encoding) Encode(entry Entry) []byte {
+	switch e {
+	case Text:
+		return encodeText(entry, false)
+	case Logfmt:
+		return encodeLogfmt(entry)
+	case JSON:
+		return encodeJSON(entry)
+	}
+	return encodeText(entry, true)
+}
+
+func encodeText(entry Entry, colored bool) []byte {
+	h, m, s := entry.Time.Clock()
+	clock := fmt.Sprintf("%02d:%02d:%02d", h, m, s)
+	level := strings.ToUpper(entry.Level.String())
+
+	var buf bytes.Buffer
+	if colored {
+		buf.WriteString(fmt.Sprintf("[%s] [%s] [%s] %s", color.HiGreenString(clock), levelPaint[entry.Level](level), color.WhiteString(entry.Logger), chat.TranslateConsole(entry.Message)))
+	} else {
+		buf.WriteString(fmt.Sprintf("[%s] [%s] [%s] %s", clock, level, entry.Logger, chat.Strip(entry.Message)))
+	}
+
+	for _, f := range entry.Fields {
+		pair := f.Key + "=" + logfmtValue(f.Value)
+		if colored {
+			pair = color.HiBlackString(pair)
+		}
+		buf.WriteString(" ")
+		buf.WriteString(pair)
+	}
+
+	buf.WriteString("\n")
+	return buf.Bytes()
+}
+
+func encodeLogfmt(entry Entry) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("time=" + entry.Time.Format(time.RFC3339Nano))
+	buf.WriteString(" level=" + entry.Level.String())
+	buf.WriteString(" logger=" + logfmtValue(entry.Logger))
+	buf.WriteString(" msg=" + logfmtValue(chat.Strip(entry.Message)))
+
+	for _, f := range entry.Fields {
+		buf.WriteString(" " + f.Key + "=" + logfmtValue(f.Value))
+	}
+
+	buf.WriteString("\n")
+	return buf.Bytes()
+}
+
+// logfmtValue quotes values which are empty or hold spaces, quotes or equal signs.
+func logfmtValue(value interface{}) string {
+	s := fmt.Sprint(value)
+	if s == "" || strings.ContainsAny(s, " \t\"=\n") {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+func encodeJSON(entry Entry) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(`{"time":`)
+	writeJSON(&buf, entry.Time.Format(time.RFC3339Nano))
+	buf.WriteString(`,"level":`)
+	writeJSON(&buf, entry.Level.String())
+	buf.WriteString(`,"logger":`)
+	writeJSON(&buf, entry.Logger)
+	buf.WriteString(`,"msg":`)
+	writeJSON(&buf, chat.Strip(entry.Message))
+
+	for _, f := range entry.Fields {
+		buf.WriteString(",")
+		writeJSON(&buf, f.Key)
+		buf.WriteString(":")
+		writeJSON(&buf, f.Value)
+	}
+
+	buf.WriteString("}\n")
+	return buf.Bytes()
+}
+
+func writeJSON(buf *bytes.Buffer, value interface{}) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		data, _ = json.Marshal(fmt.Sprint(value))
+	}
+	buf.Write(data)
+}
@@ -0,0 +1,298 @@
+package log
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// latest is the name of the file the current lines are written to.
+const latest = "latest.log"
+
+const dayLayout = "2006-01-02"
+
+type FileConfig struct {
+	// Directory keeps latest.log and the archives, no file is written when empty.
+	Directory string
+	// MaxSize archives latest.log when it grows past the given bytes, zero only rolls over daily.
+	MaxSize int64
+	// MaxAge removes archives older than the given days, zero keeps them.
+	MaxAge int
+	// MaxFiles keeps the given number of the newest archives, zero keeps all of them.
+	MaxFiles int
+	// Encoding of the lines in the file, Text when empty.
+	Encoding Encoding
+}
+
+// File writes the log to latest.log and archives it as YYYY-MM-DD-N.log.gz each
+// day, when it grows too large and when it is closed.
+type File struct {
+	config FileConfig
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+	day  string // date of the lines in latest.log
+	// retry is when a rotation is tried again after it failed
+	retry time.Time
+}
+
+// OpenFile starts latest.log, a latest.log left by a previous run is archived first.
+func OpenFile(config FileConfig) (*File, error) {
+	if err := os.MkdirAll(config.Directory, 0755); err != nil {
+		return nil, err
+	}
+
+	f := &File{config: config}
+
+	if info, err := os.Stat(f.path()); err == nil {
+		f.day = info.ModTime().Format(dayLayout)
+		if err := f.archive(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	f.prune()
+
+	return f, nil
+}
+
+func (f *File) path() string {
+	return filepath.Join(f.config.Directory, latest)
+}
+
+func (f *File) open() error {
+	file, err := os.OpenFile(f.path(), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	f.file = file
+	f.size = 0
+	f.day = time.Now().Format(dayLayout)
+	return nil
+}
+
+// Write appends to latest.log, which is rolled over first when the day changed or it is full.
+func (f *File) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	var rotateErr error
+	full := f.config.MaxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.config.MaxSize
+	if (full || time.Now().Format(dayLayout) != f.day) && !time.Now().Before(f.retry) {
+		if rotateErr = f.rotate(); f.file == nil {
+			return 0, rotateErr
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	if err == nil {
+		err = rotateErr
+	}
+	return n, err
+}
+
+// Close archives latest.log.
+func (f *File) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+
+	err := f.file.Close()
+	f.file = nil
+	if err != nil {
+		return err
+	}
+
+	if err := f.archive(); err != nil {
+		return err
+	}
+	f.prune()
+	return nil
+}
+
+// rotate archives latest.log and starts another one. When archiving fails the lines
+// go on to latest.log, the error is reported there and the rotation is tried again a
+// minute later.
+func (f *File) rotate() error {
+	err := f.file.Close()
+	f.file = nil
+	if err == nil {
+		err = f.archive()
+	}
+	if err == nil {
+		f.prune()
+		if err = f.open(); err == nil {
+			return nil
+		}
+	}
+
+	f.retry = time.Now().Add(time.Minute)
+	if reopenErr := f.reopen(); reopenErr != nil {
+		return fmt.Errorf("%v, reopen %s: %w", err, latest, reopenErr)
+	}
+	f.report(err)
+	return err
+}
+
+// reopen appends to latest.log again after a failed rotation.
+func (f *File) reopen() error {
+	file, err := os.OpenFile(f.path(), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	f.file = file
+	f.size = info.Size()
+	return nil
+}
+
+// report logs the failed rotation to latest.log and the output of the loggers, which
+// ignore the errors of Write.
+func (f *File) report(err error) {
+	entry := Entry{Time: time.Now(), Level: Warn, Logger: "log", Message: fmt.Sprintf("unable to rotate %s: %v", latest, err)}
+
+	encoding := f.config.Encoding
+	if encoding == "" {
+		encoding = Text
+	}
+	line := encoding.Encode(entry)
+	n, _ := f.file.Write(line)
+	f.size += int64(n)
+
+	output.RLock()
+	encoding = output.encoding
+	output.RUnlock()
+	_, _ = output.Write(encoding.Encode(entry))
+}
+
+// archive compresses latest.log into the next YYYY-MM-DD-N.log.gz of its day.
+func (f *File) archive() error {
+	source, err := os.Open(f.path())
+	if err != nil {
+		return err
+	}
+	defer func() { _ = source.Close() }()
+
+	name := f.nextArchive()
+	temp := name + ".tmp"
+
+	target, err := os.Create(temp)
+	if err != nil {
+		return err
+	}
+
+	writer := gzip.NewWriter(target)
+	writer.Name = latest
+	_, err = io.Copy(writer, source)
+	if closeErr := writer.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := target.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(temp)
+		return fmt.Errorf("archive %s: %w", name, err)
+	}
+
+	if err := os.Rename(temp, name); err != nil {
+		return err
+	}
+	return os.Remove(f.path())
+}
+
+// nextArchive names the archive after the newest one of the day, numbers of pruned archives are not reused.
+func (f *File) nextArchive() string {
+	next := 1
+	for _, a := range f.archives() {
+		if a.day.Format(dayLayout) == f.day && a.index >= next {
+			next = a.index + 1
+		}
+	}
+	return filepath.Join(f.config.Directory, f.day+"-"+strconv.Itoa(next)+".log.gz")
+}
+
+// archived is a YYYY-MM-DD-N.log.gz in the directory.
+type archived struct {
+	name  string
+	day   time.Time
+	index int
+}
+
+// archives lists the YYYY-MM-DD-N.log.gz files of the directory.
+func (f *File) archives() []archived {
+	entries, err := os.ReadDir(f.config.Directory)
+	if err != nil {
+		return nil
+	}
+
+	var archives []archived
+	for _, e := range entries {
+		name := strings.TrimSuffix(e.Name(), ".log.gz")
+		if name == e.Name() || len(name) < len(dayLayout)+2 {
+			continue
+		}
+
+		day, err := time.ParseInLocation(dayLayout, name[:len(dayLayout)], time.Local)
+		if err != nil {
+			continue
+		}
+		index, err := strconv.Atoi(name[len(dayLayout)+1:])
+		if err != nil {
+			continue
+		}
+		archives = append(archives, archived{name: e.Name(), day: day, index: index})
+	}
+	return archives
+}
+
+// prune removes the archives past the age or the count limit.
+func (f *File) prune() {
+	if f.config.MaxAge <= 0 && f.config.MaxFiles <= 0 {
+		return
+	}
+
+	archives := f.archives()
+
+	// newest first
+	sort.Slice(archives, func(i, j int) bool {
+		if !archives[i].day.Equal(archives[j].day) {
+			return archives[i].day.After(archives[j].day)
+		}
+		return archives[i].index > archives[j].index
+	})
+
+	oldest := time.Now().AddDate(0, 0, -f.config.MaxAge)
+	for i, a := range archives {
+		expired := f.config.MaxAge > 0 && a.day.Before(oldest)
+		if expired || (f.config.MaxFiles > 0 && i >= f.config.MaxFiles) {
+			_ = os.Remove(filepath.Join(f.config.Directory, a.name))
+		}
+	}
+}
@@ -3,10 +3,13 @@ package log
 import (
 	"fmt"
 	"github.com/OCharnyshevich/proxycraft/proxy/helper"
-	"github.com/OCharnyshevich/proxycraft/proxy/player/chat"
-	"github.com/fatih/color"
 	"io"
 	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -17,127 +20,373 @@ const (
 	Warn
 	Fail
 	Data
+	// Off is the threshold showing no level.
+	Off
 )
 
 var BasicLevel = []LogLevel{Info, Warn, Fail}
 var EveryLevel = []LogLevel{Info, Warn, Fail, Data}
 
+var levelNames = map[LogLevel]string{Info: "info", Warn: "warn", Fail: "fail", Data: "data", Off: "off"}
+
+// verbosity orders the levels from the most verbose, a threshold shows its level and the later ones.
+var verbosity = map[LogLevel]int{Data: 0, Info: 1, Warn: 2, Fail: 3, Off: 4}
+
+func (l LogLevel) String() string {
+	return levelNames[l]
+}
+
+// Shows reports whether a logger with the l threshold shows the level.
+func (l LogLevel) Shows(level LogLevel) bool {
+	return level != Off && verbosity[level] >= verbosity[l]
+}
+
+// ParseLevel reads a level name, data, info, warn, fail or off.
+func ParseLevel(name string) (LogLevel, error) {
+	for l, n := range levelNames {
+		if strings.EqualFold(name, n) {
+			return l, nil
+		}
+	}
+	return Off, fmt.Errorf("unknown log level %q", name)
+}
+
+// threshold is the most verbose of the levels, Info without any.
+func threshold(show []LogLevel) LogLevel {
+	if len(show) == 0 {
+		return Info
+	}
+
+	min := Off
+	for _, l := range show {
+		if verbosity[l] < verbosity[min] {
+			min = l
+		}
+	}
+	return min
+}
+
+// rules replace the threshold of the loggers matching their pattern, including the existing ones.
+var rules = struct {
+	sync.RWMutex
+	names  map[string]bool
+	levels map[string]LogLevel
+}{
+	names:  make(map[string]bool),
+	levels: make(map[string]LogLevel),
+}
+
+// version changes with every rule, the loggers resolve their threshold again once it does.
+var version uint64 = 1
+
+// SetLevel changes the threshold of the loggers with the given name, or of every logger the
+// pattern matches. Patterns use path.Match wildcards, "session.*" also matches "session" itself.
+func SetLevel(pattern string, min LogLevel) error {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return fmt.Errorf("bad logger pattern %q", pattern)
+	}
+
+	rules.Lock()
+	defer rules.Unlock()
+
+	rules.levels[pattern] = min
+	atomic.AddUint64(&version, 1)
+	return nil
+}
+
+// UnsetLevel removes the threshold set for the name or pattern, false if there was none.
+func UnsetLevel(pattern string) bool {
+	rules.Lock()
+	defer rules.Unlock()
+
+	if _, ok := rules.levels[pattern]; !ok {
+		return false
+	}
+	delete(rules.levels, pattern)
+	atomic.AddUint64(&version, 1)
+	return true
+}
+
+// Level returns the threshold set with SetLevel for exactly the name or pattern.
+func Level(pattern string) (LogLevel, bool) {
+	rules.RLock()
+	defer rules.RUnlock()
+
+	min, ok := rules.levels[pattern]
+	return min, ok
+}
+
+// Levels returns the thresholds set with SetLevel by name or pattern.
+func Levels() map[string]LogLevel {
+	rules.RLock()
+	defer rules.RUnlock()
+
+	levels := make(map[string]LogLevel, len(rules.levels))
+	for pattern, min := range rules.levels {
+		levels[pattern] = min
+	}
+	return levels
+}
+
+// Rule returns the pattern deciding the threshold of the loggers with the name and its level,
+// false while they use their own. The name itself wins over patterns, longer patterns over shorter.
+func Rule(name string) (string, LogLevel, bool) {
+	rules.RLock()
+	defer rules.RUnlock()
+
+	if min, ok := rules.levels[name]; ok {
+		return name, min, true
+	}
+
+	best, found := "", false
+	for pattern := range rules.levels {
+		if !match(pattern, name) {
+			continue
+		}
+		if !found || len(pattern) > len(best) || (len(pattern) == len(best) && pattern < best) {
+			best, found = pattern, true
+		}
+	}
+	return best, rules.levels[best], found
+}
+
+func match(pattern, name string) bool {
+	if strings.HasSuffix(pattern, ".*") && strings.TrimSuffix(pattern, ".*") == name {
+		return true
+	}
+	ok, _ := path.Match(pattern, name)
+	return ok
+}
+
+// Names lists the names of the created loggers.
+func Names() []string {
+	rules.RLock()
+	defer rules.RUnlock()
+
+	names := make([]string, 0, len(rules.names))
+	for name := range rules.names {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// output is where the loggers created by New write, it is swapped together for all of them.
+var output = &switchWriter{w: os.Stdout, encoding: Console}
+
+type switchWriter struct {
+	sync.RWMutex
+	w        io.Writer
+	encoding Encoding
+}
+
+func (s *switchWriter) Write(p []byte) (int, error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	return s.w.Write(p)
+}
+
+// SetOutput changes where the loggers created by New write, including the existing ones.
+func SetOutput(w io.Writer) {
+	output.Lock()
+	defer output.Unlock()
+
+	output.w = w
+}
+
+// SetEncoding changes the format of the lines written to the output of the loggers.
+func SetEncoding(encoding Encoding) {
+	output.Lock()
+	defer output.Unlock()
+
+	output.encoding = encoding
+}
+
+// files receives the lines of every logger in its own encoding, nothing is written while it is nil.
+var files = &switchWriter{}
+
+// SetFile sets the writer getting the lines of every logger, like a File. Nil stops it.
+func SetFile(w io.Writer, encoding Encoding) {
+	files.Lock()
+	defer files.Unlock()
+
+	files.w = w
+	files.encoding = encoding
+}
+
+// Sink receives every logged entry besides the output and the file, like a Ring.
+type Sink interface {
+	Log(entry Entry)
+}
+
+var sinks = struct {
+	sync.RWMutex
+	list []Sink
+}{}
+
+// AddSink starts passing the entries of every logger to the sink.
+func AddSink(sink Sink) {
+	sinks.Lock()
+	defer sinks.Unlock()
+
+	sinks.list = append(sinks.list, sink)
+}
+
+// RemoveSink stops passing entries to a sink added with AddSink.
+func RemoveSink(sink Sink) {
+	sinks.Lock()
+	defer sinks.Unlock()
+
+	for i, s := range sinks.list {
+		if s == sink {
+			sinks.list = append(sinks.list[:i:i], sinks.list[i+1:]...)
+			return
+		}
+	}
+}
+
 type Logging struct {
 	name   string
 	writer io.Writer
-	show   []LogLevel
+	min    LogLevel
+	fields []Field
+	// cached is the resolved threshold and the rules version it was resolved at,
+	// shared with the children of the logger.
+	cached *uint64
 }
 
 func (log *Logging) Name() string {
 	return log.name
 }
 
-func (log *Logging) Show() []LogLevel {
-	return log.show
-}
+// Level is the threshold of the logger, the one of the rule matching its name if any.
+func (log *Logging) Level() LogLevel {
+	current := atomic.LoadUint64(&version)
+	if cached := atomic.LoadUint64(log.cached); cached>>8 == current {
+		return LogLevel(cached & 0xff)
+	}
 
-func (log *Logging) formatPrint(level, message string) {
-	_, _ = fmt.Fprint(log.writer, fmt.Sprintf("[%s] [%s] [%s] %s\n", color.HiGreenString(currentTimeAsText()), level, color.WhiteString(log.Name()), chat.TranslateConsole(message)))
+	min := log.min
+	if _, rule, ok := Rule(log.name); ok {
+		min = rule
+	}
+	atomic.StoreUint64(log.cached, current<<8|uint64(min))
+	return min
 }
 
-func (log *Logging) info(message string) {
-	log.formatPrint(color.CyanString("INFO"), message)
+// Enabled reports whether the logger writes lines of the level.
+func (log *Logging) Enabled(level LogLevel) bool {
+	return log.Level().Shows(level)
 }
 
-func (log *Logging) warn(message string) {
-	log.formatPrint(color.YellowString("WARN"), message)
+// With returns a logger of the same name tagging its lines with the fields after the ones of this logger.
+func (log *Logging) With(fields ...Field) *Logging {
+	child := *log
+	child.fields = append(append([]Field(nil), log.fields...), fields...)
+	return &child
 }
 
-func (log *Logging) fail(message string) {
-	log.formatPrint(color.RedString("FAIL"), message)
+// Fields returns the fields the lines of the logger are tagged with.
+func (log *Logging) Fields() []Field {
+	return append([]Field(nil), log.fields...)
 }
 
-func (log *Logging) data(message string) {
-	log.formatPrint(color.MagentaString("DATA"), message)
+func (log *Logging) write(level LogLevel, message string) {
+	entry := Entry{Time: time.Now(), Level: level, Logger: log.name, Message: message, Fields: log.fields}
+
+	output.RLock()
+	encoding := output.encoding
+	output.RUnlock()
+	_, _ = log.writer.Write(encoding.Encode(entry))
+
+	files.RLock()
+	if files.w != nil {
+		_, _ = files.w.Write(files.encoding.Encode(entry))
+	}
+	files.RUnlock()
+
+	sinks.RLock()
+	defer sinks.RUnlock()
+	for _, sink := range sinks.list {
+		sink.Log(entry)
+	}
 }
 
 func (log *Logging) Info(message ...interface{}) {
-	if !checkIfLevelShows(log, Info) {
+	if !log.Enabled(Info) {
 		return
 	}
 
-	log.info(helper.ConvertToString(message...))
+	log.write(Info, helper.ConvertToString(message...))
 }
 
 func (log *Logging) Warn(message ...interface{}) {
-	if !checkIfLevelShows(log, Warn) {
+	if !log.Enabled(Warn) {
 		return
 	}
 
-	log.warn(helper.ConvertToString(message...))
+	log.write(Warn, helper.ConvertToString(message...))
 }
 
 func (log *Logging) Fail(message ...interface{}) {
-	if !checkIfLevelShows(log, Fail) {
+	if !log.Enabled(Fail) {
 		return
 	}
 
-	log.fail(helper.ConvertToString(message...))
+	log.write(Fail, helper.ConvertToString(message...))
 }
 
 func (log *Logging) Data(message ...interface{}) {
-	if !checkIfLevelShows(log, Data) {
+	if !log.Enabled(Data) {
 		return
 	}
 
-	log.data(helper.ConvertToString(message...))
+	log.write(Data, helper.ConvertToString(message...))
 }
 
 func (log *Logging) InfoF(format string, a ...interface{}) {
-	if !checkIfLevelShows(log, Info) {
+	if !log.Enabled(Info) {
 		return
 	}
 
-	log.info(fmt.Sprintf(format, a...))
+	log.write(Info, fmt.Sprintf(format, a...))
 }
 
 func (log *Logging) WarnF(format string, a ...interface{}) {
-	if !checkIfLevelShows(log, Warn) {
+	if !log.Enabled(Warn) {
 		return
 	}
 
-	log.warn(fmt.Sprintf(format, a...))
+	log.write(Warn, fmt.Sprintf(format, a...))
 }
 
 func (log *Logging) FailF(format string, a ...interface{}) {
-	if !checkIfLevelShows(log, Fail) {
+	if !log.Enabled(Fail) {
 		return
 	}
 
-	log.fail(fmt.Sprintf(format, a...))
+	log.write(Fail, fmt.Sprintf(format, a...))
 }
 
 func (log *Logging) DataF(format string, a ...interface{}) {
-	if !checkIfLevelShows(log, Data) {
+	if !log.Enabled(Data) {
 		return
 	}
 
-	log.data(fmt.Sprintf(format, a...))
+	log.write(Data, fmt.Sprintf(format, a...))
 }
 
+// New creates a logger writing to the shared output, its threshold is the most
+// verbose of the given levels, Info without any, unless a rule set with SetLevel matches its name.
 func New(name string, show ...LogLevel) *Logging {
-	return NewWith(name, os.Stdout, show...)
+	return NewWith(name, output, show...)
 }
 
 func NewWith(name string, writer io.Writer, show ...LogLevel) *Logging {
-	return &Logging{name: name, writer: writer, show: show}
-}
-
-func currentTimeAsText() string {
-	h, m, s := time.Now().Clock()
-	return fmt.Sprintf("%02d:%02d:%02d", h, m, s)
-}
+	rules.Lock()
+	rules.names[name] = true
+	rules.Unlock()
 
-func checkIfLevelShows(log *Logging, lvl LogLevel) bool {
-	for _, a := range log.Show() {
-		if a == lvl {
-			return true
-		}
-	}
-	return false
+	return &Logging{name: name, writer: writer, min: threshold(show), cached: new(uint64)}
 }
@@ -0,0 +1,121 @@
+package log
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OCharnyshevich/proxycraft/proxy/player/chat"
+)
+
+// Ring is a Sink keeping the latest entries in memory to be searched.
+type Ring struct {
+	mu      sync.RWMutex
+	entries []Entry
+	next    int
+	full    bool
+}
+
+// NewRing keeps the latest size entries.
+func NewRing(size int) *Ring {
+	return &Ring{entries: make([]Entry, size)}
+}
+
+func (r *Ring) Log(entry Entry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.entries) == 0 {
+		return
+	}
+
+	r.entries[r.next] = entry
+	r.next = (r.next + 1) % len(r.entries)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// Len is the number of kept entries.
+func (r *Ring) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.full {
+		return len(r.entries)
+	}
+	return r.next
+}
+
+// Query selects entries of a Ring, the zero value matches every entry.
+type Query struct {
+	// Logger is a logger name or a pattern like "session.*".
+	Logger string
+	// Level is the threshold of the entries, like warn for warn and fail entries.
+	// The zero value Info leaves out data entries, use Data to get every entry.
+	Level LogLevel
+	// Since and Until bound the time of the entries when set, Until is exclusive.
+	Since time.Time
+	Until time.Time
+	// Text is found in the message or the field values, ignoring case and colors.
+	Text string
+	// Limit keeps the newest entries, all of them when zero.
+	Limit int
+}
+
+func (q Query) matches(entry Entry) bool {
+	if q.Logger != "" && !match(q.Logger, entry.Logger) {
+		return false
+	}
+	if !q.Level.Shows(entry.Level) {
+		return false
+	}
+	if !q.Since.IsZero() && entry.Time.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && !entry.Time.Before(q.Until) {
+		return false
+	}
+	if q.Text == "" {
+		return true
+	}
+
+	text := strings.ToLower(q.Text)
+	if strings.Contains(strings.ToLower(chat.Strip(entry.Message)), text) {
+		return true
+	}
+	for _, f := range entry.Fields {
+		if strings.Contains(strings.ToLower(fmt.Sprint(f.Value)), text) {
+			return true
+		}
+	}
+	return false
+}
+
+// Search returns the entries matching the query, oldest first.
+func (r *Ring) Search(q Query) []Entry {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var found []Entry
+	// newest first, so the limit keeps the latest
+	for i := 0; i < len(r.entries); i++ {
+		index := (r.next - 1 - i + len(r.entries)) % len(r.entries)
+		if !r.full && index >= r.next {
+			break
+		}
+
+		if q.matches(r.entries[index]) {
+			found = append(found, r.entries[index])
+			if q.Limit > 0 && len(found) == q.Limit {
+				break
+			}
+		}
+	}
+
+	for i, j := 0, len(found)-1; i < j; i, j = i+1, j-1 {
+		found[i], found[j] = found[j], found[i]
+	}
+	return found
+}
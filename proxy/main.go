@@ -1,57 +1,182 @@
 package proxy
 
 import (
+	"fmt"
+	"strings"
+
+	"github.com/OCharnyshevich/proxycraft/proxy/chatlog"
+	"github.com/OCharnyshevich/proxycraft/proxy/command"
 	"github.com/OCharnyshevich/proxycraft/proxy/console"
+	"github.com/OCharnyshevich/proxycraft/proxy/dashboard"
 	"github.com/OCharnyshevich/proxycraft/proxy/helper"
 	"github.com/OCharnyshevich/proxycraft/proxy/log"
 	"github.com/OCharnyshevich/proxycraft/proxy/network"
+	"github.com/OCharnyshevich/proxycraft/proxy/permission"
 	"github.com/OCharnyshevich/proxycraft/proxy/player/chat"
+	"github.com/OCharnyshevich/proxycraft/proxy/plugin"
 )
 
 type proxy struct {
-	message chan helper.Message
-	console *console.Console
-	logging *log.Logging
-	network helper.Network
+	config      *Config
+	message     chan helper.Message
+	console     *console.Console
+	logging     *log.Logging
+	logs        *log.File
+	buffer      *log.Ring
+	chat        *chatlog.Archive
+	typed       typedLines
+	network     helper.Network
+	dashboard   *dashboard.Dashboard
+	commands    *command.Registry
+	permissions *permission.Store
+	plugins     *plugin.Manager
 }
 
 func New(config *Config) (*proxy, error) {
+	if config.Console.Encoding != "" {
+		log.SetEncoding(config.Console.Encoding)
+	}
+	for pattern, name := range config.LogLevels {
+		min, err := log.ParseLevel(name)
+		if err != nil {
+			return nil, fmt.Errorf("log level of %s: %w", pattern, err)
+		}
+		if err := log.SetLevel(pattern, min); err != nil {
+			return nil, err
+		}
+	}
+
+	if config.Language != "" {
+		table, err := chat.LoadLanguage(config.Language)
+		if err != nil {
+			return nil, fmt.Errorf("language: %w", err)
+		}
+		chat.SetLanguage(table)
+	}
+
+	var buffer *log.Ring
+	if config.LogBuffer > 0 {
+		buffer = log.NewRing(config.LogBuffer)
+		log.AddSink(buffer)
+	}
+
 	message := make(chan helper.Message)
 	c := console.New(message)
-	l := log.New("proxy", log.EveryLevel...)
+	l := log.New("proxy")
 	e := network.NewEvents()
 
 	n := network.New(
 		message,
 		config.Local.Host, config.Local.Port,
-		config.Remote.Host, config.Remote.Port,
+		config.Remote.Host, config.Remote.Port, config.Remote.Protocol,
 		e,
 	)
 
-	return &proxy{
-		message: message,
-		console: c,
-		logging: l,
-		network: n,
-	}, nil
+	permissions, err := permission.Open(config.Permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	var logs *log.File
+	if config.Logs.Directory != "" {
+		if logs, err = log.OpenFile(config.Logs); err != nil {
+			return nil, err
+		}
+		encoding := config.Logs.Encoding
+		if encoding == "" {
+			encoding = log.Text
+		}
+		log.SetFile(logs, encoding)
+	}
+
+	var archive *chatlog.Archive
+	if config.ChatLog != "" {
+		if archive, err = chatlog.Open(config.ChatLog); err != nil {
+			return nil, err
+		}
+	}
+
+	commands := command.NewRegistry()
+	commands.SetPermissions(permissions.Allowed)
+
+	p := &proxy{
+		config:      config,
+		message:     message,
+		console:     c,
+		logging:     l,
+		logs:        logs,
+		buffer:      buffer,
+		chat:        archive,
+		network:     n,
+		commands:    commands,
+		permissions: permissions,
+		plugins:     plugin.NewManager(n, commands, buffer, archive, config.Plugins),
+	}
+	p.registerCommands()
+	p.listenGame()
+	p.listenChat()
+
+	if config.Dashboard {
+		if dashboard.Supported() {
+			p.dashboard = dashboard.New(n, p.backends(), c.Input)
+			// the dashboard reads the keys instead of the console
+			c.SetInput(nil)
+		} else {
+			l.Warn("the dashboard needs a terminal, using the console")
+		}
+	}
+
+	c.SetHistory(config.Console.History)
+	c.SetCompleter(func(line string) []string {
+		return p.commands.Complete(c, strings.TrimPrefix(line, "/"))
+	})
+
+	return p, nil
 }
 
 func (p *proxy) Load() {
 	p.console.Load()
+	if p.dashboard != nil {
+		p.dashboard.Load()
+	}
+	go p.dispatch()
+	// plugins subscribe to the events before the first session is accepted
+	p.plugins.Load()
 	p.network.Load()
 
 	p.wait()
 }
 
 func (p *proxy) Kill() {
+	if p.dashboard != nil {
+		p.dashboard.Kill()
+	}
+	p.plugins.Kill()
 	p.console.Kill()
 	p.network.Kill()
+	if p.chat != nil {
+		_ = p.chat.Close()
+	}
+
+	p.logging.Info(chat.DarkRed, "server stopped")
+	// the process ends with the stop message, so the log is archived before
+	p.closeLogs()
 
 	// push the stop message to the server exit channel
 	p.message <- helper.Make(helper.STOP, "normal stop")
 	close(p.message)
+}
 
-	p.logging.Info(chat.DarkRed, "server stopped")
+// closeLogs archives the log file.
+func (p *proxy) closeLogs() {
+	if p.logs == nil {
+		return
+	}
+
+	log.SetFile(nil, "")
+	if err := p.logs.Close(); err != nil {
+		p.logging.FailF("unable to archive the log: %v", err)
+	}
 }
 
 func (p *proxy) wait() {
@@ -65,6 +190,12 @@ func (p *proxy) wait() {
 		case helper.FAIL:
 			p.logging.Fail("internal server error: ", command.Message)
 			p.logging.Fail("stopping server")
+			// leave the raw mode of the terminal
+			if p.dashboard != nil {
+				p.dashboard.Kill()
+			}
+			p.console.Kill()
+			p.closeLogs()
 			return
 		}
 	}
@@ -80,6 +211,19 @@ func (p *proxy) Network() helper.Network {
 	return p.network
 }
 
+func (p *proxy) Commands() *command.Registry {
+	return p.commands
+}
+
+func (p *proxy) Permissions() *permission.Store {
+	return p.permissions
+}
+
+// Register adds plugins which are loaded together with the proxy.
+func (p *proxy) Register(plugins ...plugin.Plugin) {
+	p.plugins.Register(plugins...)
+}
+
 func (p *proxy) Broadcast(message string) {
 	//p.console.SendMessage(message)
 
@@ -0,0 +1,410 @@
+// Package moderation filters the chat lines players send before they reach the
+// backend.
+//
+// Every line goes through a chain: mutes, the cooldown and flood limits, link
+// blocking, the regex filters of the config, then the caps and repeated-character
+// limits. A step either blocks the line, telling the player why, or rewrites it.
+// Every action is logged. Commands, lines starting with "/", are left alone.
+package moderation
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/OCharnyshevich/proxycraft/proxy/chatlog"
+	"github.com/OCharnyshevich/proxycraft/proxy/helper"
+	"github.com/OCharnyshevich/proxycraft/proxy/log"
+	"github.com/OCharnyshevich/proxycraft/proxy/network"
+	"github.com/OCharnyshevich/proxycraft/proxy/network/protocol"
+	"github.com/OCharnyshevich/proxycraft/proxy/player/chat"
+	"github.com/OCharnyshevich/proxycraft/proxy/plugin"
+	mcNet "github.com/Tnze/go-mc/net"
+	pk "github.com/Tnze/go-mc/net/packet"
+)
+
+// Bypass is the permission node of the players whose chat is not filtered.
+const Bypass = "proxy.moderation.bypass"
+
+// Filter actions.
+const (
+	Replace = "replace"
+	Block   = "block"
+)
+
+type Config struct {
+	// Filters are case-insensitive regular expressions checked in order.
+	Filters []Filter `json:"filters"`
+	// MaxCaps is the percent of upper case letters allowed in lines of CapsMinLength letters
+	// and more, lines above it are lowered. Zero allows any.
+	MaxCaps       int `json:"max_caps"`
+	CapsMinLength int `json:"caps_min_length"`
+	// MaxRepeat is the number of times a character may repeat in a row, longer runs are
+	// shortened. Zero allows any.
+	MaxRepeat int `json:"max_repeat"`
+	// Cooldown is the time a player waits between two lines, like "1s".
+	Cooldown string `json:"cooldown"`
+	// FloodLines is the number of lines a player may send within FloodWindow, the player
+	// is muted for FloodMute past it. Zero allows any.
+	FloodLines  int    `json:"flood_lines"`
+	FloodWindow string `json:"flood_window"`
+	FloodMute   string `json:"flood_mute"`
+	// Duplicates blocks a line repeating the previous one of the player within FloodWindow.
+	Duplicates bool `json:"duplicates"`
+	// BlockLinks blocks lines with web addresses or IP addresses, except the hosts of
+	// AllowLinks and their subdomains.
+	BlockLinks bool     `json:"block_links"`
+	AllowLinks []string `json:"allow_links"`
+	// Mutes is the file keeping the muted players.
+	Mutes string `json:"mutes"`
+}
+
+type Filter struct {
+	Pattern string `json:"pattern"`
+	// Action is replace or block.
+	Action string `json:"action"`
+	// Replacement is put in place of the matches, a * per character when empty.
+	Replacement string `json:"replacement"`
+	// Reason is told to the player when the line is blocked.
+	Reason string `json:"reason"`
+
+	regexp *regexp.Regexp
+}
+
+// Moderator is the plugin filtering the chat.
+type Moderator struct {
+	ctx    *plugin.Context
+	config Config
+
+	cooldown    time.Duration
+	floodWindow time.Duration
+	floodMute   time.Duration
+
+	mutes *mutes
+
+	mu      sync.Mutex
+	history map[int32]*history
+}
+
+// history is the recent chat of a session.
+type history struct {
+	last  time.Time
+	lines []time.Time
+	text  string
+}
+
+func New() *Moderator {
+	return &Moderator{
+		config: Config{
+			MaxCaps:       70,
+			CapsMinLength: 8,
+			MaxRepeat:     4,
+			Cooldown:      "500ms",
+			FloodLines:    5,
+			FloodWindow:   "5s",
+			FloodMute:     "1m",
+			Duplicates:    true,
+			Mutes:         "mutes.json",
+		},
+		history: make(map[int32]*history),
+	}
+}
+
+func (m *Moderator) Info() plugin.Info {
+	return plugin.Info{Name: "moderation", Version: "1.0.0"}
+}
+
+func (m *Moderator) Attach(ctx *plugin.Context) {
+	m.ctx = ctx
+}
+
+func (m *Moderator) Load() {
+	if err := m.ctx.Config.Decode(&m.config); err != nil {
+		m.ctx.Logger.FailF("invalid config: %v", err)
+		return
+	}
+	if err := m.parse(); err != nil {
+		m.ctx.Logger.Fail(err)
+		return
+	}
+
+	mutes, err := openMutes(m.config.Mutes)
+	if err != nil {
+		m.ctx.Logger.FailF("unable to read the mutes: %v", err)
+		return
+	}
+	m.mutes = mutes
+
+	m.ctx.Listen(network.PacketHandler{Priority: 16, Name: protocol.ChatServerbound, F: m.onChat})
+	m.ctx.ListenSessions(network.SessionHandler{F: func(event network.SessionEvent, session helper.Sessionable) {
+		if event == network.SessionClosed {
+			m.mu.Lock()
+			delete(m.history, session.ID())
+			m.mu.Unlock()
+		}
+	}})
+	if err := m.ctx.RegisterCommand(m.commands()...); err != nil {
+		m.ctx.Logger.Fail(err)
+	}
+}
+
+func (m *Moderator) Kill() {
+	m.mu.Lock()
+	m.history = make(map[int32]*history)
+	m.mu.Unlock()
+}
+
+// parse reads the durations and the patterns of the config.
+func (m *Moderator) parse() error {
+	durations := []struct {
+		name  string
+		value string
+		to    *time.Duration
+	}{
+		{"cooldown", m.config.Cooldown, &m.cooldown},
+		{"flood_window", m.config.FloodWindow, &m.floodWindow},
+		{"flood_mute", m.config.FloodMute, &m.floodMute},
+	}
+	for _, d := range durations {
+		*d.to = 0
+		if d.value == "" {
+			continue
+		}
+		v, err := time.ParseDuration(d.value)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", d.name, err)
+		}
+		*d.to = v
+	}
+
+	for i := range m.config.Filters {
+		f := &m.config.Filters[i]
+		if f.Action != Replace && f.Action != Block {
+			return fmt.Errorf("invalid action %q of filter %q, use replace or block", f.Action, f.Pattern)
+		}
+		re, err := regexp.Compile("(?i)" + f.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid filter %q: %w", f.Pattern, err)
+		}
+		f.regexp = re
+	}
+	return nil
+}
+
+func (m *Moderator) onChat(_ *mcNet.Conn, _ *mcNet.Conn, packet network.Packet) error {
+	var message pk.String
+	if err := packet.Scan(&message); err != nil {
+		return err
+	}
+
+	text := string(message)
+	if strings.HasPrefix(text, "/") {
+		return nil
+	}
+
+	filtered, reason := m.Check(packet.Session, text)
+	if reason != "" {
+		m.ctx.ArchiveChat(chatlog.Typed(packet.Session, text, chatlog.Blocked))
+		packet.Session.SendMessage(chat.Red, reason)
+		return network.Drop
+	}
+	if filtered == text {
+		return nil
+	}
+
+	// the message is the first field, the fields of newer versions follow it
+	var original, replaced bytes.Buffer
+	_, _ = message.WriteTo(&original)
+	_, _ = pk.String(filtered).WriteTo(&replaced)
+	replaced.Write(packet.Data[original.Len():])
+
+	return network.Rewrite{Data: replaced.Bytes()}
+}
+
+// Check runs the chain on a chat line of the player, it returns the line to forward or the
+// reason it is blocked. Plugins delivering chat themselves, like channels, call it too.
+func (m *Moderator) Check(session helper.Sessionable, text string) (string, string) {
+	if m.mutes == nil || m.ctx.Permitted(session, Bypass) {
+		return text, ""
+	}
+	return m.check(session, text)
+}
+
+func (m *Moderator) check(session helper.Sessionable, text string) (string, string) {
+	logger := m.ctx.Logger.With(log.Session(session.ID()), log.Player(session.Name()))
+
+	if mute, ok := m.mutes.get(session.Name()); ok {
+		logger.InfoF("blocked a line of the muted %s: %s", session.Name(), text)
+		return "", mute.String()
+	}
+
+	if reason := m.flood(session, text); reason != "" {
+		logger.InfoF("blocked a line of %s (%s): %s", session.Name(), reason, text)
+		return "", reason
+	}
+
+	if m.config.BlockLinks {
+		if link, ok := m.link(text); ok {
+			logger.InfoF("blocked a line of %s linking %s: %s", session.Name(), link, text)
+			return "", "Links are not allowed in chat."
+		}
+	}
+
+	for _, f := range m.config.Filters {
+		if !f.regexp.MatchString(text) {
+			continue
+		}
+		if f.Action == Block {
+			logger.InfoF("blocked a line of %s matching %q: %s", session.Name(), f.Pattern, text)
+			if f.Reason == "" {
+				return "", "Your message was blocked."
+			}
+			return "", f.Reason
+		}
+
+		replaced := f.regexp.ReplaceAllStringFunc(text, func(match string) string {
+			if f.Replacement != "" {
+				return f.Replacement
+			}
+			return strings.Repeat("*", len([]rune(match)))
+		})
+		logger.InfoF("replaced %q in a line of %s: %s", f.Pattern, session.Name(), text)
+		text = replaced
+	}
+
+	if lowered, ok := m.caps(text); ok {
+		logger.InfoF("lowered a line of %s with too many caps: %s", session.Name(), text)
+		text = lowered
+	}
+	if shortened, ok := m.repeats(text); ok {
+		logger.InfoF("shortened the repeated characters of a line of %s: %s", session.Name(), text)
+		text = shortened
+	}
+	return text, ""
+}
+
+// flood checks the cooldown, the flood limit and the duplicates, it returns the reason
+// a line is blocked.
+func (m *Moderator) flood(session helper.Sessionable, text string) string {
+	now := time.Now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	h := m.history[session.ID()]
+	if h == nil {
+		h = &history{}
+		m.history[session.ID()] = h
+	}
+
+	if m.cooldown > 0 && now.Sub(h.last) < m.cooldown {
+		return fmt.Sprintf("Please wait %s between messages.", m.cooldown)
+	}
+
+	lines := h.lines[:0]
+	for _, t := range h.lines {
+		if now.Sub(t) < m.floodWindow {
+			lines = append(lines, t)
+		}
+	}
+	h.lines = lines
+
+	if m.config.Duplicates && len(h.lines) > 0 && strings.EqualFold(h.text, text) {
+		return "Please do not repeat yourself."
+	}
+	if m.config.FloodLines > 0 && len(h.lines) >= m.config.FloodLines {
+		if m.floodMute > 0 {
+			until := now.Add(m.floodMute)
+			if err := m.mutes.set(Mute{Name: session.Name(), Until: &until, Reason: "flooding the chat", By: "moderation"}); err != nil {
+				m.ctx.Logger.FailF("unable to save the mutes: %v", err)
+			}
+			return fmt.Sprintf("You are muted for %s for flooding the chat.", m.floodMute)
+		}
+		return "You are sending messages too fast."
+	}
+
+	h.last, h.text = now, text
+	h.lines = append(h.lines, now)
+	return ""
+}
+
+// links matches web addresses, with or without a scheme, and IP addresses.
+var links = regexp.MustCompile(`(?i)(?:https?://)?(?:(?:[a-z0-9](?:[a-z0-9-]*[a-z0-9])?\.)+[a-z]{2,24}|\d{1,3}(?:\.\d{1,3}){3})(?::\d{1,5})?(?:/\S*)?`)
+
+// link returns the first link of the text whose host is not allowed.
+func (m *Moderator) link(text string) (string, bool) {
+	for _, match := range links.FindAllString(text, -1) {
+		host := strings.ToLower(match)
+		if i := strings.Index(host, "://"); i >= 0 {
+			host = host[i+3:]
+		}
+		if i := strings.IndexAny(host, ":/"); i >= 0 {
+			host = host[:i]
+		}
+
+		allowed := false
+		for _, allow := range m.config.AllowLinks {
+			allow = strings.ToLower(allow)
+			if host == allow || strings.HasSuffix(host, "."+allow) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return match, true
+		}
+	}
+	return "", false
+}
+
+// caps lowers the text when it has too many upper case letters.
+func (m *Moderator) caps(text string) (string, bool) {
+	if m.config.MaxCaps <= 0 {
+		return text, false
+	}
+
+	letters, upper := 0, 0
+	for _, r := range text {
+		if unicode.IsLetter(r) {
+			letters++
+			if unicode.IsUpper(r) {
+				upper++
+			}
+		}
+	}
+	if letters < m.config.CapsMinLength || letters == 0 || upper*100/letters <= m.config.MaxCaps {
+		return text, false
+	}
+	return strings.ToLower(text), true
+}
+
+// repeats shortens the runs of a character longer than MaxRepeat.
+func (m *Moderator) repeats(text string) (string, bool) {
+	if m.config.MaxRepeat <= 0 {
+		return text, false
+	}
+
+	var (
+		b       strings.Builder
+		last    rune
+		run     int
+		changed bool
+	)
+	for _, r := range text {
+		if r == last {
+			run++
+		} else {
+			last, run = r, 1
+		}
+		if run > m.config.MaxRepeat {
+			changed = true
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String(), changed
+}
@@ -0,0 +1,232 @@
+package moderation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OCharnyshevich/proxycraft/proxy/command"
+	"github.com/OCharnyshevich/proxycraft/proxy/player/chat"
+)
+
+// Mute keeps a player from chatting, by name.
+type Mute struct {
+	Name string `json:"name"`
+	// Until is the end of the mute, it lasts for good when nil.
+	Until  *time.Time `json:"until,omitempty"`
+	Reason string     `json:"reason,omitempty"`
+	By     string     `json:"by"`
+}
+
+// String is the message telling the player about the mute.
+func (m Mute) String() string {
+	s := "You are muted"
+	if m.Until != nil {
+		s += " for " + time.Until(*m.Until).Round(time.Second).String()
+	}
+	if m.Reason != "" {
+		s += ": " + m.Reason
+	}
+	return s + "."
+}
+
+// mutes are the muted players, kept in a JSON file.
+type mutes struct {
+	mu   sync.Mutex
+	path string
+	list map[string]Mute
+}
+
+func openMutes(path string) (*mutes, error) {
+	m := &mutes{path: path, list: make(map[string]Mute)}
+	if path == "" {
+		return m, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var list []Mute
+	if err := json.Unmarshal(content, &list); err != nil {
+		return nil, err
+	}
+	for _, mute := range list {
+		m.list[strings.ToLower(mute.Name)] = mute
+	}
+	return m, nil
+}
+
+// get returns the mute of the player, the expired mutes are removed.
+func (m *mutes) get(name string) (Mute, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	mute, ok := m.list[strings.ToLower(name)]
+	if ok && mute.Until != nil && time.Now().After(*mute.Until) {
+		delete(m.list, strings.ToLower(name))
+		_ = m.save()
+		return Mute{}, false
+	}
+	return mute, ok
+}
+
+func (m *mutes) set(mute Mute) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.list[strings.ToLower(mute.Name)] = mute
+	return m.save()
+}
+
+func (m *mutes) remove(name string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.list[strings.ToLower(name)]; !ok {
+		return false, nil
+	}
+	delete(m.list, strings.ToLower(name))
+	return true, m.save()
+}
+
+// all returns the mutes which have not expired, ordered by name.
+func (m *mutes) all() []Mute {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var list []Mute
+	for _, mute := range m.list {
+		if mute.Until == nil || time.Now().Before(*mute.Until) {
+			list = append(list, mute)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return strings.ToLower(list[i].Name) < strings.ToLower(list[j].Name) })
+	return list
+}
+
+func (m *mutes) save() error {
+	if m.path == "" {
+		return nil
+	}
+
+	list := make([]Mute, 0, len(m.list))
+	for _, mute := range m.list {
+		list = append(list, mute)
+	}
+	sort.Slice(list, func(i, j int) bool { return strings.ToLower(list[i].Name) < strings.ToLower(list[j].Name) })
+
+	content, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(m.path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+
+	tmp := m.path + ".tmp"
+	if err := os.WriteFile(tmp, content, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, m.path)
+}
+
+// mute mutes a player for the duration, for good when it is zero, and tells the player.
+func (m *Moderator) mute(name string, duration time.Duration, reason, by string) error {
+	mute := Mute{Name: name, Reason: reason, By: by}
+	if duration > 0 {
+		until := time.Now().Add(duration)
+		mute.Until = &until
+	}
+	if err := m.mutes.set(mute); err != nil {
+		m.ctx.Logger.FailF("unable to save the mutes: %v", err)
+		return err
+	}
+
+	for _, session := range m.ctx.Network.Sessions() {
+		if strings.EqualFold(session.Name(), name) {
+			mute.Name = session.Name()
+			session.SendMessage(chat.Red, mute.String())
+		}
+	}
+
+	length := "for good"
+	if duration > 0 {
+		length = "for " + duration.String()
+	}
+	m.ctx.Logger.InfoF("%s muted %s %s: %s", by, mute.Name, length, reason)
+	return nil
+}
+
+func (m *Moderator) commands() []command.Command {
+	return []command.Command{
+		{
+			Name:        "mute",
+			Description: "keeps a player from chatting, for good without a duration or with 0",
+			Params: []command.Param{
+				{Name: "player", Type: command.Player},
+				{Name: "duration", Type: command.Duration, Optional: true},
+				{Name: "reason", Type: command.Text, Optional: true},
+			},
+			Run: func(sender command.Sender, args command.Args) error {
+				return m.mute(args.String("player"), args.Duration("duration"), args.String("reason"), sender.Name())
+			},
+		},
+		{
+			Name:        "unmute",
+			Description: "lets a muted player chat again",
+			Params:      []command.Param{{Name: "player", Type: command.Player, Complete: m.muted}},
+			Run: func(sender command.Sender, args command.Args) error {
+				name := args.String("player")
+				removed, err := m.mutes.remove(name)
+				if err != nil {
+					return err
+				}
+				if !removed {
+					return fmt.Errorf("%s is not muted", name)
+				}
+
+				m.ctx.Logger.InfoF("%s unmuted %s", sender.Name(), name)
+				sender.SendMessage(name + " is unmuted")
+				return nil
+			},
+		},
+		{
+			Name:        "mutes",
+			Description: "lists the muted players",
+			Run: func(sender command.Sender, _ command.Args) error {
+				list := m.mutes.all()
+				for _, mute := range list {
+					until := "for good"
+					if mute.Until != nil {
+						until = "until " + mute.Until.Format("2006-01-02 15:04:05")
+					}
+					sender.SendMessage(fmt.Sprintf("%s %s by %s: %s", mute.Name, until, mute.By, mute.Reason))
+				}
+				sender.SendMessage(fmt.Sprintf("%d muted players", len(list)))
+				return nil
+			},
+		},
+	}
+}
+
+// muted completes the names of the muted players.
+func (m *Moderator) muted(command.Sender, []string, string) []string {
+	var names []string
+	for _, mute := range m.mutes.all() {
+		names = append(names, mute.Name)
+	}
+	return names
+}
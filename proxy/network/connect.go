@@ -0,0 +1,244 @@
+package network
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/OCharnyshevich/proxycraft/proxy/network/protocol"
+	"github.com/OCharnyshevich/proxycraft/proxy/player/chat"
+	"github.com/Tnze/go-mc/data/packetid"
+	"github.com/Tnze/go-mc/nbt"
+	mcNet "github.com/Tnze/go-mc/net"
+	mcPkt "github.com/Tnze/go-mc/net/packet"
+)
+
+// DefaultServer is the name of the backend every session starts on.
+const DefaultServer = "default"
+
+// loginTimeout limits the login to a backend the player is moved to.
+const loginTimeout = 10 * time.Second
+
+func (s *session) serverConn() *mcNet.Conn {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.server
+}
+
+// Server is the name of the backend the player is connected to.
+func (s *session) Server() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.serverName
+}
+
+func (s *session) takeRejoin() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rejoin := s.rejoin
+	s.rejoin = false
+	return rejoin
+}
+
+// Connect moves the player to another backend speaking the same protocol version.
+// The player logs in to the new backend before the old connection is closed, so a
+// failed login leaves the player where they are. Only offline mode backends are supported.
+func (s *session) Connect(name, host string, port int) error {
+	if s.State() != Play {
+		return errors.New("player is not in game")
+	}
+
+	backend := s.Backend()
+	if backend == nil {
+		return errors.New("the protocol of the player is not supported")
+	}
+
+	conn, err := mcNet.DialMC(host + ":" + strconv.Itoa(port))
+	if err != nil {
+		return err
+	}
+
+	if err := s.loginTo(conn, backend, host, port); err != nil {
+		_ = conn.Close()
+		return err
+	}
+
+	s.mu.Lock()
+	old := s.server
+	s.server = conn
+	s.serverName = name
+	s.rejoin = true
+	s.mu.Unlock()
+
+	_ = old.Close()
+
+	s.tagLogger()
+	s.log().InfoF("%s moved to %s (%s:%d)", s.Name(), name, host, port)
+	return nil
+}
+
+// loginTo runs the login sequence on a new backend connection, up to Login Success.
+func (s *session) loginTo(conn *mcNet.Conn, backend *protocol.Version, host string, port int) error {
+	_ = conn.Socket.SetDeadline(time.Now().Add(loginTimeout))
+	defer func() { _ = conn.Socket.SetDeadline(time.Time{}) }()
+
+	handshake := mcPkt.Marshal(
+		protocol.HandshakeID,
+		mcPkt.VarInt(backend.Protocol),
+		mcPkt.String(host),
+		mcPkt.UnsignedShort(port),
+		mcPkt.VarInt(Login),
+	)
+	if err := conn.WritePacket(handshake); err != nil {
+		return err
+	}
+	if err := conn.WritePacket(loginStart(backend, s.Name(), s.UUID())); err != nil {
+		return err
+	}
+
+	for {
+		var packet mcPkt.Packet
+		if err := conn.ReadPacket(&packet); err != nil {
+			return err
+		}
+
+		switch packet.ID {
+		case packetid.Disconnect:
+			var reason chat.Component
+			_ = packet.Scan(&reason)
+			return fmt.Errorf("disconnected by the backend: %s", reason.Plain())
+		case packetid.EncryptionBeginClientbound:
+			return errors.New("the backend is in online mode")
+		case packetid.Compress:
+			var threshold mcPkt.VarInt
+			if err := packet.Scan(&threshold); err != nil {
+				return err
+			}
+			conn.SetThreshold(int(threshold))
+		case packetid.LoginPluginRequest:
+			var messageID mcPkt.VarInt
+			if err := packet.Scan(&messageID); err != nil {
+				return err
+			}
+			// the proxy does not understand any login plugin channel
+			if err := conn.WritePacket(mcPkt.Marshal(packetid.LoginPluginResponse, messageID, mcPkt.Boolean(false))); err != nil {
+				return err
+			}
+		case packetid.Success:
+			return nil
+		}
+	}
+}
+
+// loginStart builds Login Start in the layout of the given version.
+func loginStart(v *protocol.Version, name string, id [16]byte) mcPkt.Packet {
+	fields := []mcPkt.FieldEncoder{mcPkt.String(name)}
+	switch {
+	case v.Protocol >= 761:
+		fields = append(fields, mcPkt.Boolean(true), mcPkt.UUID(id))
+	case v.Protocol == 760:
+		fields = append(fields, mcPkt.Boolean(false), mcPkt.Boolean(true), mcPkt.UUID(id))
+	case v.Protocol == 759:
+		fields = append(fields, mcPkt.Boolean(false))
+	}
+
+	return mcPkt.Marshal(packetid.LoginStart, fields...)
+}
+
+// rejoined passes the Join Game of the new backend to the client, followed by a
+// respawn into another world and back, which makes the client drop the old world.
+func (s *session) rejoined(packet mcPkt.Packet, backend *protocol.Version) {
+	respawns, err := joinRespawns(packet, backend)
+	if err != nil {
+		s.log().WarnF("Unable to read join game of the new backend: %v", err)
+	}
+
+	if err := s.WritePacket(packet); err != nil {
+		s.log().WarnF("Unable to send packet to client: %v", err)
+		return
+	}
+
+	for _, respawn := range respawns {
+		if err := s.WritePacket(respawn); err != nil {
+			s.log().WarnF("Unable to send packet to client: %v", err)
+			return
+		}
+	}
+}
+
+// joinRespawns reads a Join Game and returns the two respawn packets switching worlds.
+func joinRespawns(packet mcPkt.Packet, v *protocol.Version) ([]mcPkt.Packet, error) {
+	var (
+		entityID         mcPkt.Int
+		hardcore         mcPkt.Boolean
+		gamemode         mcPkt.UnsignedByte
+		previousGamemode mcPkt.Byte
+		worldCount       mcPkt.VarInt
+		worlds           []mcPkt.Identifier
+		codec            nbt.RawMessage
+		dimensionNBT     nbt.RawMessage
+		dimensionName    mcPkt.Identifier
+		worldName        mcPkt.Identifier
+		hashedSeed       mcPkt.Long
+		maxPlayers       mcPkt.VarInt
+		viewDistance     mcPkt.VarInt
+		simulation       mcPkt.VarInt
+		reducedDebugInfo mcPkt.Boolean
+		respawnScreen    mcPkt.Boolean
+		debug            mcPkt.Boolean
+		flat             mcPkt.Boolean
+	)
+
+	var dimension mcPkt.Field = mcPkt.NBT(&dimensionNBT)
+	if v.Protocol >= 759 {
+		dimension = &dimensionName
+	}
+
+	fields := []mcPkt.FieldDecoder{
+		&entityID, &hardcore, &gamemode, &previousGamemode,
+		&worldCount, mcPkt.Ary{Len: &worldCount, Ary: &worlds},
+		mcPkt.NBT(&codec), dimension, &worldName, &hashedSeed,
+		&maxPlayers, &viewDistance,
+	}
+	if v.Protocol >= 757 {
+		fields = append(fields, &simulation)
+	}
+	fields = append(fields, &reducedDebugInfo, &respawnScreen, &debug, &flat)
+
+	if err := packet.Scan(fields...); err != nil {
+		return nil, err
+	}
+
+	var dimensionField mcPkt.FieldEncoder = mcPkt.NBT(dimensionNBT)
+	if v.Protocol >= 759 {
+		dimensionField = dimensionName
+	}
+
+	other := mcPkt.Identifier("minecraft:the_end")
+	if worldName == other {
+		other = "minecraft:overworld"
+	}
+
+	var respawns []mcPkt.Packet
+	for _, world := range []mcPkt.Identifier{other, worldName} {
+		fields := []mcPkt.FieldEncoder{
+			dimensionField, world, hashedSeed, gamemode, previousGamemode, debug, flat,
+			mcPkt.Boolean(false), // no metadata kept
+		}
+		if v.Protocol >= 759 {
+			fields = append(fields, mcPkt.Boolean(false)) // no last death location
+		}
+
+		respawn, err := v.Marshal(protocol.Respawn, fields...)
+		if err != nil {
+			return nil, err
+		}
+		respawns = append(respawns, respawn)
+	}
+
+	return respawns, nil
+}
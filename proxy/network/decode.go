@@ -0,0 +1,61 @@
+package network
+
+import (
+	"github.com/OCharnyshevich/proxycraft/proxy/network/protocol"
+	pk "github.com/Tnze/go-mc/net/packet"
+)
+
+// decoders read the fields of the packets which are commonly inspected,
+// like by the event stream and the packet tracer.
+var decoders = map[protocol.Name]func(p Packet) (map[string]interface{}, error){
+	protocol.UpdateTime: func(p Packet) (map[string]interface{}, error) {
+		var age, time pk.Long
+		err := p.Scan(&age, &time)
+		return map[string]interface{}{"world_age": age, "time_of_day": time}, err
+	},
+	protocol.UpdateHealth: func(p Packet) (map[string]interface{}, error) {
+		var (
+			health     pk.Float
+			food       pk.VarInt
+			saturation pk.Float
+		)
+		err := p.Scan(&health, &food, &saturation)
+		return map[string]interface{}{"health": health, "food": food, "saturation": saturation}, err
+	},
+	protocol.GameStateChange: func(p Packet) (map[string]interface{}, error) {
+		var (
+			reason pk.UnsignedByte
+			value  pk.Float
+		)
+		err := p.Scan(&reason, &value)
+		return map[string]interface{}{"reason": reason, "value": value}, err
+	},
+	protocol.Experience: func(p Packet) (map[string]interface{}, error) {
+		var (
+			bar   pk.Float
+			level pk.VarInt
+			total pk.VarInt
+		)
+		err := p.Scan(&bar, &level, &total)
+		return map[string]interface{}{"bar": bar, "level": level, "total": total}, err
+	},
+	protocol.PositionClientbound: func(p Packet) (map[string]interface{}, error) {
+		var (
+			x, y, z    pk.Double
+			yaw, pitch pk.Float
+		)
+		err := p.Scan(&x, &y, &z, &yaw, &pitch)
+		return map[string]interface{}{"x": x, "y": y, "z": z, "yaw": yaw, "pitch": pitch}, err
+	},
+}
+
+// Decode reads the fields of a packet, false for packets without a decoder or with malformed data.
+func Decode(p Packet) (map[string]interface{}, bool) {
+	decoder, ok := decoders[p.Name]
+	if !ok {
+		return nil, false
+	}
+
+	fields, err := decoder(p)
+	return fields, err == nil
+}
@@ -1,9 +1,12 @@
 package network
 
 import (
+	"errors"
+	"sync"
+
 	"github.com/OCharnyshevich/proxycraft/proxy/helper"
+	"github.com/OCharnyshevich/proxycraft/proxy/network/protocol"
 	"github.com/Tnze/go-mc/chat"
-	"github.com/Tnze/go-mc/data/packetid"
 	mcNet "github.com/Tnze/go-mc/net"
 	pk "github.com/Tnze/go-mc/net/packet"
 	"github.com/google/uuid"
@@ -23,47 +26,216 @@ func (h *handlerHeap) Pop() interface{} {
 	return old[n-1]
 }
 
+// insert adds the handler after the ones of a lower or the same priority, so the
+// handlers are called by priority and then in the order they were added.
+func (h *handlerHeap) insert(l PacketHandler) {
+	i := len(*h)
+	for i > 0 && (*h)[i-1].Priority > l.Priority {
+		i--
+	}
+	*h = append(*h, PacketHandler{})
+	copy((*h)[i+1:], (*h)[i:])
+	(*h)[i] = l
+}
+
 type Events struct {
-	generic  *handlerHeap           // for every packet
-	handlers map[int32]*handlerHeap // for specific packet id only
+	mu       sync.RWMutex
+	generic  *handlerHeap                   // for every packet sent by the server
+	incoming *handlerHeap                   // for every packet sent by the client
+	handlers map[protocol.Name]*handlerHeap // for specific packet name only
+	sessions []SessionHandler
+}
+
+type SessionEvent int
+
+const (
+	// SessionOpened is sent when a client connected and the backend connection is open.
+	SessionOpened SessionEvent = iota
+	// SessionJoined is sent when the login succeeded and the player is known.
+	SessionJoined
+	SessionClosed
+)
+
+func (e SessionEvent) String() string {
+	switch e {
+	case SessionOpened:
+		return "opened"
+	case SessionJoined:
+		return "joined"
+	default:
+		return "closed"
+	}
+}
+
+type SessionHandler struct {
+	// Owner tags the handler, so it can be removed with Events.RemoveListeners.
+	Owner string
+	F     func(event SessionEvent, session helper.Sessionable)
 }
 
-func NewEvents() Events {
-	return Events{
-		handlers: make(map[int32]*handlerHeap),
+func NewEvents() *Events {
+	return &Events{
+		handlers: make(map[protocol.Name]*handlerHeap),
 	}
 }
 
 func (e *Events) AddListener(listeners ...PacketHandler) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
 	for _, l := range listeners {
 		var s *handlerHeap
 		var ok bool
-		if s, ok = e.handlers[l.ID]; !ok {
+		if s, ok = e.handlers[l.Name]; !ok {
 			s = &handlerHeap{l}
-			e.handlers[l.ID] = s
+			e.handlers[l.Name] = s
 		} else {
-			s.Push(l)
+			s.insert(l)
 		}
 	}
 }
 
-// AddGeneric adds listeners like AddListener, but the packet ID is ignored.
-// Generic listener is always called before specific packet listener.
+// AddGeneric adds listeners like AddListener, but the packet name is ignored.
+// Generic listener is always called before specific packet listener, and only
+// receives the packets sent by the server.
 func (e *Events) AddGeneric(listeners ...PacketHandler) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
 	for _, l := range listeners {
 		if e.generic == nil {
 			e.generic = &handlerHeap{l}
 		} else {
-			e.generic.Push(l)
+			e.generic.insert(l)
 		}
 	}
 }
 
-type PacketHandlerFunc func(client *mcNet.Conn, server *mcNet.Conn, p pk.Packet) error
+// AddGenericServerbound adds generic listeners for the packets sent by the client,
+// which are called before the specific packet listeners as well.
+func (e *Events) AddGenericServerbound(listeners ...PacketHandler) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, l := range listeners {
+		if e.incoming == nil {
+			e.incoming = &handlerHeap{l}
+		} else {
+			e.incoming.insert(l)
+		}
+	}
+}
+
+// AddSessionListener adds handlers for the session lifecycle.
+func (e *Events) AddSessionListener(listeners ...SessionHandler) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.sessions = append(e.sessions, listeners...)
+}
+
+func (e *Events) dispatchSession(event SessionEvent, session helper.Sessionable) {
+	e.mu.RLock()
+	listeners := append([]SessionHandler(nil), e.sessions...)
+	e.mu.RUnlock()
+
+	for _, l := range listeners {
+		l.F(event, session)
+	}
+}
+
+// RemoveListeners removes the packet and session listeners added with the given Owner.
+func (e *Events) RemoveListeners(owner string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	sessions := e.sessions[:0]
+	for _, l := range e.sessions {
+		if l.Owner != owner {
+			sessions = append(sessions, l)
+		}
+	}
+	e.sessions = sessions
+
+	if e.generic != nil {
+		e.generic = without(e.generic, owner)
+	}
+	if e.incoming != nil {
+		e.incoming = without(e.incoming, owner)
+	}
+	for name, h := range e.handlers {
+		if h = without(h, owner); h == nil {
+			delete(e.handlers, name)
+		} else {
+			e.handlers[name] = h
+		}
+	}
+}
+
+// listeners returns a copy of the generic listeners of the direction and the listeners of the named packet.
+func (e *Events) listeners(name protocol.Name, clientbound bool) (generic, specific []PacketHandler) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	heap := e.incoming
+	if clientbound {
+		heap = e.generic
+	}
+	if heap != nil {
+		generic = append(generic, *heap...)
+	}
+	if h := e.handlers[name]; h != nil && name != "" {
+		specific = append(specific, *h...)
+	}
+
+	return generic, specific
+}
+
+func without(h *handlerHeap, owner string) *handlerHeap {
+	kept := make(handlerHeap, 0, len(*h))
+	for _, l := range *h {
+		if l.Owner != owner {
+			kept = append(kept, l)
+		}
+	}
+
+	if len(kept) == 0 {
+		return nil
+	}
+	return &kept
+}
+
+// Drop is returned by a handler to keep the packet from being forwarded.
+var Drop = errors.New("drop packet")
+
+// Rewrite is returned by a handler to forward the packet with other data, the handlers
+// after it see the new data.
+type Rewrite struct {
+	Data []byte
+}
+
+func (r Rewrite) Error() string {
+	return "rewrite packet"
+}
+
+// Packet is a packet read from the wire, tagged with its version independent name.
+// Packets of both directions reach handlers in the backend's protocol version, and
+// are translated when they are written to the client through the Session.
+type Packet struct {
+	pk.Packet
+	Name    protocol.Name
+	Version *protocol.Version
+	Session helper.Sessionable
+}
+
+type PacketHandlerFunc func(client *mcNet.Conn, server *mcNet.Conn, p Packet) error
 type PacketHandler struct {
-	ID       int32
+	Name protocol.Name
+	// Priority orders the handlers of a packet, lower ones are called first.
 	Priority int
-	F        func(client *mcNet.Conn, server *mcNet.Conn, p pk.Packet) error
+	// Owner tags the handler, so it can be removed with Events.RemoveListeners.
+	Owner string
+	F     func(client *mcNet.Conn, server *mcNet.Conn, p Packet) error
 }
 
 type EventsListener struct {
@@ -72,58 +244,73 @@ type EventsListener struct {
 	KickDisconnect func(reason chat.Message) error
 	HealthChange   func(health float32) error
 	Death          func() error
+
+	// SessionChatMsg is ChatMsg with the session receiving the message.
+	SessionChatMsg func(session helper.Sessionable, c chat.Message, pos byte, uuid uuid.UUID) error
 }
 
 func (e EventsListener) Attach(n helper.Network) {
-	(n.Events().(*Events)).AddListener(
-		PacketHandler{Priority: 64, ID: packetid.Login, F: e.onJoinGame},
-		PacketHandler{Priority: 64, ID: packetid.ChatClientbound, F: e.onChatMsg},
-		PacketHandler{Priority: 64, ID: packetid.KickDisconnect, F: e.onKickDisconnect},
-		PacketHandler{Priority: 64, ID: packetid.UpdateHealth, F: e.onUpdateHealth},
-	)
+	(n.Events().(*Events)).AddListener(e.Handlers()...)
 }
 
-func (e *EventsListener) onJoinGame(_ *mcNet.Conn, _ *mcNet.Conn, _ pk.Packet) error {
+// Handlers returns the packet handlers behind the callbacks.
+func (e EventsListener) Handlers() []PacketHandler {
+	return []PacketHandler{
+		{Priority: 64, Name: protocol.Login, F: e.onJoinGame},
+		{Priority: 64, Name: protocol.ChatClientbound, F: e.onChatMsg},
+		{Priority: 64, Name: protocol.KickDisconnect, F: e.onKickDisconnect},
+		{Priority: 64, Name: protocol.UpdateHealth, F: e.onUpdateHealth},
+	}
+}
+
+func (e *EventsListener) onJoinGame(_ *mcNet.Conn, _ *mcNet.Conn, _ Packet) error {
 	if e.GameStart != nil {
 		return e.GameStart()
 	}
 	return nil
 }
 
-func (e *EventsListener) onKickDisconnect(_ *mcNet.Conn, _ *mcNet.Conn, p pk.Packet) error {
+func (e *EventsListener) onKickDisconnect(_ *mcNet.Conn, _ *mcNet.Conn, p Packet) error {
 	if e.KickDisconnect != nil {
 		var reason chat.Message
 		if err := p.Scan(&reason); err != nil {
-			return PacketHandlerError{ID: p.ID, Err: err}
+			return PacketHandlerError{ID: p.ID, Name: p.Name, Err: err}
 		}
 		return e.KickDisconnect(reason)
 	}
 	return nil
 }
 
-func (e *EventsListener) onChatMsg(_ *mcNet.Conn, _ *mcNet.Conn, p pk.Packet) error {
-	if e.ChatMsg != nil {
+func (e *EventsListener) onChatMsg(_ *mcNet.Conn, _ *mcNet.Conn, p Packet) error {
+	if e.ChatMsg != nil || e.SessionChatMsg != nil {
 		var msg chat.Message
 		var pos pk.Byte
 		var sender pk.UUID
 
 		if err := p.Scan(&msg, &pos, &sender); err != nil {
-			return PacketHandlerError{ID: p.ID, Err: err}
+			return PacketHandlerError{ID: p.ID, Name: p.Name, Err: err}
 		}
 
-		return e.ChatMsg(msg, byte(pos), uuid.UUID(sender))
+		if e.SessionChatMsg != nil {
+			if err := e.SessionChatMsg(p.Session, msg, byte(pos), uuid.UUID(sender)); err != nil {
+				return err
+			}
+		}
+		if e.ChatMsg != nil {
+			return e.ChatMsg(msg, byte(pos), uuid.UUID(sender))
+		}
 	}
 	return nil
 }
 
-func (e *EventsListener) onUpdateHealth(_ *mcNet.Conn, _ *mcNet.Conn, p pk.Packet) error {
+func (e *EventsListener) onUpdateHealth(_ *mcNet.Conn, _ *mcNet.Conn, p Packet) error {
 	if e.ChatMsg != nil {
 		var health pk.Float
 		var food pk.VarInt
 		var foodSaturation pk.Float
 
 		if err := p.Scan(&health, &food, &foodSaturation); err != nil {
-			return PacketHandlerError{ID: p.ID, Err: err}
+			return PacketHandlerError{ID: p.ID, Name: p.Name, Err: err}
 		}
 		if e.HealthChange != nil {
 			if err := e.HealthChange(float32(health)); err != nil {
@@ -1,19 +1,23 @@
 package network
 
 import (
+	"errors"
 	"fmt"
 	"github.com/OCharnyshevich/proxycraft/proxy/helper"
 	"github.com/OCharnyshevich/proxycraft/proxy/log"
 	mcNet "github.com/Tnze/go-mc/net"
+	"net"
 	"strconv"
+	"sync"
 )
 
 type network struct {
 	localHost string
 	localPort int
 
-	remoteHost string
-	remotePort int
+	remoteHost     string
+	remotePort     int
+	remoteProtocol int32
 
 	logger *log.Logging
 	//packets base.Packets
@@ -22,21 +26,25 @@ type network struct {
 	//quit chan base.PlayerAndConnection
 
 	localConn *mcNet.Listener
-	sessions  []helper.Sessionable
-	events    Events
+	events    *Events
+
+	mu       sync.RWMutex
+	sessions []helper.Sessionable
 
 	report chan helper.Message
 }
 
-func New(report chan helper.Message, lHost string, lPort int, rHost string, rPort int, events Events) helper.Network {
+func New(report chan helper.Message, lHost string, lPort int, rHost string, rPort int, rProtocol int32, events *Events) helper.Network {
 	return &network{
 		localHost:  lHost,
 		localPort:  lPort,
 		remoteHost: rHost,
 		remotePort: rPort,
 
+		remoteProtocol: rProtocol,
+
 		report: report,
-		logger: log.New("network", log.EveryLevel...),
+		logger: log.New("network"),
 		events: events,
 	}
 }
@@ -49,17 +57,44 @@ func (n *network) Load() {
 }
 
 func (n *network) Kill() {
-	for _, sess := range n.sessions {
-		sess.Kill()
+	if n.localConn != nil {
+		_ = n.localConn.Close()
+	}
+
+	for _, sess := range n.Sessions() {
+		sess.Disconnect("Proxy is shutting down")
 	}
 }
 
 func (n *network) Events() interface{} {
-	return &n.events
+	return n.events
 }
 
+// Sessions returns a snapshot of the open sessions.
 func (n *network) Sessions() []helper.Sessionable {
-	return n.sessions
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	return append([]helper.Sessionable(nil), n.sessions...)
+}
+
+func (n *network) add(session helper.Sessionable) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.sessions = append(n.sessions, session)
+}
+
+func (n *network) remove(session helper.Sessionable) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for i, s := range n.sessions {
+		if s == session {
+			n.sessions = append(n.sessions[:i], n.sessions[i+1:]...)
+			return
+		}
+	}
 }
 
 func (n *network) startListening() error {
@@ -74,14 +109,20 @@ func (n *network) startListening() error {
 
 	go func() {
 		for {
-			session, err := NewSession(n.localConn, n.remoteHost, n.remotePort, &n.events)
+			session, err := NewSession(n.localConn, n.remoteHost, n.remotePort, n.remoteProtocol, n.events)
 			if err != nil {
+				if errors.Is(err, net.ErrClosed) {
+					return
+				}
 				//n.report <- helper.Make(helper.FAIL, err)
 				n.logger.Warn(err)
 				continue
 			}
-			n.sessions = append(n.sessions, &session)
-			go session.StreamBidirectional()
+			n.add(session)
+			go func() {
+				session.StreamBidirectional()
+				n.remove(session)
+			}()
 		}
 	}()
 
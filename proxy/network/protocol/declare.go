@@ -0,0 +1,233 @@
+package protocol
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	pk "github.com/Tnze/go-mc/net/packet"
+)
+
+// Node types and flags of the Declare Commands graph.
+const (
+	NodeRoot     byte = 0x00
+	NodeLiteral  byte = 0x01
+	NodeArgument byte = 0x02
+
+	NodeExecutable  byte = 0x04
+	NodeRedirect    byte = 0x08
+	NodeSuggestions byte = 0x10
+)
+
+// CommandNode is a node of the Declare Commands graph, as sent up to 1.18.2 where
+// parsers are identified by name. Properties keep the parser properties in their binary form.
+type CommandNode struct {
+	Flags       byte
+	Children    []int32
+	Redirect    int32
+	Name        string
+	Parser      string
+	Properties  []byte
+	Suggestions string
+}
+
+func (n CommandNode) Type() byte {
+	return n.Flags & 0x03
+}
+
+// ReadCommands decodes Declare Commands into its nodes and the index of the root node.
+func (v *Version) ReadCommands(p pk.Packet) ([]CommandNode, int32, error) {
+	if v.Protocol >= 759 {
+		return nil, 0, fmt.Errorf("command parsers of %v are not supported", v)
+	}
+
+	r := bytes.NewReader(p.Data)
+
+	var count pk.VarInt
+	if _, err := count.ReadFrom(r); err != nil {
+		return nil, 0, err
+	}
+
+	nodes := make([]CommandNode, count)
+	for i := range nodes {
+		if err := readNode(r, &nodes[i]); err != nil {
+			return nil, 0, fmt.Errorf("node %d: %w", i, err)
+		}
+	}
+
+	var root pk.VarInt
+	if _, err := root.ReadFrom(r); err != nil {
+		return nil, 0, err
+	}
+
+	return nodes, int32(root), nil
+}
+
+// MarshalCommands encodes the nodes as Declare Commands.
+func (v *Version) MarshalCommands(nodes []CommandNode, root int32) (pk.Packet, error) {
+	var buf bytes.Buffer
+
+	_, _ = pk.VarInt(len(nodes)).WriteTo(&buf)
+	for _, n := range nodes {
+		writeNode(&buf, n)
+	}
+	_, _ = pk.VarInt(root).WriteTo(&buf)
+
+	id, ok := v.ID(DeclareCommands)
+	if !ok {
+		return pk.Packet{}, UnknownPacketError{Name: DeclareCommands, Protocol: v.Protocol}
+	}
+
+	return pk.Packet{ID: id, Data: buf.Bytes()}, nil
+}
+
+func readNode(r *bytes.Reader, n *CommandNode) error {
+	var (
+		flags    pk.Byte
+		count    pk.VarInt
+		children []pk.VarInt
+	)
+	if _, err := (pk.Tuple{&flags, &count, pk.Ary{Len: &count, Ary: &children}}).ReadFrom(r); err != nil {
+		return err
+	}
+
+	n.Flags = byte(flags)
+	n.Children = make([]int32, len(children))
+	for i, c := range children {
+		n.Children[i] = int32(c)
+	}
+
+	if n.Flags&NodeRedirect != 0 {
+		var redirect pk.VarInt
+		if _, err := redirect.ReadFrom(r); err != nil {
+			return err
+		}
+		n.Redirect = int32(redirect)
+	}
+
+	if n.Type() == NodeLiteral || n.Type() == NodeArgument {
+		var name pk.String
+		if _, err := name.ReadFrom(r); err != nil {
+			return err
+		}
+		n.Name = string(name)
+	}
+
+	if n.Type() == NodeArgument {
+		var parser pk.Identifier
+		if _, err := parser.ReadFrom(r); err != nil {
+			return err
+		}
+		n.Parser = string(parser)
+
+		start := r.Len()
+		if err := skipProperties(r, n.Parser); err != nil {
+			return err
+		}
+		// re-read the skipped properties as they are
+		n.Properties = make([]byte, start-r.Len())
+		if _, err := r.Seek(int64(-len(n.Properties)), io.SeekCurrent); err != nil {
+			return err
+		}
+		if _, err := io.ReadFull(r, n.Properties); err != nil {
+			return err
+		}
+	}
+
+	if n.Flags&NodeSuggestions != 0 {
+		var suggestions pk.Identifier
+		if _, err := suggestions.ReadFrom(r); err != nil {
+			return err
+		}
+		n.Suggestions = string(suggestions)
+	}
+
+	return nil
+}
+
+// parsers are the argument parsers up to 1.18.2 without properties.
+var parsers = map[string]bool{
+	"brigadier:bool": true, "minecraft:game_profile": true, "minecraft:block_pos": true,
+	"minecraft:column_pos": true, "minecraft:vec3": true, "minecraft:vec2": true,
+	"minecraft:block_state": true, "minecraft:block_predicate": true, "minecraft:item_stack": true,
+	"minecraft:item_predicate": true, "minecraft:color": true, "minecraft:component": true,
+	"minecraft:message": true, "minecraft:nbt_compound_tag": true, "minecraft:nbt_tag": true,
+	"minecraft:nbt_path": true, "minecraft:objective": true, "minecraft:objective_criteria": true,
+	"minecraft:operation": true, "minecraft:particle": true, "minecraft:angle": true,
+	"minecraft:rotation": true, "minecraft:scoreboard_slot": true, "minecraft:swizzle": true,
+	"minecraft:team": true, "minecraft:item_slot": true, "minecraft:resource_location": true,
+	"minecraft:mob_effect": true, "minecraft:function": true, "minecraft:entity_anchor": true,
+	"minecraft:int_range": true, "minecraft:float_range": true, "minecraft:item_enchantment": true,
+	"minecraft:entity_summon": true, "minecraft:dimension": true, "minecraft:uuid": true,
+	"minecraft:time": true,
+}
+
+// skipProperties reads past the properties of the parsers which have them. An unknown
+// parser is an error, its properties cannot be told apart from the next node.
+func skipProperties(r *bytes.Reader, parser string) error {
+	var err error
+	switch parser {
+	case "brigadier:double", "brigadier:long":
+		err = skipBounds(r, 8)
+	case "brigadier:float", "brigadier:integer":
+		err = skipBounds(r, 4)
+	case "brigadier:string":
+		var kind pk.VarInt
+		_, err = kind.ReadFrom(r)
+	case "minecraft:entity", "minecraft:score_holder", "minecraft:range":
+		_, err = r.ReadByte()
+	case "minecraft:resource", "minecraft:resource_or_tag":
+		// the registry of the resources, since 1.18.2
+		var registry pk.Identifier
+		_, err = registry.ReadFrom(r)
+	default:
+		if !parsers[parser] {
+			err = fmt.Errorf("unknown parser %s", parser)
+		}
+	}
+	return err
+}
+
+// skipBounds skips the min and max of a number parser, which are present by the flags.
+func skipBounds(r *bytes.Reader, size int64) error {
+	flags, err := r.ReadByte()
+	if err != nil {
+		return err
+	}
+
+	skip := int64(0)
+	if flags&0x01 != 0 {
+		skip += size
+	}
+	if flags&0x02 != 0 {
+		skip += size
+	}
+	if skip > int64(r.Len()) {
+		return io.ErrUnexpectedEOF
+	}
+
+	_, err = r.Seek(skip, io.SeekCurrent)
+	return err
+}
+
+func writeNode(w io.Writer, n CommandNode) {
+	_, _ = pk.Byte(n.Flags).WriteTo(w)
+	_, _ = pk.VarInt(len(n.Children)).WriteTo(w)
+	for _, c := range n.Children {
+		_, _ = pk.VarInt(c).WriteTo(w)
+	}
+
+	if n.Flags&NodeRedirect != 0 {
+		_, _ = pk.VarInt(n.Redirect).WriteTo(w)
+	}
+	if n.Type() == NodeLiteral || n.Type() == NodeArgument {
+		_, _ = pk.String(n.Name).WriteTo(w)
+	}
+	if n.Type() == NodeArgument {
+		_, _ = pk.Identifier(n.Parser).WriteTo(w)
+		_, _ = w.Write(n.Properties)
+	}
+	if n.Flags&NodeSuggestions != 0 {
+		_, _ = pk.Identifier(n.Suggestions).WriteTo(w)
+	}
+}
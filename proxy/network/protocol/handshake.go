@@ -0,0 +1,35 @@
+package protocol
+
+import (
+	pk "github.com/Tnze/go-mc/net/packet"
+)
+
+// HandshakeID is the ID of the only packet of the handshaking state.
+const HandshakeID = 0x00
+
+type Handshake struct {
+	Protocol  int32
+	Address   string
+	Port      uint16
+	NextState int32
+}
+
+func ReadHandshake(p pk.Packet) (h Handshake, err error) {
+	var (
+		protocol  pk.VarInt
+		address   pk.String
+		port      pk.UnsignedShort
+		nextState pk.VarInt
+	)
+
+	if err = p.Scan(&protocol, &address, &port, &nextState); err != nil {
+		return h, err
+	}
+
+	h.Protocol = int32(protocol)
+	h.Address = string(address)
+	h.Port = uint16(port)
+	h.NextState = int32(nextState)
+
+	return h, nil
+}
@@ -0,0 +1,188 @@
+package protocol
+
+// Clientbound play packets.
+const (
+	SpawnEntity                Name = "SpawnEntity"
+	SpawnEntityExperienceOrb   Name = "SpawnEntityExperienceOrb"
+	SpawnEntityLiving          Name = "SpawnEntityLiving"
+	SpawnEntityPainting        Name = "SpawnEntityPainting"
+	NamedEntitySpawn           Name = "NamedEntitySpawn"
+	Animation                  Name = "Animation"
+	Statistics                 Name = "Statistics"
+	AcknowledgePlayerDigging   Name = "AcknowledgePlayerDigging"
+	BlockBreakAnimation        Name = "BlockBreakAnimation"
+	TileEntityData             Name = "TileEntityData"
+	BlockAction                Name = "BlockAction"
+	BlockChange                Name = "BlockChange"
+	BossBar                    Name = "BossBar"
+	Difficulty                 Name = "Difficulty"
+	ChatClientbound            Name = "ChatClientbound"
+	TabCompleteClientbound     Name = "TabCompleteClientbound"
+	DeclareCommands            Name = "DeclareCommands"
+	TransactionClientbound     Name = "TransactionClientbound"
+	CloseWindowClientbound     Name = "CloseWindowClientbound"
+	WindowItems                Name = "WindowItems"
+	CraftProgressBar           Name = "CraftProgressBar"
+	SetSlot                    Name = "SetSlot"
+	SetCooldown                Name = "SetCooldown"
+	CustomPayloadClientbound   Name = "CustomPayloadClientbound"
+	NamedSoundEffect           Name = "NamedSoundEffect"
+	KickDisconnect             Name = "KickDisconnect"
+	EntityStatus               Name = "EntityStatus"
+	Explosion                  Name = "Explosion"
+	UnloadChunk                Name = "UnloadChunk"
+	GameStateChange            Name = "GameStateChange"
+	OpenHorseWindow            Name = "OpenHorseWindow"
+	KeepAliveClientbound       Name = "KeepAliveClientbound"
+	MapChunk                   Name = "MapChunk"
+	WorldEvent                 Name = "WorldEvent"
+	WorldParticles             Name = "WorldParticles"
+	UpdateLight                Name = "UpdateLight"
+	Login                      Name = "Login"
+	Map                        Name = "Map"
+	TradeList                  Name = "TradeList"
+	RelEntityMove              Name = "RelEntityMove"
+	EntityMoveLook             Name = "EntityMoveLook"
+	EntityLook                 Name = "EntityLook"
+	Entity                     Name = "Entity"
+	VehicleMoveClientbound     Name = "VehicleMoveClientbound"
+	OpenBook                   Name = "OpenBook"
+	OpenWindow                 Name = "OpenWindow"
+	OpenSignEntity             Name = "OpenSignEntity"
+	CraftRecipeResponse        Name = "CraftRecipeResponse"
+	AbilitiesClientbound       Name = "AbilitiesClientbound"
+	CombatEvent                Name = "CombatEvent"
+	PlayerInfo                 Name = "PlayerInfo"
+	FacePlayer                 Name = "FacePlayer"
+	PositionClientbound        Name = "PositionClientbound"
+	UnlockRecipes              Name = "UnlockRecipes"
+	DestroyEntity              Name = "DestroyEntity"
+	RemoveEntityEffect         Name = "RemoveEntityEffect"
+	ResourcePackSend           Name = "ResourcePackSend"
+	Respawn                    Name = "Respawn"
+	EntityHeadRotation         Name = "EntityHeadRotation"
+	MultiBlockChange           Name = "MultiBlockChange"
+	SelectAdvancementTab       Name = "SelectAdvancementTab"
+	WorldBorder                Name = "WorldBorder"
+	Camera                     Name = "Camera"
+	HeldItemSlotClientbound    Name = "HeldItemSlotClientbound"
+	UpdateViewPosition         Name = "UpdateViewPosition"
+	UpdateViewDistance         Name = "UpdateViewDistance"
+	SpawnPosition              Name = "SpawnPosition"
+	ScoreboardDisplayObjective Name = "ScoreboardDisplayObjective"
+	EntityMetadata             Name = "EntityMetadata"
+	AttachEntity               Name = "AttachEntity"
+	EntityVelocity             Name = "EntityVelocity"
+	EntityEquipment            Name = "EntityEquipment"
+	Experience                 Name = "Experience"
+	UpdateHealth               Name = "UpdateHealth"
+	ScoreboardObjective        Name = "ScoreboardObjective"
+	SetPassengers              Name = "SetPassengers"
+	Teams                      Name = "Teams"
+	ScoreboardScore            Name = "ScoreboardScore"
+	UpdateTime                 Name = "UpdateTime"
+	Title                      Name = "Title"
+	EntitySoundEffect          Name = "EntitySoundEffect"
+	SoundEffect                Name = "SoundEffect"
+	StopSound                  Name = "StopSound"
+	PlayerlistHeader           Name = "PlayerlistHeader"
+	NbtQueryResponse           Name = "NbtQueryResponse"
+	Collect                    Name = "Collect"
+	EntityTeleport             Name = "EntityTeleport"
+	Advancements               Name = "Advancements"
+	EntityUpdateAttributes     Name = "EntityUpdateAttributes"
+	EntityEffect               Name = "EntityEffect"
+	DeclareRecipes             Name = "DeclareRecipes"
+	Tags                       Name = "Tags"
+	SculkVibrationSignal       Name = "SculkVibrationSignal"
+	ClearTitles                Name = "ClearTitles"
+	InitializeWorldBorder      Name = "InitializeWorldBorder"
+	Ping                       Name = "Ping"
+	EndCombatEvent             Name = "EndCombatEvent"
+	EnterCombatEvent           Name = "EnterCombatEvent"
+	DeathCombatEvent           Name = "DeathCombatEvent"
+	ActionBar                  Name = "ActionBar"
+	WorldBorderCenter          Name = "WorldBorderCenter"
+	WorldBorderLerpSize        Name = "WorldBorderLerpSize"
+	WorldBorderSize            Name = "WorldBorderSize"
+	WorldBorderWarningDelay    Name = "WorldBorderWarningDelay"
+	WorldBorderWarningReach    Name = "WorldBorderWarningReach"
+	SetTitleSubtitle           Name = "SetTitleSubtitle"
+	SetTitleText               Name = "SetTitleText"
+	SetTitleTime               Name = "SetTitleTime"
+	SimulationDistance         Name = "SimulationDistance"
+	BlockChangedAck            Name = "BlockChangedAck"
+	ChatPreviewClientbound     Name = "ChatPreviewClientbound"
+	PlayerChat                 Name = "PlayerChat"
+	ServerData                 Name = "ServerData"
+	DisplayChatPreview         Name = "DisplayChatPreview"
+	SystemChat                 Name = "SystemChat"
+	CustomChatCompletions      Name = "CustomChatCompletions"
+	DeleteChat                 Name = "DeleteChat"
+	PlayerChatHeader           Name = "PlayerChatHeader"
+	DisguisedChat              Name = "DisguisedChat"
+	PlayerInfoRemove           Name = "PlayerInfoRemove"
+	PlayerInfoUpdate           Name = "PlayerInfoUpdate"
+	UpdateEnabledFeatures      Name = "UpdateEnabledFeatures"
+	BundleDelimiter            Name = "BundleDelimiter"
+	ChunksBiomes               Name = "ChunksBiomes"
+	DamageEvent                Name = "DamageEvent"
+	HurtAnimation              Name = "HurtAnimation"
+)
+
+// Serverbound play packets.
+const (
+	TeleportConfirm            Name = "TeleportConfirm"
+	QueryBlockNbt              Name = "QueryBlockNbt"
+	SetDifficulty              Name = "SetDifficulty"
+	ChatServerbound            Name = "ChatServerbound"
+	ClientCommand              Name = "ClientCommand"
+	Settings                   Name = "Settings"
+	TabCompleteServerbound     Name = "TabCompleteServerbound"
+	TransactionServerbound     Name = "TransactionServerbound"
+	EnchantItem                Name = "EnchantItem"
+	WindowClick                Name = "WindowClick"
+	CloseWindowServerbound     Name = "CloseWindowServerbound"
+	CustomPayloadServerbound   Name = "CustomPayloadServerbound"
+	EditBook                   Name = "EditBook"
+	QueryEntityNbt             Name = "QueryEntityNbt"
+	UseEntity                  Name = "UseEntity"
+	GenerateStructure          Name = "GenerateStructure"
+	KeepAliveServerbound       Name = "KeepAliveServerbound"
+	LockDifficulty             Name = "LockDifficulty"
+	PositionServerbound        Name = "PositionServerbound"
+	PositionLook               Name = "PositionLook"
+	Look                       Name = "Look"
+	Flying                     Name = "Flying"
+	VehicleMoveServerbound     Name = "VehicleMoveServerbound"
+	SteerBoat                  Name = "SteerBoat"
+	PickItem                   Name = "PickItem"
+	CraftRecipeRequest         Name = "CraftRecipeRequest"
+	AbilitiesServerbound       Name = "AbilitiesServerbound"
+	BlockDig                   Name = "BlockDig"
+	EntityAction               Name = "EntityAction"
+	SteerVehicle               Name = "SteerVehicle"
+	DisplayedRecipe            Name = "DisplayedRecipe"
+	RecipeBook                 Name = "RecipeBook"
+	NameItem                   Name = "NameItem"
+	ResourcePackReceive        Name = "ResourcePackReceive"
+	AdvancementTab             Name = "AdvancementTab"
+	SelectTrade                Name = "SelectTrade"
+	SetBeaconEffect            Name = "SetBeaconEffect"
+	HeldItemSlotServerbound    Name = "HeldItemSlotServerbound"
+	UpdateCommandBlock         Name = "UpdateCommandBlock"
+	UpdateCommandBlockMinecart Name = "UpdateCommandBlockMinecart"
+	SetCreativeSlot            Name = "SetCreativeSlot"
+	UpdateJigsawBlock          Name = "UpdateJigsawBlock"
+	UpdateStructureBlock       Name = "UpdateStructureBlock"
+	UpdateSign                 Name = "UpdateSign"
+	ArmAnimation               Name = "ArmAnimation"
+	Spectate                   Name = "Spectate"
+	BlockPlace                 Name = "BlockPlace"
+	UseItem                    Name = "UseItem"
+	Pong                       Name = "Pong"
+	ChatCommand                Name = "ChatCommand"
+	ChatPreviewServerbound     Name = "ChatPreviewServerbound"
+	MessageAcknowledgement     Name = "MessageAcknowledgement"
+	ChatSessionUpdate          Name = "ChatSessionUpdate"
+)
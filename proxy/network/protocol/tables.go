@@ -0,0 +1,1130 @@
+package protocol
+
+// Play state packet tables, indexed by packet ID. The 1.16.5 through 1.19.4
+// tables follow the packet order published with the matching go-mc releases.
+
+// 1.16.4 - 1.16.5
+var clientbound754 = []Name{
+	SpawnEntity,
+	SpawnEntityExperienceOrb,
+	SpawnEntityLiving,
+	SpawnEntityPainting,
+	NamedEntitySpawn,
+	Animation,
+	Statistics,
+	AcknowledgePlayerDigging,
+	BlockBreakAnimation,
+	TileEntityData,
+	BlockAction,
+	BlockChange,
+	BossBar,
+	Difficulty,
+	ChatClientbound,
+	TabCompleteClientbound,
+	DeclareCommands,
+	TransactionClientbound,
+	CloseWindowClientbound,
+	WindowItems,
+	CraftProgressBar,
+	SetSlot,
+	SetCooldown,
+	CustomPayloadClientbound,
+	NamedSoundEffect,
+	KickDisconnect,
+	EntityStatus,
+	Explosion,
+	UnloadChunk,
+	GameStateChange,
+	OpenHorseWindow,
+	KeepAliveClientbound,
+	MapChunk,
+	WorldEvent,
+	WorldParticles,
+	UpdateLight,
+	Login,
+	Map,
+	TradeList,
+	RelEntityMove,
+	EntityMoveLook,
+	EntityLook,
+	Entity,
+	VehicleMoveClientbound,
+	OpenBook,
+	OpenWindow,
+	OpenSignEntity,
+	CraftRecipeResponse,
+	AbilitiesClientbound,
+	CombatEvent,
+	PlayerInfo,
+	FacePlayer,
+	PositionClientbound,
+	UnlockRecipes,
+	DestroyEntity,
+	RemoveEntityEffect,
+	ResourcePackSend,
+	Respawn,
+	EntityHeadRotation,
+	MultiBlockChange,
+	SelectAdvancementTab,
+	WorldBorder,
+	Camera,
+	HeldItemSlotClientbound,
+	UpdateViewPosition,
+	UpdateViewDistance,
+	SpawnPosition,
+	ScoreboardDisplayObjective,
+	EntityMetadata,
+	AttachEntity,
+	EntityVelocity,
+	EntityEquipment,
+	Experience,
+	UpdateHealth,
+	ScoreboardObjective,
+	SetPassengers,
+	Teams,
+	ScoreboardScore,
+	UpdateTime,
+	Title,
+	EntitySoundEffect,
+	SoundEffect,
+	StopSound,
+	PlayerlistHeader,
+	NbtQueryResponse,
+	Collect,
+	EntityTeleport,
+	Advancements,
+	EntityUpdateAttributes,
+	EntityEffect,
+	DeclareRecipes,
+	Tags,
+}
+
+var serverbound754 = []Name{
+	TeleportConfirm,
+	QueryBlockNbt,
+	SetDifficulty,
+	ChatServerbound,
+	ClientCommand,
+	Settings,
+	TabCompleteServerbound,
+	TransactionServerbound,
+	EnchantItem,
+	WindowClick,
+	CloseWindowServerbound,
+	CustomPayloadServerbound,
+	EditBook,
+	QueryEntityNbt,
+	UseEntity,
+	GenerateStructure,
+	KeepAliveServerbound,
+	LockDifficulty,
+	PositionServerbound,
+	PositionLook,
+	Look,
+	Flying,
+	VehicleMoveServerbound,
+	SteerBoat,
+	PickItem,
+	CraftRecipeRequest,
+	AbilitiesServerbound,
+	BlockDig,
+	EntityAction,
+	SteerVehicle,
+	DisplayedRecipe,
+	RecipeBook,
+	NameItem,
+	ResourcePackReceive,
+	AdvancementTab,
+	SelectTrade,
+	SetBeaconEffect,
+	HeldItemSlotServerbound,
+	UpdateCommandBlock,
+	UpdateCommandBlockMinecart,
+	SetCreativeSlot,
+	UpdateJigsawBlock,
+	UpdateStructureBlock,
+	UpdateSign,
+	ArmAnimation,
+	Spectate,
+	BlockPlace,
+	UseItem,
+}
+
+// 1.17 - 1.17.1
+var clientbound756 = []Name{
+	SpawnEntity,
+	SpawnEntityExperienceOrb,
+	SpawnEntityLiving,
+	SpawnEntityPainting,
+	NamedEntitySpawn,
+	SculkVibrationSignal,
+	Animation,
+	Statistics,
+	AcknowledgePlayerDigging,
+	BlockBreakAnimation,
+	TileEntityData,
+	BlockAction,
+	BlockChange,
+	BossBar,
+	Difficulty,
+	ChatClientbound,
+	ClearTitles,
+	TabCompleteClientbound,
+	DeclareCommands,
+	CloseWindowClientbound,
+	WindowItems,
+	CraftProgressBar,
+	SetSlot,
+	SetCooldown,
+	CustomPayloadClientbound,
+	NamedSoundEffect,
+	KickDisconnect,
+	EntityStatus,
+	Explosion,
+	UnloadChunk,
+	GameStateChange,
+	OpenHorseWindow,
+	InitializeWorldBorder,
+	KeepAliveClientbound,
+	MapChunk,
+	WorldEvent,
+	WorldParticles,
+	UpdateLight,
+	Login,
+	Map,
+	TradeList,
+	RelEntityMove,
+	EntityMoveLook,
+	EntityLook,
+	VehicleMoveClientbound,
+	OpenBook,
+	OpenWindow,
+	OpenSignEntity,
+	Ping,
+	CraftRecipeResponse,
+	AbilitiesClientbound,
+	EndCombatEvent,
+	EnterCombatEvent,
+	DeathCombatEvent,
+	PlayerInfo,
+	FacePlayer,
+	PositionClientbound,
+	UnlockRecipes,
+	DestroyEntity,
+	RemoveEntityEffect,
+	ResourcePackSend,
+	Respawn,
+	EntityHeadRotation,
+	MultiBlockChange,
+	SelectAdvancementTab,
+	ActionBar,
+	WorldBorderCenter,
+	WorldBorderLerpSize,
+	WorldBorderSize,
+	WorldBorderWarningDelay,
+	WorldBorderWarningReach,
+	Camera,
+	HeldItemSlotClientbound,
+	UpdateViewPosition,
+	UpdateViewDistance,
+	SpawnPosition,
+	ScoreboardDisplayObjective,
+	EntityMetadata,
+	AttachEntity,
+	EntityVelocity,
+	EntityEquipment,
+	Experience,
+	UpdateHealth,
+	ScoreboardObjective,
+	SetPassengers,
+	Teams,
+	ScoreboardScore,
+	SetTitleSubtitle,
+	UpdateTime,
+	SetTitleText,
+	SetTitleTime,
+	EntitySoundEffect,
+	SoundEffect,
+	StopSound,
+	PlayerlistHeader,
+	NbtQueryResponse,
+	Collect,
+	EntityTeleport,
+	Advancements,
+	EntityUpdateAttributes,
+	EntityEffect,
+	DeclareRecipes,
+	Tags,
+}
+
+var serverbound756 = []Name{
+	TeleportConfirm,
+	QueryBlockNbt,
+	SetDifficulty,
+	ChatServerbound,
+	ClientCommand,
+	Settings,
+	TabCompleteServerbound,
+	EnchantItem,
+	WindowClick,
+	CloseWindowServerbound,
+	CustomPayloadServerbound,
+	EditBook,
+	QueryEntityNbt,
+	UseEntity,
+	GenerateStructure,
+	KeepAliveServerbound,
+	LockDifficulty,
+	PositionServerbound,
+	PositionLook,
+	Look,
+	Flying,
+	VehicleMoveServerbound,
+	SteerBoat,
+	PickItem,
+	CraftRecipeRequest,
+	AbilitiesServerbound,
+	BlockDig,
+	EntityAction,
+	SteerVehicle,
+	Pong,
+	DisplayedRecipe,
+	RecipeBook,
+	NameItem,
+	ResourcePackReceive,
+	AdvancementTab,
+	SelectTrade,
+	SetBeaconEffect,
+	HeldItemSlotServerbound,
+	UpdateCommandBlock,
+	UpdateCommandBlockMinecart,
+	SetCreativeSlot,
+	UpdateJigsawBlock,
+	UpdateStructureBlock,
+	UpdateSign,
+	ArmAnimation,
+	Spectate,
+	BlockPlace,
+	UseItem,
+}
+
+// 1.18 - 1.18.2
+var clientbound757 = []Name{
+	SpawnEntity,
+	SpawnEntityExperienceOrb,
+	SpawnEntityLiving,
+	SpawnEntityPainting,
+	NamedEntitySpawn,
+	SculkVibrationSignal,
+	Animation,
+	Statistics,
+	AcknowledgePlayerDigging,
+	BlockBreakAnimation,
+	TileEntityData,
+	BlockAction,
+	BlockChange,
+	BossBar,
+	Difficulty,
+	ChatClientbound,
+	ClearTitles,
+	TabCompleteClientbound,
+	DeclareCommands,
+	CloseWindowClientbound,
+	WindowItems,
+	CraftProgressBar,
+	SetSlot,
+	SetCooldown,
+	CustomPayloadClientbound,
+	NamedSoundEffect,
+	KickDisconnect,
+	EntityStatus,
+	Explosion,
+	UnloadChunk,
+	GameStateChange,
+	OpenHorseWindow,
+	InitializeWorldBorder,
+	KeepAliveClientbound,
+	MapChunk,
+	WorldEvent,
+	WorldParticles,
+	UpdateLight,
+	Login,
+	Map,
+	TradeList,
+	RelEntityMove,
+	EntityMoveLook,
+	EntityLook,
+	VehicleMoveClientbound,
+	OpenBook,
+	OpenWindow,
+	OpenSignEntity,
+	Ping,
+	CraftRecipeResponse,
+	AbilitiesClientbound,
+	EndCombatEvent,
+	EnterCombatEvent,
+	DeathCombatEvent,
+	PlayerInfo,
+	FacePlayer,
+	PositionClientbound,
+	UnlockRecipes,
+	DestroyEntity,
+	RemoveEntityEffect,
+	ResourcePackSend,
+	Respawn,
+	EntityHeadRotation,
+	MultiBlockChange,
+	SelectAdvancementTab,
+	ActionBar,
+	WorldBorderCenter,
+	WorldBorderLerpSize,
+	WorldBorderSize,
+	WorldBorderWarningDelay,
+	WorldBorderWarningReach,
+	Camera,
+	HeldItemSlotClientbound,
+	UpdateViewPosition,
+	UpdateViewDistance,
+	SpawnPosition,
+	ScoreboardDisplayObjective,
+	EntityMetadata,
+	AttachEntity,
+	EntityVelocity,
+	EntityEquipment,
+	Experience,
+	UpdateHealth,
+	ScoreboardObjective,
+	SetPassengers,
+	Teams,
+	ScoreboardScore,
+	SimulationDistance,
+	SetTitleSubtitle,
+	UpdateTime,
+	SetTitleText,
+	SetTitleTime,
+	EntitySoundEffect,
+	SoundEffect,
+	StopSound,
+	PlayerlistHeader,
+	NbtQueryResponse,
+	Collect,
+	EntityTeleport,
+	Advancements,
+	EntityUpdateAttributes,
+	EntityEffect,
+	DeclareRecipes,
+	Tags,
+}
+
+var serverbound757 = []Name{
+	TeleportConfirm,
+	QueryBlockNbt,
+	SetDifficulty,
+	ChatServerbound,
+	ClientCommand,
+	Settings,
+	TabCompleteServerbound,
+	EnchantItem,
+	WindowClick,
+	CloseWindowServerbound,
+	CustomPayloadServerbound,
+	EditBook,
+	QueryEntityNbt,
+	UseEntity,
+	GenerateStructure,
+	KeepAliveServerbound,
+	LockDifficulty,
+	PositionServerbound,
+	PositionLook,
+	Look,
+	Flying,
+	VehicleMoveServerbound,
+	SteerBoat,
+	PickItem,
+	CraftRecipeRequest,
+	AbilitiesServerbound,
+	BlockDig,
+	EntityAction,
+	SteerVehicle,
+	Pong,
+	DisplayedRecipe,
+	RecipeBook,
+	NameItem,
+	ResourcePackReceive,
+	AdvancementTab,
+	SelectTrade,
+	SetBeaconEffect,
+	HeldItemSlotServerbound,
+	UpdateCommandBlock,
+	UpdateCommandBlockMinecart,
+	SetCreativeSlot,
+	UpdateJigsawBlock,
+	UpdateStructureBlock,
+	UpdateSign,
+	ArmAnimation,
+	Spectate,
+	BlockPlace,
+	UseItem,
+}
+
+// 1.19
+var clientbound759 = []Name{
+	SpawnEntity,
+	SpawnEntityExperienceOrb,
+	NamedEntitySpawn,
+	Animation,
+	Statistics,
+	BlockChangedAck,
+	BlockBreakAnimation,
+	TileEntityData,
+	BlockAction,
+	BlockChange,
+	BossBar,
+	Difficulty,
+	ChatPreviewClientbound,
+	ClearTitles,
+	TabCompleteClientbound,
+	DeclareCommands,
+	CloseWindowClientbound,
+	WindowItems,
+	CraftProgressBar,
+	SetSlot,
+	SetCooldown,
+	CustomPayloadClientbound,
+	NamedSoundEffect,
+	KickDisconnect,
+	EntityStatus,
+	Explosion,
+	UnloadChunk,
+	GameStateChange,
+	OpenHorseWindow,
+	InitializeWorldBorder,
+	KeepAliveClientbound,
+	MapChunk,
+	WorldEvent,
+	WorldParticles,
+	UpdateLight,
+	Login,
+	Map,
+	TradeList,
+	RelEntityMove,
+	EntityMoveLook,
+	EntityLook,
+	VehicleMoveClientbound,
+	OpenBook,
+	OpenWindow,
+	OpenSignEntity,
+	Ping,
+	CraftRecipeResponse,
+	AbilitiesClientbound,
+	PlayerChat,
+	EndCombatEvent,
+	EnterCombatEvent,
+	DeathCombatEvent,
+	PlayerInfo,
+	FacePlayer,
+	PositionClientbound,
+	UnlockRecipes,
+	DestroyEntity,
+	RemoveEntityEffect,
+	ResourcePackSend,
+	Respawn,
+	EntityHeadRotation,
+	MultiBlockChange,
+	SelectAdvancementTab,
+	ServerData,
+	ActionBar,
+	WorldBorderCenter,
+	WorldBorderLerpSize,
+	WorldBorderSize,
+	WorldBorderWarningDelay,
+	WorldBorderWarningReach,
+	Camera,
+	HeldItemSlotClientbound,
+	UpdateViewPosition,
+	UpdateViewDistance,
+	SpawnPosition,
+	DisplayChatPreview,
+	ScoreboardDisplayObjective,
+	EntityMetadata,
+	AttachEntity,
+	EntityVelocity,
+	EntityEquipment,
+	Experience,
+	UpdateHealth,
+	ScoreboardObjective,
+	SetPassengers,
+	Teams,
+	ScoreboardScore,
+	SimulationDistance,
+	SetTitleSubtitle,
+	UpdateTime,
+	SetTitleText,
+	SetTitleTime,
+	EntitySoundEffect,
+	SoundEffect,
+	StopSound,
+	SystemChat,
+	PlayerlistHeader,
+	NbtQueryResponse,
+	Collect,
+	EntityTeleport,
+	Advancements,
+	EntityUpdateAttributes,
+	EntityEffect,
+	DeclareRecipes,
+	Tags,
+}
+
+var serverbound759 = []Name{
+	TeleportConfirm,
+	QueryBlockNbt,
+	SetDifficulty,
+	ChatCommand,
+	ChatServerbound,
+	ChatPreviewServerbound,
+	ClientCommand,
+	Settings,
+	TabCompleteServerbound,
+	EnchantItem,
+	WindowClick,
+	CloseWindowServerbound,
+	CustomPayloadServerbound,
+	EditBook,
+	QueryEntityNbt,
+	UseEntity,
+	GenerateStructure,
+	KeepAliveServerbound,
+	LockDifficulty,
+	PositionServerbound,
+	PositionLook,
+	Look,
+	Flying,
+	VehicleMoveServerbound,
+	SteerBoat,
+	PickItem,
+	CraftRecipeRequest,
+	AbilitiesServerbound,
+	BlockDig,
+	EntityAction,
+	SteerVehicle,
+	Pong,
+	DisplayedRecipe,
+	RecipeBook,
+	NameItem,
+	ResourcePackReceive,
+	AdvancementTab,
+	SelectTrade,
+	SetBeaconEffect,
+	HeldItemSlotServerbound,
+	UpdateCommandBlock,
+	UpdateCommandBlockMinecart,
+	SetCreativeSlot,
+	UpdateJigsawBlock,
+	UpdateStructureBlock,
+	UpdateSign,
+	ArmAnimation,
+	Spectate,
+	BlockPlace,
+	UseItem,
+}
+
+// 1.19.1 - 1.19.2
+var clientbound760 = []Name{
+	SpawnEntity,
+	SpawnEntityExperienceOrb,
+	NamedEntitySpawn,
+	Animation,
+	Statistics,
+	BlockChangedAck,
+	BlockBreakAnimation,
+	TileEntityData,
+	BlockAction,
+	BlockChange,
+	BossBar,
+	Difficulty,
+	ChatPreviewClientbound,
+	ClearTitles,
+	TabCompleteClientbound,
+	DeclareCommands,
+	CloseWindowClientbound,
+	WindowItems,
+	CraftProgressBar,
+	SetSlot,
+	SetCooldown,
+	CustomChatCompletions,
+	CustomPayloadClientbound,
+	NamedSoundEffect,
+	DeleteChat,
+	KickDisconnect,
+	EntityStatus,
+	Explosion,
+	UnloadChunk,
+	GameStateChange,
+	OpenHorseWindow,
+	InitializeWorldBorder,
+	KeepAliveClientbound,
+	MapChunk,
+	WorldEvent,
+	WorldParticles,
+	UpdateLight,
+	Login,
+	Map,
+	TradeList,
+	RelEntityMove,
+	EntityMoveLook,
+	EntityLook,
+	VehicleMoveClientbound,
+	OpenBook,
+	OpenWindow,
+	OpenSignEntity,
+	Ping,
+	CraftRecipeResponse,
+	AbilitiesClientbound,
+	PlayerChatHeader,
+	PlayerChat,
+	EndCombatEvent,
+	EnterCombatEvent,
+	DeathCombatEvent,
+	PlayerInfo,
+	FacePlayer,
+	PositionClientbound,
+	UnlockRecipes,
+	DestroyEntity,
+	RemoveEntityEffect,
+	ResourcePackSend,
+	Respawn,
+	EntityHeadRotation,
+	MultiBlockChange,
+	SelectAdvancementTab,
+	ServerData,
+	ActionBar,
+	WorldBorderCenter,
+	WorldBorderLerpSize,
+	WorldBorderSize,
+	WorldBorderWarningDelay,
+	WorldBorderWarningReach,
+	Camera,
+	HeldItemSlotClientbound,
+	UpdateViewPosition,
+	UpdateViewDistance,
+	SpawnPosition,
+	DisplayChatPreview,
+	ScoreboardDisplayObjective,
+	EntityMetadata,
+	AttachEntity,
+	EntityVelocity,
+	EntityEquipment,
+	Experience,
+	UpdateHealth,
+	ScoreboardObjective,
+	SetPassengers,
+	Teams,
+	ScoreboardScore,
+	SimulationDistance,
+	SetTitleSubtitle,
+	UpdateTime,
+	SetTitleText,
+	SetTitleTime,
+	EntitySoundEffect,
+	SoundEffect,
+	StopSound,
+	SystemChat,
+	PlayerlistHeader,
+	NbtQueryResponse,
+	Collect,
+	EntityTeleport,
+	Advancements,
+	EntityUpdateAttributes,
+	EntityEffect,
+	DeclareRecipes,
+	Tags,
+}
+
+var serverbound760 = []Name{
+	TeleportConfirm,
+	QueryBlockNbt,
+	SetDifficulty,
+	MessageAcknowledgement,
+	ChatCommand,
+	ChatServerbound,
+	ChatPreviewServerbound,
+	ClientCommand,
+	Settings,
+	TabCompleteServerbound,
+	EnchantItem,
+	WindowClick,
+	CloseWindowServerbound,
+	CustomPayloadServerbound,
+	EditBook,
+	QueryEntityNbt,
+	UseEntity,
+	GenerateStructure,
+	KeepAliveServerbound,
+	LockDifficulty,
+	PositionServerbound,
+	PositionLook,
+	Look,
+	Flying,
+	VehicleMoveServerbound,
+	SteerBoat,
+	PickItem,
+	CraftRecipeRequest,
+	AbilitiesServerbound,
+	BlockDig,
+	EntityAction,
+	SteerVehicle,
+	Pong,
+	DisplayedRecipe,
+	RecipeBook,
+	NameItem,
+	ResourcePackReceive,
+	AdvancementTab,
+	SelectTrade,
+	SetBeaconEffect,
+	HeldItemSlotServerbound,
+	UpdateCommandBlock,
+	UpdateCommandBlockMinecart,
+	SetCreativeSlot,
+	UpdateJigsawBlock,
+	UpdateStructureBlock,
+	UpdateSign,
+	ArmAnimation,
+	Spectate,
+	BlockPlace,
+	UseItem,
+}
+
+// 1.19.3
+var clientbound761 = []Name{
+	SpawnEntity,
+	SpawnEntityExperienceOrb,
+	NamedEntitySpawn,
+	Animation,
+	Statistics,
+	BlockChangedAck,
+	BlockBreakAnimation,
+	TileEntityData,
+	BlockAction,
+	BlockChange,
+	BossBar,
+	Difficulty,
+	ClearTitles,
+	TabCompleteClientbound,
+	DeclareCommands,
+	CloseWindowClientbound,
+	WindowItems,
+	CraftProgressBar,
+	SetSlot,
+	SetCooldown,
+	CustomChatCompletions,
+	CustomPayloadClientbound,
+	DeleteChat,
+	KickDisconnect,
+	DisguisedChat,
+	EntityStatus,
+	Explosion,
+	UnloadChunk,
+	GameStateChange,
+	OpenHorseWindow,
+	InitializeWorldBorder,
+	KeepAliveClientbound,
+	MapChunk,
+	WorldEvent,
+	WorldParticles,
+	UpdateLight,
+	Login,
+	Map,
+	TradeList,
+	RelEntityMove,
+	EntityMoveLook,
+	EntityLook,
+	VehicleMoveClientbound,
+	OpenBook,
+	OpenWindow,
+	OpenSignEntity,
+	Ping,
+	CraftRecipeResponse,
+	AbilitiesClientbound,
+	PlayerChat,
+	EndCombatEvent,
+	EnterCombatEvent,
+	DeathCombatEvent,
+	PlayerInfoRemove,
+	PlayerInfoUpdate,
+	FacePlayer,
+	PositionClientbound,
+	UnlockRecipes,
+	DestroyEntity,
+	RemoveEntityEffect,
+	ResourcePackSend,
+	Respawn,
+	EntityHeadRotation,
+	MultiBlockChange,
+	SelectAdvancementTab,
+	ServerData,
+	ActionBar,
+	WorldBorderCenter,
+	WorldBorderLerpSize,
+	WorldBorderSize,
+	WorldBorderWarningDelay,
+	WorldBorderWarningReach,
+	Camera,
+	HeldItemSlotClientbound,
+	UpdateViewPosition,
+	UpdateViewDistance,
+	SpawnPosition,
+	ScoreboardDisplayObjective,
+	EntityMetadata,
+	AttachEntity,
+	EntityVelocity,
+	EntityEquipment,
+	Experience,
+	UpdateHealth,
+	ScoreboardObjective,
+	SetPassengers,
+	Teams,
+	ScoreboardScore,
+	SimulationDistance,
+	SetTitleSubtitle,
+	UpdateTime,
+	SetTitleText,
+	SetTitleTime,
+	EntitySoundEffect,
+	SoundEffect,
+	StopSound,
+	SystemChat,
+	PlayerlistHeader,
+	NbtQueryResponse,
+	Collect,
+	EntityTeleport,
+	Advancements,
+	EntityUpdateAttributes,
+	UpdateEnabledFeatures,
+	EntityEffect,
+	DeclareRecipes,
+	Tags,
+}
+
+var serverbound761 = []Name{
+	TeleportConfirm,
+	QueryBlockNbt,
+	SetDifficulty,
+	MessageAcknowledgement,
+	ChatCommand,
+	ChatServerbound,
+	ClientCommand,
+	Settings,
+	TabCompleteServerbound,
+	EnchantItem,
+	WindowClick,
+	CloseWindowServerbound,
+	CustomPayloadServerbound,
+	EditBook,
+	QueryEntityNbt,
+	UseEntity,
+	GenerateStructure,
+	KeepAliveServerbound,
+	LockDifficulty,
+	PositionServerbound,
+	PositionLook,
+	Look,
+	Flying,
+	VehicleMoveServerbound,
+	SteerBoat,
+	PickItem,
+	CraftRecipeRequest,
+	AbilitiesServerbound,
+	BlockDig,
+	EntityAction,
+	SteerVehicle,
+	Pong,
+	ChatSessionUpdate,
+	DisplayedRecipe,
+	RecipeBook,
+	NameItem,
+	ResourcePackReceive,
+	AdvancementTab,
+	SelectTrade,
+	SetBeaconEffect,
+	HeldItemSlotServerbound,
+	UpdateCommandBlock,
+	UpdateCommandBlockMinecart,
+	SetCreativeSlot,
+	UpdateJigsawBlock,
+	UpdateStructureBlock,
+	UpdateSign,
+	ArmAnimation,
+	Spectate,
+	BlockPlace,
+	UseItem,
+}
+
+// 1.19.4
+var clientbound762 = []Name{
+	BundleDelimiter,
+	SpawnEntity,
+	SpawnEntityExperienceOrb,
+	NamedEntitySpawn,
+	Animation,
+	Statistics,
+	BlockChangedAck,
+	BlockBreakAnimation,
+	TileEntityData,
+	BlockAction,
+	BlockChange,
+	BossBar,
+	Difficulty,
+	ChunksBiomes,
+	ClearTitles,
+	TabCompleteClientbound,
+	DeclareCommands,
+	CloseWindowClientbound,
+	WindowItems,
+	CraftProgressBar,
+	SetSlot,
+	SetCooldown,
+	CustomChatCompletions,
+	CustomPayloadClientbound,
+	DamageEvent,
+	DeleteChat,
+	KickDisconnect,
+	DisguisedChat,
+	EntityStatus,
+	Explosion,
+	UnloadChunk,
+	GameStateChange,
+	OpenHorseWindow,
+	HurtAnimation,
+	InitializeWorldBorder,
+	KeepAliveClientbound,
+	MapChunk,
+	WorldEvent,
+	WorldParticles,
+	UpdateLight,
+	Login,
+	Map,
+	TradeList,
+	RelEntityMove,
+	EntityMoveLook,
+	EntityLook,
+	VehicleMoveClientbound,
+	OpenBook,
+	OpenWindow,
+	OpenSignEntity,
+	Ping,
+	CraftRecipeResponse,
+	AbilitiesClientbound,
+	PlayerChat,
+	EndCombatEvent,
+	EnterCombatEvent,
+	DeathCombatEvent,
+	PlayerInfoRemove,
+	PlayerInfoUpdate,
+	FacePlayer,
+	PositionClientbound,
+	UnlockRecipes,
+	DestroyEntity,
+	RemoveEntityEffect,
+	ResourcePackSend,
+	Respawn,
+	EntityHeadRotation,
+	MultiBlockChange,
+	SelectAdvancementTab,
+	ServerData,
+	ActionBar,
+	WorldBorderCenter,
+	WorldBorderLerpSize,
+	WorldBorderSize,
+	WorldBorderWarningDelay,
+	WorldBorderWarningReach,
+	Camera,
+	HeldItemSlotClientbound,
+	UpdateViewPosition,
+	UpdateViewDistance,
+	SpawnPosition,
+	ScoreboardDisplayObjective,
+	EntityMetadata,
+	AttachEntity,
+	EntityVelocity,
+	EntityEquipment,
+	Experience,
+	UpdateHealth,
+	ScoreboardObjective,
+	SetPassengers,
+	Teams,
+	ScoreboardScore,
+	SimulationDistance,
+	SetTitleSubtitle,
+	UpdateTime,
+	SetTitleText,
+	SetTitleTime,
+	EntitySoundEffect,
+	SoundEffect,
+	StopSound,
+	SystemChat,
+	PlayerlistHeader,
+	NbtQueryResponse,
+	Collect,
+	EntityTeleport,
+	Advancements,
+	EntityUpdateAttributes,
+	UpdateEnabledFeatures,
+	EntityEffect,
+	DeclareRecipes,
+	Tags,
+}
+
+var serverbound762 = []Name{
+	TeleportConfirm,
+	QueryBlockNbt,
+	SetDifficulty,
+	MessageAcknowledgement,
+	ChatCommand,
+	ChatServerbound,
+	ChatSessionUpdate,
+	ClientCommand,
+	Settings,
+	TabCompleteServerbound,
+	EnchantItem,
+	WindowClick,
+	CloseWindowServerbound,
+	CustomPayloadServerbound,
+	EditBook,
+	QueryEntityNbt,
+	UseEntity,
+	GenerateStructure,
+	KeepAliveServerbound,
+	LockDifficulty,
+	PositionServerbound,
+	PositionLook,
+	Look,
+	Flying,
+	VehicleMoveServerbound,
+	SteerBoat,
+	PickItem,
+	CraftRecipeRequest,
+	AbilitiesServerbound,
+	BlockDig,
+	EntityAction,
+	SteerVehicle,
+	Pong,
+	DisplayedRecipe,
+	RecipeBook,
+	NameItem,
+	ResourcePackReceive,
+	AdvancementTab,
+	SelectTrade,
+	SetBeaconEffect,
+	HeldItemSlotServerbound,
+	UpdateCommandBlock,
+	UpdateCommandBlockMinecart,
+	SetCreativeSlot,
+	UpdateJigsawBlock,
+	UpdateStructureBlock,
+	UpdateSign,
+	ArmAnimation,
+	Spectate,
+	BlockPlace,
+	UseItem,
+}
@@ -0,0 +1,155 @@
+package protocol
+
+import (
+	"fmt"
+	"sort"
+
+	pk "github.com/Tnze/go-mc/net/packet"
+)
+
+// Name is a version independent identifier of a play state packet.
+type Name string
+
+type Direction int
+
+const (
+	Clientbound Direction = iota
+	Serverbound
+)
+
+func (d Direction) String() string {
+	if d == Serverbound {
+		return "serverbound"
+	}
+	return "clientbound"
+}
+
+type Version struct {
+	Protocol int32
+	Release  string
+
+	clientbound []Name
+	serverbound []Name
+	ids         map[Name]int32
+}
+
+// DefaultProtocol is the protocol spoken by the backend server.
+const DefaultProtocol = 757
+
+var versions = make(map[int32]*Version)
+
+func init() {
+	register(754, "1.16.5", clientbound754, serverbound754)
+	register(755, "1.17", clientbound756, serverbound756)
+	register(756, "1.17.1", clientbound756, serverbound756)
+	register(757, "1.18.1", clientbound757, serverbound757)
+	register(758, "1.18.2", clientbound757, serverbound757)
+	register(759, "1.19", clientbound759, serverbound759)
+	register(760, "1.19.2", clientbound760, serverbound760)
+	register(761, "1.19.3", clientbound761, serverbound761)
+	register(762, "1.19.4", clientbound762, serverbound762)
+}
+
+func register(protocol int32, release string, clientbound, serverbound []Name) {
+	v := &Version{
+		Protocol:    protocol,
+		Release:     release,
+		clientbound: clientbound,
+		serverbound: serverbound,
+		ids:         make(map[Name]int32, len(clientbound)+len(serverbound)),
+	}
+
+	for id, name := range clientbound {
+		v.ids[name] = int32(id)
+	}
+	for id, name := range serverbound {
+		v.ids[name] = int32(id)
+	}
+
+	versions[protocol] = v
+}
+
+// Lookup returns the packet tables for the given protocol number.
+func Lookup(protocol int32) (*Version, bool) {
+	v, ok := versions[protocol]
+	return v, ok
+}
+
+// Default returns the packet tables of DefaultProtocol.
+func Default() *Version {
+	return versions[DefaultProtocol]
+}
+
+// Supported lists every known version ordered by protocol number.
+func Supported() []*Version {
+	list := make([]*Version, 0, len(versions))
+	for _, v := range versions {
+		list = append(list, v)
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i].Protocol < list[j].Protocol })
+
+	return list
+}
+
+func (v *Version) String() string {
+	return fmt.Sprintf("%s (%d)", v.Release, v.Protocol)
+}
+
+// Name resolves the wire ID of a play packet, it returns an empty Name for unknown IDs.
+func (v *Version) Name(d Direction, id int32) Name {
+	table := v.clientbound
+	if d == Serverbound {
+		table = v.serverbound
+	}
+
+	if id < 0 || int(id) >= len(table) {
+		return ""
+	}
+
+	return table[id]
+}
+
+// Names lists the play packets of a direction ordered by their wire ID.
+func (v *Version) Names(d Direction) []Name {
+	if d == Serverbound {
+		return append([]Name(nil), v.serverbound...)
+	}
+	return append([]Name(nil), v.clientbound...)
+}
+
+// ID resolves the wire ID of a named play packet.
+func (v *Version) ID(name Name) (int32, bool) {
+	id, ok := v.ids[name]
+	return id, ok
+}
+
+// Has reports whether the named packet exists in this version.
+func (v *Version) Has(name Name) bool {
+	_, ok := v.ids[name]
+	return ok
+}
+
+// SharesTables reports whether both versions use the same packet IDs.
+func (v *Version) SharesTables(o *Version) bool {
+	return &v.clientbound[0] == &o.clientbound[0] && &v.serverbound[0] == &o.serverbound[0]
+}
+
+// Marshal is pk.Marshal keyed by the packet name.
+func (v *Version) Marshal(name Name, fields ...pk.FieldEncoder) (pk.Packet, error) {
+	id, ok := v.ids[name]
+	if !ok {
+		return pk.Packet{}, UnknownPacketError{Name: name, Protocol: v.Protocol}
+	}
+
+	return pk.Marshal(id, fields...), nil
+}
+
+type UnknownPacketError struct {
+	Name     Name
+	Protocol int32
+}
+
+func (e UnknownPacketError) Error() string {
+	return fmt.Sprintf("packet %s does not exist in protocol %d", e.Name, e.Protocol)
+}
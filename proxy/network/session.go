@@ -5,36 +5,66 @@ import (
 	"fmt"
 	"github.com/OCharnyshevich/proxycraft/proxy/helper"
 	"github.com/OCharnyshevich/proxycraft/proxy/log"
+	"github.com/OCharnyshevich/proxycraft/proxy/network/protocol"
+	"github.com/OCharnyshevich/proxycraft/proxy/network/translate"
 	"github.com/Tnze/go-mc/chat"
 	"github.com/Tnze/go-mc/data/packetid"
 	mcNet "github.com/Tnze/go-mc/net"
 	mcPkt "github.com/Tnze/go-mc/net/packet"
+	"github.com/google/uuid"
 	"io"
 	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
 type State int
 
 const (
-	Status State = 1
-	Login  State = 2
-	Play   State = 3
+	Handshaking State = 0
+	Status      State = 1
+	Login       State = 2
+	Play        State = 3
 )
 
+// sessionIDs numbers the sessions in the order they were accepted.
+var sessionIDs int32
+
 type session struct {
-	logger    *log.Logging
+	id        int32
+	base      *log.Logging
+	logger    atomic.Value // *log.Logging tagged with the session
 	startTime time.Time
 	client    *mcNet.Conn
 	server    *mcNet.Conn
-	state     State
 	events    *Events
+
+	remoteProtocol int32
+
+	mu         sync.RWMutex
+	state      State
+	protocol   *protocol.Version
+	backend    *protocol.Version
+	translator *translate.Translator
+	name       string
+	uuid       uuid.UUID
+	serverName string
+	rejoin     bool // the next Join Game comes from a backend the player was moved to
+
+	writeMu sync.Mutex
+
+	stats
 }
 
-func NewSession(localConn *mcNet.Listener, remoteHost string, remotePort int, events *Events) (sess session, err error) {
-	sess.logger = log.New("session", log.EveryLevel...)
-	sess.state = Status
+func NewSession(localConn *mcNet.Listener, remoteHost string, remotePort int, remoteProtocol int32, events *Events) (sess *session, err error) {
+	sess = &session{}
+	sess.base = log.New("session")
+	sess.logger.Store(sess.base)
+	sess.state = Handshaking
 	sess.events = events
+	sess.remoteProtocol = remoteProtocol
+	sess.serverName = DefaultServer
 
 	client, err := localConn.Accept()
 	if err != nil {
@@ -50,11 +80,15 @@ func NewSession(localConn *mcNet.Listener, remoteHost string, remotePort int, ev
 
 	//server.SetThreshold(256)
 
+	client.Reader = readCounter{Reader: client.Reader, n: &sess.received}
+	client.Writer = writeCounter{Writer: client.Writer, n: &sess.sent}
 	sess.client = &client
+	sess.id = atomic.AddInt32(&sessionIDs, 1)
+	sess.tagLogger()
 	sess.startTime = time.Now().UTC()
 
-	sess.logger.InfoF("Accepted connection from %s", client.Socket.RemoteAddr().String())
-	sess.logger.InfoF("Connected to backend on %s", server.Socket.RemoteAddr().String())
+	sess.log().InfoF("Accepted connection from %s", client.Socket.RemoteAddr().String())
+	sess.log().InfoF("Connected to backend on %s", server.Socket.RemoteAddr().String())
 	sess.server = server
 
 	return sess, err
@@ -62,10 +96,13 @@ func NewSession(localConn *mcNet.Listener, remoteHost string, remotePort int, ev
 
 func (s *session) Kill() {
 	_ = s.client.Close()
-	_ = s.server.Close()
+	_ = s.serverConn().Close()
 }
 
 func (s *session) StreamBidirectional() {
+	s.events.dispatchSession(SessionOpened, s)
+	defer s.events.dispatchSession(SessionClosed, s)
+
 	errs := make(chan error, 2)
 	closer := make(chan interface{}, 2)
 	go s.ClientToServer(errs, closer)
@@ -90,16 +127,37 @@ func (s *session) ClientToServer(errs chan error, closer chan interface{}) {
 					errs <- err
 					break
 				}
-				s.logger.WarnF("Unable to read packet from client: %v", err)
+				s.log().WarnF("Unable to read packet from client: %v", err)
 				continue
 			}
 
-			if err := s.server.WritePacket(packet); err != nil {
+			var ok bool
+			if packet, ok = s.serverbound(packet); !ok {
+				continue
+			}
+
+			if s.State() == Play {
+				if backend, _ := s.link(); backend != nil {
+					named := Packet{Packet: packet, Name: backend.Name(protocol.Serverbound, packet.ID), Version: backend, Session: s}
+					if named.Name == protocol.KeepAliveServerbound {
+						s.keepAliveAnswered()
+					}
+					named, err := s.dispatch(named, false)
+					if errors.Is(err, Drop) {
+						continue
+					} else if err != nil {
+						s.log().With(log.Packet(packet.ID)).WarnF("PacketHandlerError: %v", err)
+					}
+					packet = named.Packet
+				}
+			}
+
+			if err := s.serverConn().WritePacket(packet); err != nil {
 				if errors.Is(err, io.EOF) {
 					errs <- err
 					break
 				}
-				s.logger.WarnF("Unable to send packet to server: %v", err)
+				s.log().WarnF("Unable to send packet to server: %v", err)
 			}
 		}
 	}
@@ -112,81 +170,381 @@ func (s *session) ServerToClient(errs chan error, closer chan interface{}) {
 			return
 		default:
 			var packet mcPkt.Packet
-			err := s.server.ReadPacket(&packet)
+			server := s.serverConn()
+			err := server.ReadPacket(&packet)
+			if server != s.serverConn() {
+				// the player was moved to another backend while the packet was read
+				continue
+			}
 			if err != nil {
 				if errors.Is(err, io.EOF) {
 					errs <- err
 					break
 				}
-				s.logger.WarnF("Unable to read packet from server: %v", err)
+				s.log().WarnF("Unable to read packet from server: %v", err)
 				continue
 			}
 
-			if err := s.handleServerbound(packet); err != nil {
-				s.logger.WarnF("PacketHandlerError: %v", err)
-			}
+			state := s.State()
+			if state == Play {
+				if backend, _ := s.link(); backend != nil {
+					named := Packet{Packet: packet, Name: backend.Name(protocol.Clientbound, packet.ID), Version: backend, Session: s}
+					named, err := s.dispatch(named, true)
+					if errors.Is(err, Drop) {
+						continue
+					} else if err != nil {
+						s.log().With(log.Packet(packet.ID)).WarnF("PacketHandlerError: %v", err)
+					}
+					packet = named.Packet
 
-			if packet.ID == packetid.UpdateTime {
-				continue
+					if named.Name == protocol.KeepAliveClientbound {
+						s.keepAliveSent()
+					}
+					if named.Name == protocol.UpdateTime {
+						continue
+					}
+					if named.Name == protocol.Login && s.takeRejoin() {
+						s.rejoined(packet, backend)
+						continue
+					}
+				}
 			}
 
-			if err := s.client.WritePacket(packet); err != nil {
+			if err := s.WritePacket(packet); err != nil {
 				if errors.Is(err, io.EOF) {
 					errs <- err
 					break
 				}
-				s.logger.WarnF("Unable to send packet to client: %v", err)
+				s.log().WarnF("Unable to send packet to client: %v", err)
+			}
+
+			if state == Login {
+				s.login(packet)
 			}
 		}
 	}
 }
 
-func (s *session) handleServerbound(packet mcPkt.Packet) (err error) {
-	if s.events.generic != nil {
-		for _, handler := range *s.events.generic {
-			if err = handler.F(s.client, s.server, packet); err != nil {
-				return PacketHandlerError{ID: packet.ID, Err: err}
+// serverbound follows the handshake and translates the client's packets, false means the packet is dropped.
+func (s *session) serverbound(packet mcPkt.Packet) (mcPkt.Packet, bool) {
+	state := s.State()
+	if state == Handshaking && packet.ID == protocol.HandshakeID {
+		s.handshake(packet)
+	}
+
+	_, translator := s.link()
+	if translator == nil {
+		return packet, true
+	}
+
+	var err error
+	switch state {
+	case Handshaking:
+		packet, err = translator.Handshake(packet)
+	case Login:
+		if packet.ID == packetid.LoginStart {
+			packet, err = translator.LoginStart(packet)
+		}
+	case Play:
+		return translator.Serverbound(packet)
+	}
+
+	if err != nil {
+		s.log().WarnF("Unable to translate packet 0x%X from client: %v", packet.ID, err)
+	}
+
+	return packet, true
+}
+
+// clientbound translates a packet of the server for the client, false means the packet is dropped.
+func (s *session) clientbound(packet mcPkt.Packet) (mcPkt.Packet, bool) {
+	_, translator := s.link()
+	if translator == nil {
+		return packet, true
+	}
+
+	var err error
+	switch s.State() {
+	case Status:
+		if packet.ID == packetid.ServerInfo {
+			packet, err = translator.Status(packet)
+		}
+	case Login:
+		if packet.ID == packetid.Success {
+			packet, err = translator.LoginSuccess(packet)
+		}
+	case Play:
+		return translator.Clientbound(packet)
+	}
+
+	if err != nil {
+		s.log().WarnF("Unable to translate packet 0x%X from server: %v", packet.ID, err)
+	}
+
+	return packet, true
+}
+
+// handshake reads the protocol version and the requested state from the client's Handshake.
+func (s *session) handshake(packet mcPkt.Packet) {
+	h, err := protocol.ReadHandshake(packet)
+	if err != nil {
+		s.log().WarnF("Unable to read handshake: %v", err)
+		return
+	}
+
+	version, ok := protocol.Lookup(h.Protocol)
+	if !ok && State(h.NextState) == Login {
+		s.log().WarnF("Unsupported protocol %d, packets will be passed through without handlers", h.Protocol)
+	}
+
+	backend := version
+	if s.remoteProtocol != 0 {
+		backend, _ = protocol.Lookup(s.remoteProtocol)
+	}
+
+	var translator *translate.Translator
+	if ok && backend != nil {
+		if translator, err = translate.New(version, backend, s.log()); err != nil {
+			s.log().WarnF("Packets will be passed through: %v", err)
+		}
+	}
+
+	s.mu.Lock()
+	s.state = State(h.NextState)
+	s.protocol = version
+	s.backend = backend
+	s.translator = translator
+	s.mu.Unlock()
+
+	if ok && s.state == Login {
+		if translator != nil {
+			s.log().InfoF("Client is using Minecraft %v, translating to %v", version, backend)
+		} else {
+			s.log().InfoF("Client is using Minecraft %v", version)
+		}
+	}
+}
+
+// login follows the login sequence so the session knows when compression and the play state begin.
+func (s *session) login(packet mcPkt.Packet) {
+	switch packet.ID {
+	case packetid.Compress:
+		var threshold mcPkt.VarInt
+		if err := packet.Scan(&threshold); err != nil {
+			s.log().WarnF("Unable to read compression threshold: %v", err)
+			return
+		}
+		s.client.SetThreshold(int(threshold))
+		s.serverConn().SetThreshold(int(threshold))
+	case packetid.Success:
+		var (
+			id   mcPkt.UUID
+			name mcPkt.String
+		)
+		if err := packet.Scan(&id, &name); err != nil {
+			s.log().WarnF("Unable to read login success: %v", err)
+		}
+
+		s.mu.Lock()
+		s.state = Play
+		s.uuid = uuid.UUID(id)
+		s.name = string(name)
+		s.mu.Unlock()
+
+		s.tagLogger()
+		s.log().InfoF("%s (%s) joined the game", name, uuid.UUID(id))
+		s.events.dispatchSession(SessionJoined, s)
+	}
+}
+
+// Disconnect kicks the player with the given reason and closes the session.
+func (s *session) Disconnect(reason string) {
+	var err error
+
+	switch s.State() {
+	case Login:
+		// the login state has its own disconnect packet, which is the same in every version
+		err = s.WritePacket(mcPkt.Marshal(packetid.Disconnect, chat.Text(reason)))
+	case Play:
+		if version := s.Backend(); version != nil {
+			var packet mcPkt.Packet
+			if packet, err = version.Marshal(protocol.KickDisconnect, chat.Text(reason)); err == nil {
+				err = s.WritePacket(packet)
 			}
 		}
 	}
-	if listeners := s.events.handlers[packet.ID]; listeners != nil {
-		for _, handler := range *listeners {
-			err = handler.F(s.client, s.server, packet)
+	if err != nil {
+		s.log().WarnF("Unable to send disconnect: %v", err)
+	}
+
+	s.Kill()
+}
+
+// log returns the logger of the session.
+func (s *session) log() *log.Logging {
+	return s.logger.Load().(*log.Logging)
+}
+
+// tagLogger tags the lines of the session with its ID, the player and the backend.
+func (s *session) tagLogger() {
+	fields := []log.Field{log.Session(s.id)}
+	if name := s.Name(); name != "" {
+		fields = append(fields, log.Player(name))
+	}
+	fields = append(fields, log.Backend(s.Server()))
+
+	s.logger.Store(s.base.With(fields...))
+}
+
+func (s *session) ID() int32 {
+	return s.id
+}
+
+func (s *session) Name() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.name
+}
+
+func (s *session) UUID() uuid.UUID {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.uuid
+}
+
+func (s *session) Address() string {
+	return s.client.Socket.RemoteAddr().String()
+}
+
+func (s *session) StartTime() time.Time {
+	return s.startTime
+}
+
+func (s *session) State() State {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.state
+}
+
+// Version is the protocol version of the client, nil while it is unknown or unsupported.
+func (s *session) Version() *protocol.Version {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.protocol
+}
+
+func (s *session) Backend() *protocol.Version {
+	backend, _ := s.link()
+	return backend
+}
+
+// link returns the backend's protocol version and the translator between the client and the backend.
+func (s *session) link() (*protocol.Version, *translate.Translator) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.backend, s.translator
+}
+
+// WritePacket sends a packet built for the backend's protocol version to the client.
+func (s *session) WritePacket(packet mcPkt.Packet) error {
+	packet, ok := s.clientbound(packet)
+	if !ok {
+		return nil
+	}
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	return s.client.WritePacket(packet)
+}
+
+// dispatch passes the packet to the generic listeners of its direction and the listeners of its name.
+// dispatch calls the handlers of the packet, it returns the packet as rewritten by them.
+func (s *session) dispatch(packet Packet, clientbound bool) (Packet, error) {
+	generic, specific := s.events.listeners(packet.Name, clientbound)
+	server := s.serverConn()
+
+	for _, handlers := range [][]PacketHandler{generic, specific} {
+		for _, handler := range handlers {
+			err := handler.F(s.client, server, packet)
+			var rewrite Rewrite
+			if errors.As(err, &rewrite) {
+				packet.Data = rewrite.Data
+				continue
+			}
 			if err != nil {
-				return PacketHandlerError{ID: packet.ID, Err: err}
+				return packet, PacketHandlerError{ID: packet.ID, Name: packet.Name, Err: err}
 			}
 		}
 	}
 
-	return nil
+	return packet, nil
 }
 
 func (s *session) SendMessage(message ...interface{}) {
-	err := s.client.WritePacket(mcPkt.Marshal(
-		packetid.ChatClientbound,
-		chat.Text(helper.ConvertToString(message)), mcPkt.Byte(2),
-		mcPkt.UUID{},
-	))
+	version, _ := s.link()
+	if version == nil {
+		version = protocol.Default()
+	}
+
+	packet, err := chatPacket(version, chat.Text(helper.ConvertToString(message)))
+	if err == nil {
+		err = s.WritePacket(packet)
+	}
 	if err != nil {
-		s.logger.Fail(err)
+		s.log().Fail(err)
 	}
 
-	err = s.client.WritePacket(mcPkt.Marshal(
-		packetid.UpdateTime,
+	packet, err = version.Marshal(
+		protocol.UpdateTime,
 		mcPkt.Long(275690), mcPkt.Long(1019),
-	))
+	)
+	if err == nil {
+		err = s.WritePacket(packet)
+	}
 	if err != nil {
-		s.logger.Fail(err)
+		s.log().Fail(err)
+	}
+}
+
+// ChatPacket builds a chat message of the player with the UUID, shown in the chat box, in the
+// layout used by the given version. The message is a chat component, like a chat.Message.
+func ChatPacket(version *protocol.Version, msg mcPkt.FieldEncoder, sender uuid.UUID) (mcPkt.Packet, error) {
+	switch {
+	case version.Has(protocol.ChatClientbound):
+		return version.Marshal(protocol.ChatClientbound, msg, mcPkt.Byte(0), mcPkt.UUID(sender))
+	case version.Protocol == 759:
+		return version.Marshal(protocol.SystemChat, msg, mcPkt.VarInt(1))
+	default:
+		return version.Marshal(protocol.SystemChat, msg, mcPkt.Boolean(false))
+	}
+}
+
+// chatPacket builds a system chat message in the layout used by the given version.
+func chatPacket(version *protocol.Version, msg chat.Message) (mcPkt.Packet, error) {
+	switch {
+	case version.Has(protocol.ChatClientbound):
+		return version.Marshal(protocol.ChatClientbound, msg, mcPkt.Byte(2), mcPkt.UUID{})
+	case version.Protocol == 759:
+		return version.Marshal(protocol.SystemChat, msg, mcPkt.VarInt(2))
+	default:
+		return version.Marshal(protocol.SystemChat, msg, mcPkt.Boolean(true))
 	}
 }
 
 type PacketHandlerError struct {
-	ID  int32
-	Err error
+	ID   int32
+	Name protocol.Name
+	Err  error
 }
 
 func (d PacketHandlerError) Error() string {
-	return fmt.Sprintf("handle packet 0x%X error: %v", d.ID, d.Err)
+	return fmt.Sprintf("handle packet %s (0x%X) error: %v", d.Name, d.ID, d.Err)
 }
 
 func (d PacketHandlerError) Unwrap() error {
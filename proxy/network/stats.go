@@ -0,0 +1,57 @@
+package network
+
+import (
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// stats counts the traffic of a session and measures the ping by the keep alives.
+type stats struct {
+	received  uint64
+	sent      uint64
+	ping      int64 // nanoseconds
+	keepAlive int64 // unix nanoseconds the last keep alive was passed to the client
+}
+
+type readCounter struct {
+	io.Reader
+	n *uint64
+}
+
+func (r readCounter) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	atomic.AddUint64(r.n, uint64(n))
+	return n, err
+}
+
+type writeCounter struct {
+	io.Writer
+	n *uint64
+}
+
+func (w writeCounter) Write(p []byte) (int, error) {
+	n, err := w.Writer.Write(p)
+	atomic.AddUint64(w.n, uint64(n))
+	return n, err
+}
+
+func (s *stats) keepAliveSent() {
+	atomic.StoreInt64(&s.keepAlive, time.Now().UnixNano())
+}
+
+func (s *stats) keepAliveAnswered() {
+	if sent := atomic.SwapInt64(&s.keepAlive, 0); sent != 0 {
+		atomic.StoreInt64(&s.ping, time.Now().UnixNano()-sent)
+	}
+}
+
+// Ping is the time the client took to answer the last keep alive, zero before the first one.
+func (s *stats) Ping() time.Duration {
+	return time.Duration(atomic.LoadInt64(&s.ping))
+}
+
+// Traffic returns the bytes received from and sent to the client, as they went over the wire.
+func (s *stats) Traffic() (received, sent uint64) {
+	return atomic.LoadUint64(&s.received), atomic.LoadUint64(&s.sent)
+}
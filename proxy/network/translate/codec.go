@@ -0,0 +1,243 @@
+package translate
+
+import (
+	"reflect"
+	"strings"
+
+	pk "github.com/Tnze/go-mc/net/packet"
+)
+
+// patchCodec reshapes the registry codec of a 1.18 server into the one the client expects.
+// Clients fail to join when a required field or registry is missing, unknown fields are ignored.
+func (t *Translator) patchCodec(codec map[string]interface{}) {
+	dimensions := registry(codec, "minecraft:dimension_type")
+	for _, entry := range dimensions {
+		t.patchDimension(element(entry))
+	}
+
+	for _, entry := range registry(codec, "minecraft:worldgen/biome") {
+		t.patchBiome(element(entry))
+	}
+
+	if t.client.Protocol >= 759 {
+		codec["minecraft:chat_type"] = chatTypes(t.client.Protocol)
+	}
+	if t.client.Protocol >= 762 {
+		codec["minecraft:damage_type"] = damageTypes()
+	}
+
+	t.mu.Lock()
+	t.dimensions = dimensions
+	t.mu.Unlock()
+}
+
+func (t *Translator) patchDimension(dimension map[string]interface{}) {
+	if dimension == nil {
+		return
+	}
+
+	// 1.18.2 references the infiniburn block tag with a leading #
+	if infiniburn, ok := dimension["infiniburn"].(string); ok {
+		infiniburn = strings.TrimPrefix(infiniburn, "#")
+		if t.client.Protocol >= 758 {
+			infiniburn = "#" + infiniburn
+		}
+		dimension["infiniburn"] = infiniburn
+	}
+
+	if height, ok := dimension["logical_height"].(int32); ok && t.client.Protocol < 755 && height > 256 {
+		dimension["logical_height"] = int32(256)
+	}
+
+	if t.client.Protocol >= 759 {
+		setDefault(dimension, "monster_spawn_light_level", int32(0))
+		setDefault(dimension, "monster_spawn_block_light_limit", int32(0))
+	}
+}
+
+func (t *Translator) patchBiome(biome map[string]interface{}) {
+	if biome == nil {
+		return
+	}
+
+	if t.client.Protocol < 757 {
+		setDefault(biome, "depth", float32(0.1))
+		setDefault(biome, "scale", float32(0.2))
+	}
+
+	if precipitation, ok := biome["precipitation"].(string); ok && t.client.Protocol >= 762 {
+		biome["has_precipitation"] = boolByte(precipitation != "none")
+	}
+}
+
+// dimensionField is the dimension of Join Game and Respawn, a whole dimension type
+// up to 1.18.2 and the name of a dimension type from 1.19 on.
+func (t *Translator) dimensionField(dimension map[string]interface{}, world pk.Identifier) pk.FieldEncoder {
+	if t.client.Protocol < 759 {
+		t.patchDimension(dimension)
+		return pk.NBT(dimension)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// the codec was already patched, so compare against a patched copy
+	patched := make(map[string]interface{}, len(dimension)+2)
+	for k, v := range dimension {
+		patched[k] = v
+	}
+	t.patchDimension(patched)
+
+	for _, entry := range t.dimensions {
+		if reflect.DeepEqual(element(entry), patched) {
+			if name, ok := entry.(map[string]interface{})["name"].(string); ok {
+				return pk.Identifier(name)
+			}
+		}
+	}
+
+	// vanilla dimension types share the name of their world
+	return world
+}
+
+func registry(codec map[string]interface{}, name string) []interface{} {
+	r, _ := codec[name].(map[string]interface{})
+	entries, _ := r["value"].([]interface{})
+	return entries
+}
+
+func element(entry interface{}) map[string]interface{} {
+	e, _ := entry.(map[string]interface{})
+	el, _ := e["element"].(map[string]interface{})
+	return el
+}
+
+func setDefault(compound map[string]interface{}, key string, value interface{}) {
+	if _, ok := compound[key]; !ok {
+		compound[key] = value
+	}
+}
+
+func boolByte(b bool) byte {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func entries(kind string, elements []map[string]interface{}, names ...string) map[string]interface{} {
+	value := make([]interface{}, len(names))
+	for i, name := range names {
+		value[i] = map[string]interface{}{
+			"name":    name,
+			"id":      int32(i),
+			"element": elements[i],
+		}
+	}
+
+	return map[string]interface{}{
+		"type":  kind,
+		"value": value,
+	}
+}
+
+func decoration(key string) map[string]interface{} {
+	return map[string]interface{}{
+		"translation_key": key,
+		"parameters":      []string{"sender", "content"},
+		"style":           map[string]interface{}{},
+	}
+}
+
+// chatTypes builds the chat_type registry required since 1.19. Chat is translated
+// into system chat, so the registry only needs to satisfy the client.
+func chatTypes(protocol int32) map[string]interface{} {
+	if protocol == 759 {
+		// 1.19 looks system chat up by the position, see chatClientbound
+		return entries("minecraft:chat_type", []map[string]interface{}{
+			{
+				"chat":      map[string]interface{}{"decoration": decoration("chat.type.text")},
+				"narration": map[string]interface{}{"decoration": decoration("chat.type.text.narrate"), "priority": "chat"},
+			},
+			{
+				"chat":      map[string]interface{}{},
+				"narration": map[string]interface{}{"priority": "system"},
+			},
+			{
+				"overlay": map[string]interface{}{},
+			},
+		}, "minecraft:chat", "minecraft:system", "minecraft:game_info")
+	}
+
+	return entries("minecraft:chat_type", []map[string]interface{}{
+		{
+			"chat":      decoration("chat.type.text"),
+			"narration": decoration("chat.type.text.narrate"),
+		},
+	}, "minecraft:chat")
+}
+
+// vanillaDamageTypes are the damage types a 1.19.4 client looks up on joining, with their death message IDs.
+var vanillaDamageTypes = [][2]string{
+	{"minecraft:in_fire", "inFire"},
+	{"minecraft:lightning_bolt", "lightningBolt"},
+	{"minecraft:on_fire", "onFire"},
+	{"minecraft:lava", "lava"},
+	{"minecraft:hot_floor", "hotFloor"},
+	{"minecraft:in_wall", "inWall"},
+	{"minecraft:cramming", "cramming"},
+	{"minecraft:drown", "drown"},
+	{"minecraft:starve", "starve"},
+	{"minecraft:cactus", "cactus"},
+	{"minecraft:fall", "fall"},
+	{"minecraft:fly_into_wall", "flyIntoWall"},
+	{"minecraft:out_of_world", "outOfWorld"},
+	{"minecraft:generic", "generic"},
+	{"minecraft:magic", "magic"},
+	{"minecraft:wither", "wither"},
+	{"minecraft:dragon_breath", "dragonBreath"},
+	{"minecraft:dry_out", "dryout"},
+	{"minecraft:sweet_berry_bush", "sweetBerryBush"},
+	{"minecraft:freeze", "freeze"},
+	{"minecraft:stalagmite", "stalagmite"},
+	{"minecraft:falling_block", "fallingBlock"},
+	{"minecraft:falling_anvil", "anvil"},
+	{"minecraft:falling_stalactite", "fallingStalactite"},
+	{"minecraft:sting", "sting"},
+	{"minecraft:mob_attack", "mob"},
+	{"minecraft:mob_attack_no_aggro", "mob"},
+	{"minecraft:player_attack", "player"},
+	{"minecraft:arrow", "arrow"},
+	{"minecraft:trident", "trident"},
+	{"minecraft:mob_projectile", "mob"},
+	{"minecraft:fireworks", "fireworks"},
+	{"minecraft:fireball", "fireball"},
+	{"minecraft:unattributed_fireball", "onFire"},
+	{"minecraft:wither_skull", "witherSkull"},
+	{"minecraft:thrown", "thrown"},
+	{"minecraft:indirect_magic", "indirectMagic"},
+	{"minecraft:thorns", "thorns"},
+	{"minecraft:explosion", "explosion"},
+	{"minecraft:player_explosion", "explosion.player"},
+	{"minecraft:sonic_boom", "sonic_boom"},
+	{"minecraft:bad_respawn_point", "badRespawnPoint"},
+	{"minecraft:outside_border", "outsideBorder"},
+	{"minecraft:generic_kill", "genericKill"},
+}
+
+// damageTypes builds the damage_type registry required since 1.19.4.
+func damageTypes() map[string]interface{} {
+	names := make([]string, len(vanillaDamageTypes))
+	elements := make([]map[string]interface{}, len(vanillaDamageTypes))
+
+	for i, d := range vanillaDamageTypes {
+		names[i] = d[0]
+		elements[i] = map[string]interface{}{
+			"message_id": d[1],
+			"scaling":    "when_caused_by_living_non_player",
+			"exhaustion": float32(0.1),
+		}
+	}
+
+	return entries("minecraft:damage_type", elements, names...)
+}
@@ -0,0 +1,123 @@
+package translate
+
+import (
+	"embed"
+	"strings"
+
+	"github.com/OCharnyshevich/proxycraft/proxy/network/protocol"
+	pk "github.com/Tnze/go-mc/net/packet"
+)
+
+// The item registries list the item names by ID, generated from the item data of the
+// go-mc releases. The 1.18 list is the 1.17 one with the Otherside music disc, which is
+// the only item added in 1.18.
+//
+//go:embed items/*.txt
+var itemFiles embed.FS
+
+// itemRegistries names the item list of the protocols, the items of 1.19 to 1.19.3
+// are unknown.
+var itemRegistries = map[int32]string{
+	754: "1.16.5",
+	755: "1.17",
+	756: "1.17",
+	757: "1.18",
+	758: "1.18",
+	762: "1.19.4",
+}
+
+// renamedItems pair the old and the new name of the renamed items, both ways.
+var renamedItems = map[string]string{
+	"grass_path": "dirt_path", "dirt_path": "grass_path", // 1.17
+}
+
+// itemIDs maps the item IDs of one registry to the ones of another, -1 marks the
+// items missing there.
+type itemIDs []int32
+
+// itemMapping maps the item IDs between the registries of the client and the server.
+// It is nil when the registries are the same, the IDs are kept as they are.
+type itemMapping struct {
+	known       bool
+	clientbound itemIDs
+	serverbound itemIDs
+}
+
+// newItemMapping maps the items between the versions, known is false when the item
+// registry of one of them is unknown.
+func newItemMapping(client, server *protocol.Version) *itemMapping {
+	clientName, clientOK := itemRegistries[client.Protocol]
+	serverName, serverOK := itemRegistries[server.Protocol]
+	if !clientOK || !serverOK {
+		return &itemMapping{}
+	}
+	if clientName == serverName {
+		return nil
+	}
+
+	clientItems, serverItems := items(clientName), items(serverName)
+	return &itemMapping{
+		known:       true,
+		clientbound: mapItems(serverItems, clientItems),
+		serverbound: mapItems(clientItems, serverItems),
+	}
+}
+
+// items reads an item registry, the embedded files are known to exist.
+func items(name string) []string {
+	content, err := itemFiles.ReadFile("items/" + name + ".txt")
+	if err != nil {
+		panic(err)
+	}
+	return strings.Fields(string(content))
+}
+
+func mapItems(from, to []string) itemIDs {
+	ids := make(map[string]int32, len(to))
+	for id, name := range to {
+		ids[name] = int32(id)
+	}
+
+	mapped := make(itemIDs, len(from))
+	for id, name := range from {
+		target, ok := ids[name]
+		if !ok {
+			target, ok = ids[renamedItems[name]]
+		}
+		if !ok {
+			target = -1
+		}
+		mapped[id] = target
+	}
+	return mapped
+}
+
+// convert maps the item of the slot, an item missing on the other side or of an unknown
+// registry empties the slot rather than showing another item.
+func (m *itemMapping) convert(ids itemIDs, s slot) slot {
+	if !s.Present {
+		return s
+	}
+	if !m.known || int(s.Item) < 0 || int(s.Item) >= len(ids) || ids[s.Item] < 0 {
+		return slot{}
+	}
+
+	s.Item = pk.VarInt(ids[s.Item])
+	return s
+}
+
+// toClient maps an item of the server to the client.
+func (m *itemMapping) toClient(s slot) slot {
+	if m == nil {
+		return s
+	}
+	return m.convert(m.clientbound, s)
+}
+
+// toServer maps an item of the client to the server.
+func (m *itemMapping) toServer(s slot) slot {
+	if m == nil {
+		return s
+	}
+	return m.convert(m.serverbound, s)
+}
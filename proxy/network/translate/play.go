@@ -0,0 +1,371 @@
+package translate
+
+import (
+	"fmt"
+
+	"github.com/OCharnyshevich/proxycraft/proxy/network/protocol"
+	pk "github.com/Tnze/go-mc/net/packet"
+)
+
+// clientbound rules are keyed by the server's packet names.
+var clientbound = map[protocol.Name]converter{
+	protocol.KeepAliveClientbound: toClient,
+	protocol.Ping:                 toClient,
+	protocol.KickDisconnect:       toClient,
+	protocol.ChatClientbound:      chatClientbound,
+
+	protocol.TabCompleteClientbound: toClient,
+	protocol.DeclareCommands:        declareCommands,
+
+	protocol.Login:              joinGame,
+	protocol.Respawn:            respawn,
+	protocol.UpdateViewPosition: toClient,
+	protocol.UpdateTime:         toClient,
+	protocol.UpdateHealth:       toClient,
+
+	protocol.PositionClientbound:    playerPosition,
+	protocol.RelEntityMove:          toClient,
+	protocol.EntityMoveLook:         toClient,
+	protocol.EntityLook:             toClient,
+	protocol.EntityTeleport:         toClient,
+	protocol.EntityHeadRotation:     toClient,
+	protocol.EntityVelocity:         toClient,
+	protocol.VehicleMoveClientbound: toClient,
+
+	protocol.WindowItems:             windowItems,
+	protocol.SetSlot:                 setSlot,
+	protocol.OpenWindow:              toClient,
+	protocol.CloseWindowClientbound:  toClient,
+	protocol.CraftProgressBar:        toClient,
+	protocol.HeldItemSlotClientbound: toClient,
+}
+
+// serverbound rules are keyed by the client's packet names.
+var serverbound = map[protocol.Name]converter{
+	protocol.KeepAliveServerbound:   toServer,
+	protocol.Pong:                   toServer,
+	protocol.TeleportConfirm:        toServer,
+	protocol.ClientCommand:          toServer,
+	protocol.Settings:               settings,
+	protocol.ChatServerbound:        chatServerbound,
+	protocol.ChatCommand:            chatCommand,
+	protocol.ChatPreviewServerbound: ignore,
+	protocol.MessageAcknowledgement: ignore,
+	protocol.ChatSessionUpdate:      ignore,
+	protocol.TabCompleteServerbound: toServer,
+
+	protocol.PositionServerbound:    toServer,
+	protocol.PositionLook:           toServer,
+	protocol.Look:                   toServer,
+	protocol.Flying:                 toServer,
+	protocol.VehicleMoveServerbound: toServer,
+
+	protocol.WindowClick:             windowClick,
+	protocol.TransactionServerbound:  ignore,
+	protocol.CloseWindowServerbound:  toServer,
+	protocol.HeldItemSlotServerbound: toServer,
+	protocol.SetCreativeSlot:         setCreativeSlot,
+}
+
+// chatClientbound turns chat into system chat for 1.19 clients, which only accept signed player chat.
+func chatClientbound(t *Translator, name protocol.Name, p pk.Packet) (pk.Packet, error) {
+	if t.client.Has(protocol.ChatClientbound) {
+		return toClient(t, name, p)
+	}
+
+	var (
+		message  pk.String
+		position pk.Byte
+	)
+	if err := p.Scan(&message, &position); err != nil {
+		return p, err
+	}
+
+	if t.client.Protocol == 759 {
+		// the position matches the IDs of the chat_type registry sent in Join Game
+		return t.client.Marshal(protocol.SystemChat, message, pk.VarInt(position))
+	}
+
+	return t.client.Marshal(protocol.SystemChat, message, pk.Boolean(position == 2))
+}
+
+// declareCommands passes the command graph to the clients up to 1.18.2. The parsers of
+// registry resources added in 1.18.2 become resource locations for older clients. The
+// clients from 1.19 number the parsers, which is not translated, they get no graph and
+// complete no commands.
+func declareCommands(t *Translator, _ protocol.Name, p pk.Packet) (pk.Packet, error) {
+	if t.client.Protocol >= 759 {
+		return p, fmt.Errorf("the command parsers of %v are not translated", t.client)
+	}
+
+	nodes, root, err := t.server.ReadCommands(p)
+	if err != nil {
+		return p, err
+	}
+
+	if t.client.Protocol < 758 {
+		for i, n := range nodes {
+			if n.Parser == "minecraft:resource" || n.Parser == "minecraft:resource_or_tag" {
+				nodes[i].Parser = "minecraft:resource_location"
+				nodes[i].Properties = nil
+			}
+		}
+	}
+
+	return t.client.MarshalCommands(nodes, root)
+}
+
+func joinGame(t *Translator, _ protocol.Name, p pk.Packet) (pk.Packet, error) {
+	var (
+		entityID           pk.Int
+		hardcore           pk.Boolean
+		gamemode           pk.UnsignedByte
+		previousGamemode   pk.Byte
+		worldCount         pk.VarInt
+		worlds             []pk.Identifier
+		codec              map[string]interface{}
+		dimension          map[string]interface{}
+		worldName          pk.Identifier
+		hashedSeed         pk.Long
+		maxPlayers         pk.VarInt
+		viewDistance       pk.VarInt
+		simulationDistance pk.VarInt
+		reducedDebugInfo   pk.Boolean
+		respawnScreen      pk.Boolean
+		debug              pk.Boolean
+		flat               pk.Boolean
+	)
+
+	err := p.Scan(
+		&entityID, &hardcore, &gamemode, &previousGamemode,
+		&worldCount, pk.Ary{Len: &worldCount, Ary: &worlds},
+		pk.NBT(&codec), pk.NBT(&dimension), &worldName, &hashedSeed,
+		&maxPlayers, &viewDistance, &simulationDistance,
+		&reducedDebugInfo, &respawnScreen, &debug, &flat,
+	)
+	if err != nil {
+		return p, err
+	}
+
+	t.patchCodec(codec)
+	dimensionField := t.dimensionField(dimension, worldName)
+
+	fields := []pk.FieldEncoder{
+		entityID, hardcore, gamemode, previousGamemode,
+		worldCount, pk.Ary{Ary: worlds},
+		pk.NBT(codec), dimensionField, worldName, hashedSeed,
+		maxPlayers, viewDistance,
+	}
+	if t.client.Protocol >= 757 {
+		fields = append(fields, simulationDistance)
+	}
+	fields = append(fields, reducedDebugInfo, respawnScreen, debug, flat)
+	if t.client.Protocol >= 759 {
+		// no last death location
+		fields = append(fields, pk.Boolean(false))
+	}
+
+	return t.client.Marshal(protocol.Login, fields...)
+}
+
+func respawn(t *Translator, _ protocol.Name, p pk.Packet) (pk.Packet, error) {
+	var (
+		dimension        map[string]interface{}
+		worldName        pk.Identifier
+		hashedSeed       pk.Long
+		gamemode         pk.UnsignedByte
+		previousGamemode pk.Byte
+		debug            pk.Boolean
+		flat             pk.Boolean
+		copyMetadata     pk.Boolean
+	)
+
+	err := p.Scan(
+		pk.NBT(&dimension), &worldName, &hashedSeed,
+		&gamemode, &previousGamemode, &debug, &flat, &copyMetadata,
+	)
+	if err != nil {
+		return p, err
+	}
+
+	fields := []pk.FieldEncoder{
+		t.dimensionField(dimension, worldName), worldName, hashedSeed,
+		gamemode, previousGamemode, debug, flat, copyMetadata,
+	}
+	if t.client.Protocol >= 759 {
+		fields = append(fields, pk.Boolean(false))
+	}
+
+	return t.client.Marshal(protocol.Respawn, fields...)
+}
+
+// playerPosition drops the "dismount vehicle" flag, which only exists from 1.17 to 1.19.3.
+func playerPosition(t *Translator, name protocol.Name, p pk.Packet) (pk.Packet, error) {
+	if t.client.Protocol >= 755 && t.client.Protocol <= 761 {
+		return toClient(t, name, p)
+	}
+
+	var (
+		x, y, z    pk.Double
+		yaw, pitch pk.Float
+		flags      pk.Byte
+		teleportID pk.VarInt
+	)
+	if err := p.Scan(&x, &y, &z, &yaw, &pitch, &flags, &teleportID); err != nil {
+		return p, err
+	}
+
+	return t.client.Marshal(name, x, y, z, yaw, pitch, flags, teleportID)
+}
+
+// windowItems maps the items, and drops the state ID and the carried item for clients
+// older than 1.17.1.
+func windowItems(t *Translator, name protocol.Name, p pk.Packet) (pk.Packet, error) {
+	var (
+		windowID pk.UnsignedByte
+		stateID  pk.VarInt
+		count    pk.VarInt
+		slots    []slot
+		carried  slot
+	)
+	if err := p.Scan(&windowID, &stateID, &count, pk.Ary{Len: &count, Ary: &slots}, &carried); err != nil {
+		return p, err
+	}
+
+	t.setStateID(stateID)
+
+	for i := range slots {
+		slots[i] = t.items.toClient(slots[i])
+	}
+	if t.client.Protocol >= 756 {
+		return t.client.Marshal(name, windowID, stateID, count, pk.Ary{Ary: slots}, t.items.toClient(carried))
+	}
+
+	return t.client.Marshal(name, windowID, pk.Short(count), pk.Ary{Ary: slots})
+}
+
+func setSlot(t *Translator, name protocol.Name, p pk.Packet) (pk.Packet, error) {
+	var (
+		windowID pk.Byte
+		stateID  pk.VarInt
+		index    pk.Short
+		item     slot
+	)
+	if err := p.Scan(&windowID, &stateID, &index, &item); err != nil {
+		return p, err
+	}
+
+	t.setStateID(stateID)
+
+	item = t.items.toClient(item)
+	if t.client.Protocol >= 756 {
+		return t.client.Marshal(name, windowID, stateID, index, item)
+	}
+
+	return t.client.Marshal(name, windowID, index, item)
+}
+
+func settings(t *Translator, name protocol.Name, p pk.Packet) (pk.Packet, error) {
+	var (
+		locale        pk.String
+		viewDistance  pk.Byte
+		chatMode      pk.VarInt
+		chatColors    pk.Boolean
+		skinParts     pk.UnsignedByte
+		mainHand      pk.VarInt
+		textFiltering pk.Boolean
+		allowListing  = pk.Boolean(true)
+	)
+
+	fields := []pk.FieldDecoder{&locale, &viewDistance, &chatMode, &chatColors, &skinParts, &mainHand}
+	if t.client.Protocol >= 755 {
+		fields = append(fields, &textFiltering)
+	}
+	if t.client.Protocol >= 757 {
+		fields = append(fields, &allowListing)
+	}
+	if err := p.Scan(fields...); err != nil {
+		return p, err
+	}
+
+	return t.server.Marshal(name, locale, viewDistance, chatMode, chatColors, skinParts, mainHand, textFiltering, allowListing)
+}
+
+// chatServerbound keeps the message only, the 1.19 signature is meaningless to a 1.18 server.
+func chatServerbound(t *Translator, name protocol.Name, p pk.Packet) (pk.Packet, error) {
+	var message pk.String
+	if err := p.Scan(&message); err != nil {
+		return p, err
+	}
+
+	return t.server.Marshal(name, message)
+}
+
+// chatCommand turns the 1.19 command packet back into a chat message starting with a slash.
+func chatCommand(t *Translator, _ protocol.Name, p pk.Packet) (pk.Packet, error) {
+	var command pk.String
+	if err := p.Scan(&command); err != nil {
+		return p, err
+	}
+
+	return t.server.Marshal(protocol.ChatServerbound, "/"+command)
+}
+
+// windowClick supplies the state ID and the changed slots introduced in 1.17 and 1.17.1,
+// and maps the items. The server answers an inaccurate prediction by resending the window
+// content.
+func windowClick(t *Translator, name protocol.Name, p pk.Packet) (pk.Packet, error) {
+	var (
+		windowID pk.UnsignedByte
+		stateID  = t.lastStateID()
+		index    pk.Short
+		button   pk.Byte
+		action   pk.Short
+		mode     pk.VarInt
+		count    pk.VarInt
+		changed  []changedSlot
+		carried  slot
+	)
+
+	var err error
+	switch {
+	case t.client.Protocol >= 756:
+		err = p.Scan(&windowID, &stateID, &index, &button, &mode, &count, pk.Ary{Len: &count, Ary: &changed}, &carried)
+	case t.client.Protocol == 755:
+		err = p.Scan(&windowID, &index, &button, &mode, &count, pk.Ary{Len: &count, Ary: &changed}, &carried)
+	default:
+		err = p.Scan(&windowID, &index, &button, &action, &mode)
+	}
+	if err != nil {
+		return p, err
+	}
+
+	for i := range changed {
+		changed[i].Item = t.items.toServer(changed[i].Item)
+	}
+
+	return t.server.Marshal(
+		name,
+		windowID, stateID, index, button, mode,
+		pk.VarInt(len(changed)), pk.Ary{Ary: changed}, t.items.toServer(carried),
+	)
+}
+
+// setCreativeSlot maps the item. An item missing on the server is dropped with the
+// packet, an empty slot would clear the slot on the server.
+func setCreativeSlot(t *Translator, name protocol.Name, p pk.Packet) (pk.Packet, error) {
+	var (
+		index pk.Short
+		item  slot
+	)
+	if err := p.Scan(&index, &item); err != nil {
+		return p, err
+	}
+
+	mapped := t.items.toServer(item)
+	if item.Present && !mapped.Present {
+		return p, fmt.Errorf("item %d of %v is unknown to %v", item.Item, t.client, t.server)
+	}
+
+	return t.server.Marshal(name, index, mapped)
+}
@@ -0,0 +1,63 @@
+package translate
+
+import (
+	"io"
+
+	"github.com/Tnze/go-mc/nbt"
+	pk "github.com/Tnze/go-mc/net/packet"
+)
+
+// slot is an item stack as sent since 1.13.2, the NBT is kept in its binary form.
+type slot struct {
+	Present pk.Boolean
+	Item    pk.VarInt
+	Count   pk.Byte
+	Tag     nbt.RawMessage
+}
+
+func (s *slot) ReadFrom(r io.Reader) (n int64, err error) {
+	nn, err := s.Present.ReadFrom(r)
+	n += nn
+	if err != nil || !s.Present {
+		return n, err
+	}
+
+	nn, err = pk.Tuple{&s.Item, &s.Count, pk.NBT(&s.Tag)}.ReadFrom(r)
+	return n + nn, err
+}
+
+func (s slot) WriteTo(w io.Writer) (n int64, err error) {
+	nn, err := s.Present.WriteTo(w)
+	n += nn
+	if err != nil || !s.Present {
+		return n, err
+	}
+
+	nn, err = pk.Tuple{s.Item, s.Count}.WriteTo(w)
+	n += nn
+	if err != nil {
+		return n, err
+	}
+
+	if s.Tag.Type == nbt.TagEnd {
+		nn, err := w.Write([]byte{nbt.TagEnd})
+		return n + int64(nn), err
+	}
+
+	nn, err = pk.NBT(s.Tag).WriteTo(w)
+	return n + nn, err
+}
+
+// changedSlot is an entry of the changed slots array of Click Window.
+type changedSlot struct {
+	Index pk.Short
+	Item  slot
+}
+
+func (c *changedSlot) ReadFrom(r io.Reader) (int64, error) {
+	return pk.Tuple{&c.Index, &c.Item}.ReadFrom(r)
+}
+
+func (c changedSlot) WriteTo(w io.Writer) (int64, error) {
+	return pk.Tuple{c.Index, c.Item}.WriteTo(w)
+}
@@ -0,0 +1,207 @@
+package translate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/OCharnyshevich/proxycraft/proxy/log"
+	"github.com/OCharnyshevich/proxycraft/proxy/network/protocol"
+	pk "github.com/Tnze/go-mc/net/packet"
+)
+
+// converter turns a packet of one version into the matching packet of the other side.
+type converter func(t *Translator, name protocol.Name, p pk.Packet) (pk.Packet, error)
+
+// errIgnored marks packets which have no counterpart on the other side and are dropped quietly.
+var errIgnored = errors.New("packet has no counterpart")
+
+var errUntranslatable = errors.New("no translation rule")
+
+// Translator converts packets between the protocol version of a client and the
+// version spoken by the backend server.
+type Translator struct {
+	client *protocol.Version
+	server *protocol.Version
+	logger *log.Logging
+
+	stateID int32 // last window state ID sent by the server
+	items   *itemMapping
+
+	mu         sync.Mutex
+	dimensions []interface{} // dimension types of the last registry codec
+	reported   map[protocol.Name]bool
+}
+
+// New returns a Translator for a client joining the server, or nil when both
+// sides speak the same version and packets can be passed through as is.
+func New(client, server *protocol.Version, logger *log.Logging) (*Translator, error) {
+	if client.Protocol == server.Protocol {
+		return nil, nil
+	}
+
+	if server.Protocol != 757 && server.Protocol != 758 {
+		return nil, fmt.Errorf("translation is only available for 1.18 backends, not %v", server)
+	}
+
+	t := &Translator{
+		client:   client,
+		server:   server,
+		logger:   logger,
+		items:    newItemMapping(client, server),
+		reported: make(map[protocol.Name]bool),
+	}
+	if t.items != nil && !t.items.known {
+		logger.WarnF("The item IDs of %v are unknown, items are not shown to the player", client)
+	}
+	return t, nil
+}
+
+// Clientbound converts a play packet sent by the server, false means the packet has to be dropped.
+func (t *Translator) Clientbound(p pk.Packet) (pk.Packet, bool) {
+	name := t.server.Name(protocol.Clientbound, p.ID)
+	return t.convert(clientbound, protocol.Clientbound, name, p)
+}
+
+// Serverbound converts a play packet sent by the client, false means the packet has to be dropped.
+func (t *Translator) Serverbound(p pk.Packet) (pk.Packet, bool) {
+	name := t.client.Name(protocol.Serverbound, p.ID)
+	return t.convert(serverbound, protocol.Serverbound, name, p)
+}
+
+func (t *Translator) convert(rules map[protocol.Name]converter, d protocol.Direction, name protocol.Name, p pk.Packet) (pk.Packet, bool) {
+	var err error
+
+	if rule, ok := rules[name]; ok {
+		if p, err = rule(t, name, p); err == nil {
+			return p, true
+		}
+	} else if t.client.SharesTables(t.server) {
+		// the layouts only differ for the packets with a rule
+		return p, true
+	} else {
+		err = errUntranslatable
+	}
+
+	if !errors.Is(err, errIgnored) {
+		t.report(d, name, p.ID, err)
+	}
+
+	return p, false
+}
+
+// report logs the first drop of every packet and keeps the repeated ones at Data level.
+func (t *Translator) report(d protocol.Direction, name protocol.Name, id int32, err error) {
+	t.mu.Lock()
+	seen := t.reported[name]
+	t.reported[name] = true
+	t.mu.Unlock()
+
+	if seen {
+		t.logger.DataF("Dropped %s packet %s (0x%X): %v", d, name, id, err)
+		return
+	}
+
+	t.logger.WarnF("Dropped %s packet %s (0x%X) between %v and %v: %v", d, name, id, t.client, t.server, err)
+}
+
+// Handshake points the client's handshake to the server's protocol version.
+func (t *Translator) Handshake(p pk.Packet) (pk.Packet, error) {
+	h, err := protocol.ReadHandshake(p)
+	if err != nil {
+		return p, err
+	}
+
+	return pk.Marshal(
+		protocol.HandshakeID,
+		pk.VarInt(t.server.Protocol),
+		pk.String(h.Address),
+		pk.UnsignedShort(h.Port),
+		pk.VarInt(h.NextState),
+	), nil
+}
+
+// LoginStart strips the chat signing data added in 1.19, a 1.18 server only reads the name.
+func (t *Translator) LoginStart(p pk.Packet) (pk.Packet, error) {
+	var name pk.String
+	if err := p.Scan(&name); err != nil {
+		return p, err
+	}
+
+	return pk.Marshal(p.ID, name), nil
+}
+
+// LoginSuccess appends the empty property list a 1.19 client expects.
+func (t *Translator) LoginSuccess(p pk.Packet) (pk.Packet, error) {
+	if t.client.Protocol < 759 {
+		return p, nil
+	}
+
+	var (
+		id   pk.UUID
+		name pk.String
+	)
+	if err := p.Scan(&id, &name); err != nil {
+		return p, err
+	}
+
+	return pk.Marshal(p.ID, id, name, pk.VarInt(0)), nil
+}
+
+// Status reports the client's own protocol in the server list ping, so the
+// client shows the server as compatible.
+func (t *Translator) Status(p pk.Packet) (pk.Packet, error) {
+	var response pk.String
+	if err := p.Scan(&response); err != nil {
+		return p, err
+	}
+
+	var status map[string]interface{}
+	if err := json.Unmarshal([]byte(response), &status); err != nil {
+		return p, err
+	}
+
+	if version, ok := status["version"].(map[string]interface{}); ok {
+		version["protocol"] = t.client.Protocol
+	}
+
+	data, err := json.Marshal(status)
+	if err != nil {
+		return p, err
+	}
+
+	return pk.Marshal(p.ID, pk.String(data)), nil
+}
+
+func (t *Translator) setStateID(id pk.VarInt) {
+	atomic.StoreInt32(&t.stateID, int32(id))
+}
+
+func (t *Translator) lastStateID() pk.VarInt {
+	return pk.VarInt(atomic.LoadInt32(&t.stateID))
+}
+
+// toClient only rewrites the packet ID, the layout is the same for both versions.
+func toClient(t *Translator, name protocol.Name, p pk.Packet) (pk.Packet, error) {
+	return remap(t.client, name, p)
+}
+
+// toServer only rewrites the packet ID, the layout is the same for both versions.
+func toServer(t *Translator, name protocol.Name, p pk.Packet) (pk.Packet, error) {
+	return remap(t.server, name, p)
+}
+
+func remap(v *protocol.Version, name protocol.Name, p pk.Packet) (pk.Packet, error) {
+	id, ok := v.ID(name)
+	if !ok {
+		return p, protocol.UnknownPacketError{Name: name, Protocol: v.Protocol}
+	}
+
+	return pk.Packet{ID: id, Data: p.Data}, nil
+}
+
+func ignore(_ *Translator, _ protocol.Name, p pk.Packet) (pk.Packet, error) {
+	return p, errIgnored
+}
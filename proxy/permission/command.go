@@ -0,0 +1,202 @@
+package permission
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/OCharnyshevich/proxycraft/proxy/command"
+	"github.com/google/uuid"
+)
+
+// Lookup resolves a player name or UUID to the UUID and the current name of the player.
+type Lookup func(player string) (uuid.UUID, string, error)
+
+const usage = "perm groups | perm default [group] | " +
+	"perm user <player> [info | check <node> | set <node> [true|false] | unset <node> | group add|remove <group>] | " +
+	"perm group <group> [info | create | delete | set <node> [true|false] | unset <node> | parent add|remove <group>]"
+
+// Command builds the perm command editing the store.
+func (s *Store) Command(lookup Lookup, players command.Completer) command.Command {
+	return command.Command{
+		Name:        "perm",
+		Aliases:     []string{"permissions"},
+		Description: "shows and edits the permission groups and players, perm without arguments shows the usage",
+		Complete: func(sender command.Sender, args []string, prefix string) []string {
+			return s.complete(sender, args, prefix, players)
+		},
+		Run: func(sender command.Sender, args command.Args) error {
+			return s.run(sender, args.Raw, lookup)
+		},
+	}
+}
+
+func (s *Store) run(sender command.Sender, args []string, lookup Lookup) error {
+	if len(args) == 0 {
+		return errors.New("usage: " + usage)
+	}
+
+	switch args[0] {
+	case "groups":
+		sender.SendMessage("groups: ", strings.Join(s.Groups(), ", "), ", default: ", s.Default())
+		return nil
+	case "default":
+		if len(args) < 2 {
+			sender.SendMessage("default group: ", s.Default())
+			return nil
+		}
+		if err := s.SetDefault(args[1]); err != nil {
+			return err
+		}
+		sender.SendMessage("default group set to ", args[1])
+		return nil
+	case "user":
+		if len(args) < 2 {
+			return errors.New("usage: " + usage)
+		}
+		id, name, err := lookup(args[1])
+		if err != nil {
+			return err
+		}
+		return s.runUser(sender, id, name, args[2:])
+	case "group":
+		if len(args) < 2 {
+			return errors.New("usage: " + usage)
+		}
+		return s.runGroup(sender, args[1], args[2:])
+	}
+
+	return errors.New("usage: " + usage)
+}
+
+func (s *Store) runUser(sender command.Sender, id uuid.UUID, name string, args []string) error {
+	if len(args) == 0 || args[0] == "info" {
+		u, _ := s.User(id)
+		groups := u.Groups
+		if len(groups) == 0 {
+			groups = []string{s.Default() + " (default)"}
+		}
+		sender.SendMessage(name, " (", id, ") groups: ", strings.Join(groups, ", "))
+		sendNodes(sender, u.Permissions)
+		return nil
+	}
+
+	var err error
+	switch {
+	case args[0] == "check" && len(args) == 2:
+		sender.SendMessage(name, " ", args[1], ": ", s.Has(id, args[1]))
+		return nil
+	case args[0] == "set" && (len(args) == 2 || len(args) == 3):
+		var value bool
+		if value, err = parseValue(args[2:]); err == nil {
+			err = s.SetUserPermission(id, name, args[1], value)
+		}
+	case args[0] == "unset" && len(args) == 2:
+		err = s.UnsetUserPermission(id, args[1])
+	case args[0] == "group" && len(args) == 3 && args[1] == "add":
+		err = s.AddUserGroup(id, name, args[2])
+	case args[0] == "group" && len(args) == 3 && args[1] == "remove":
+		err = s.RemoveUserGroup(id, args[2])
+	default:
+		return errors.New("usage: " + usage)
+	}
+
+	if err != nil {
+		return err
+	}
+	sender.SendMessage("updated ", name)
+	return nil
+}
+
+func (s *Store) runGroup(sender command.Sender, group string, args []string) error {
+	if len(args) == 0 || args[0] == "info" {
+		g, ok := s.Group(group)
+		if !ok {
+			return fmt.Errorf("unknown group %q", group)
+		}
+		sender.SendMessage(group, " parents: ", strings.Join(g.Parents, ", "))
+		sendNodes(sender, g.Permissions)
+		return nil
+	}
+
+	var err error
+	switch {
+	case args[0] == "create" && len(args) == 1:
+		err = s.CreateGroup(group)
+	case args[0] == "delete" && len(args) == 1:
+		err = s.DeleteGroup(group)
+	case args[0] == "set" && (len(args) == 2 || len(args) == 3):
+		var value bool
+		if value, err = parseValue(args[2:]); err == nil {
+			err = s.SetGroupPermission(group, args[1], value)
+		}
+	case args[0] == "unset" && len(args) == 2:
+		err = s.UnsetGroupPermission(group, args[1])
+	case args[0] == "parent" && len(args) == 3 && args[1] == "add":
+		err = s.AddParent(group, args[2])
+	case args[0] == "parent" && len(args) == 3 && args[1] == "remove":
+		err = s.RemoveParent(group, args[2])
+	default:
+		return errors.New("usage: " + usage)
+	}
+
+	if err != nil {
+		return err
+	}
+	sender.SendMessage("updated group ", group)
+	return nil
+}
+
+func (s *Store) complete(sender command.Sender, args []string, prefix string, players command.Completer) []string {
+	switch len(args) {
+	case 0:
+		return []string{"groups", "default", "user", "group"}
+	case 1:
+		switch args[0] {
+		case "user":
+			return players(sender, args, prefix)
+		case "group", "default":
+			return s.Groups()
+		}
+	case 2:
+		switch args[0] {
+		case "user":
+			return []string{"info", "check", "set", "unset", "group"}
+		case "group":
+			return []string{"info", "create", "delete", "set", "unset", "parent"}
+		}
+	case 3:
+		if args[2] == "group" || args[2] == "parent" {
+			return []string{"add", "remove"}
+		}
+	case 4:
+		switch args[2] {
+		case "set":
+			return []string{"true", "false"}
+		case "group", "parent":
+			return s.Groups()
+		}
+	}
+	return nil
+}
+
+func parseValue(args []string) (bool, error) {
+	if len(args) == 0 {
+		return true, nil
+	}
+	return strconv.ParseBool(args[0])
+}
+
+func sendNodes(sender command.Sender, nodes map[string]bool) {
+	keys := make([]string, 0, len(nodes))
+	for node := range nodes {
+		keys = append(keys, node)
+	}
+	sort.Strings(keys)
+
+	for _, node := range keys {
+		sender.SendMessage("  ", node, ": ", nodes[node])
+	}
+}
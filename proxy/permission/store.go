@@ -0,0 +1,468 @@
+package permission
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/OCharnyshevich/proxycraft/proxy/command"
+	"github.com/OCharnyshevich/proxycraft/proxy/helper"
+	"github.com/google/uuid"
+)
+
+type Group struct {
+	Parents []string `json:"parents,omitempty"`
+	// Permissions maps nodes like "proxy.command.kick" or "proxy.command.*" to
+	// granted (true) or denied (false).
+	Permissions map[string]bool `json:"permissions"`
+}
+
+type User struct {
+	// Name is the last known name of the player, only for readability of the file.
+	Name        string          `json:"name,omitempty"`
+	Groups      []string        `json:"groups,omitempty"`
+	Permissions map[string]bool `json:"permissions,omitempty"`
+}
+
+type data struct {
+	Default string              `json:"default"`
+	Groups  map[string]*Group   `json:"groups"`
+	Users   map[uuid.UUID]*User `json:"users"`
+}
+
+// Store holds the groups and users, and saves every change to its file.
+type Store struct {
+	path string
+
+	mu   sync.RWMutex
+	data data
+}
+
+// Open loads the permissions file, a missing file is created with a default group
+// allowed to use help and list, and an admin group holding every permission.
+func Open(path string) (*Store, error) {
+	s := &Store{
+		path: path,
+		data: data{
+			Default: "default",
+			Groups: map[string]*Group{
+				"default": {Permissions: map[string]bool{"proxy.command.help": true, "proxy.command.list": true}},
+				"admin":   {Parents: []string{"default"}, Permissions: map[string]bool{"*": true}},
+			},
+			Users: make(map[uuid.UUID]*User),
+		},
+	}
+
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, s.save()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(content, &s.data); err != nil {
+		return nil, fmt.Errorf("invalid permissions file %s: %v", path, err)
+	}
+	if s.data.Groups == nil {
+		s.data.Groups = make(map[string]*Group)
+	}
+	if s.data.Users == nil {
+		s.data.Users = make(map[uuid.UUID]*User)
+	}
+
+	// the nodes are looked up in lower case, a hand written file may have others
+	for _, g := range s.data.Groups {
+		g.Permissions = lowerNodes(g.Permissions)
+	}
+	for _, u := range s.data.Users {
+		u.Permissions = lowerNodes(u.Permissions)
+	}
+
+	return s, nil
+}
+
+// lowerNodes turns the nodes to lower case, a node written in lower case wins over the
+// same node written otherwise.
+func lowerNodes(nodes map[string]bool) map[string]bool {
+	if nodes == nil {
+		return nil
+	}
+
+	lower := make(map[string]bool, len(nodes))
+	for node, v := range nodes {
+		if node == strings.ToLower(node) {
+			lower[node] = v
+		}
+	}
+	for node, v := range nodes {
+		if _, ok := lower[strings.ToLower(node)]; !ok {
+			lower[strings.ToLower(node)] = v
+		}
+	}
+	return lower
+}
+
+// save writes the file through a temporary file, so a crash never leaves half of it.
+func (s *Store) save() error {
+	content, err := json.MarshalIndent(s.data, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if dir := filepath.Dir(s.path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, content, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// Allowed is the permission check of the command registry. Players are checked by their UUID,
+// the console and every other sender which is not a player hold all permissions.
+func (s *Store) Allowed(sender command.Sender, node string) bool {
+	session, ok := sender.(helper.Sessionable)
+	if !ok {
+		return true
+	}
+
+	return s.Has(session.UUID(), node)
+}
+
+// Has resolves a node for a player: the player's own nodes win over the nodes of the
+// groups, which win over their parents. Players without a group are in the default group.
+func (s *Store) Has(id uuid.UUID, node string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	node = strings.ToLower(node)
+
+	groups := []string{s.data.Default}
+	if u := s.data.Users[id]; u != nil {
+		if v, ok := match(u.Permissions, node); ok {
+			return v
+		}
+		if len(u.Groups) > 0 {
+			groups = u.Groups
+		}
+	}
+
+	visited := make(map[string]bool)
+	for _, g := range groups {
+		if v, ok := s.resolve(g, node, visited); ok {
+			return v
+		}
+	}
+
+	return false
+}
+
+func (s *Store) resolve(group, node string, visited map[string]bool) (bool, bool) {
+	if visited[group] {
+		return false, false
+	}
+	visited[group] = true
+
+	g := s.data.Groups[group]
+	if g == nil {
+		return false, false
+	}
+
+	if v, ok := match(g.Permissions, node); ok {
+		return v, true
+	}
+
+	for _, parent := range g.Parents {
+		if v, ok := s.resolve(parent, node, visited); ok {
+			return v, true
+		}
+	}
+
+	return false, false
+}
+
+// match looks the node up, falling back to wildcards from the most specific one,
+// "a.b.c" is matched by "a.b.c", "a.b.*", "a.*" and "*".
+func match(permissions map[string]bool, node string) (bool, bool) {
+	if v, ok := permissions[node]; ok {
+		return v, true
+	}
+
+	parts := strings.Split(node, ".")
+	for i := len(parts) - 1; i >= 0; i-- {
+		wildcard := strings.Join(append(parts[:i:i], "*"), ".")
+		if v, ok := permissions[wildcard]; ok {
+			return v, true
+		}
+	}
+
+	return false, false
+}
+
+// user returns the user, creating it on the first change.
+func (s *Store) user(id uuid.UUID, name string) *User {
+	u := s.data.Users[id]
+	if u == nil {
+		u = &User{}
+		s.data.Users[id] = u
+	}
+	if name != "" {
+		u.Name = name
+	}
+	if u.Permissions == nil {
+		u.Permissions = make(map[string]bool)
+	}
+	return u
+}
+
+func (s *Store) group(name string) (*Group, error) {
+	g := s.data.Groups[name]
+	if g == nil {
+		return nil, fmt.Errorf("unknown group %q", name)
+	}
+	return g, nil
+}
+
+// update applies a change and saves the file, the change is undone when either fails
+// so the groups and users always match the file.
+func (s *Store) update(change func() error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot := s.data.copy()
+	err := change()
+	if err == nil {
+		err = s.save()
+	}
+	if err != nil {
+		s.data = snapshot
+	}
+	return err
+}
+
+func (s *Store) SetUserPermission(id uuid.UUID, name, node string, value bool) error {
+	return s.update(func() error {
+		s.user(id, name).Permissions[strings.ToLower(node)] = value
+		return nil
+	})
+}
+
+func (s *Store) UnsetUserPermission(id uuid.UUID, node string) error {
+	return s.update(func() error {
+		if u := s.data.Users[id]; u != nil {
+			delete(u.Permissions, strings.ToLower(node))
+		}
+		return nil
+	})
+}
+
+func (s *Store) AddUserGroup(id uuid.UUID, name, group string) error {
+	return s.update(func() error {
+		if _, err := s.group(group); err != nil {
+			return err
+		}
+		u := s.user(id, name)
+		u.Groups = appendUnique(u.Groups, group)
+		return nil
+	})
+}
+
+func (s *Store) RemoveUserGroup(id uuid.UUID, group string) error {
+	return s.update(func() error {
+		if u := s.data.Users[id]; u != nil {
+			u.Groups = remove(u.Groups, group)
+		}
+		return nil
+	})
+}
+
+func (s *Store) CreateGroup(name string) error {
+	return s.update(func() error {
+		if s.data.Groups[name] != nil {
+			return fmt.Errorf("group %q already exists", name)
+		}
+		s.data.Groups[name] = &Group{Permissions: make(map[string]bool)}
+		return nil
+	})
+}
+
+func (s *Store) DeleteGroup(name string) error {
+	return s.update(func() error {
+		if name == s.data.Default {
+			return errors.New("the default group can not be deleted")
+		}
+		if _, err := s.group(name); err != nil {
+			return err
+		}
+
+		delete(s.data.Groups, name)
+		for _, g := range s.data.Groups {
+			g.Parents = remove(g.Parents, name)
+		}
+		for _, u := range s.data.Users {
+			u.Groups = remove(u.Groups, name)
+		}
+		return nil
+	})
+}
+
+func (s *Store) SetGroupPermission(group, node string, value bool) error {
+	return s.update(func() error {
+		g, err := s.group(group)
+		if err != nil {
+			return err
+		}
+		if g.Permissions == nil {
+			g.Permissions = make(map[string]bool)
+		}
+		g.Permissions[strings.ToLower(node)] = value
+		return nil
+	})
+}
+
+func (s *Store) UnsetGroupPermission(group, node string) error {
+	return s.update(func() error {
+		g, err := s.group(group)
+		if err != nil {
+			return err
+		}
+		delete(g.Permissions, strings.ToLower(node))
+		return nil
+	})
+}
+
+func (s *Store) AddParent(group, parent string) error {
+	return s.update(func() error {
+		g, err := s.group(group)
+		if err != nil {
+			return err
+		}
+		if _, err := s.group(parent); err != nil {
+			return err
+		}
+		if group == parent {
+			return errors.New("a group can not inherit from itself")
+		}
+		g.Parents = appendUnique(g.Parents, parent)
+		return nil
+	})
+}
+
+func (s *Store) RemoveParent(group, parent string) error {
+	return s.update(func() error {
+		g, err := s.group(group)
+		if err != nil {
+			return err
+		}
+		g.Parents = remove(g.Parents, parent)
+		return nil
+	})
+}
+
+// SetDefault changes the group of players without a group.
+func (s *Store) SetDefault(group string) error {
+	return s.update(func() error {
+		if _, err := s.group(group); err != nil {
+			return err
+		}
+		s.data.Default = group
+		return nil
+	})
+}
+
+func (s *Store) Default() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.data.Default
+}
+
+// Groups lists the group names.
+func (s *Store) Groups() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	names := make([]string, 0, len(s.data.Groups))
+	for name := range s.data.Groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Group returns a copy of the group.
+func (s *Store) Group(name string) (Group, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	g := s.data.Groups[name]
+	if g == nil {
+		return Group{}, false
+	}
+	return Group{Parents: append([]string(nil), g.Parents...), Permissions: copyNodes(g.Permissions)}, true
+}
+
+// User returns a copy of the user.
+func (s *Store) User(id uuid.UUID) (User, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	u := s.data.Users[id]
+	if u == nil {
+		return User{}, false
+	}
+	return User{Name: u.Name, Groups: append([]string(nil), u.Groups...), Permissions: copyNodes(u.Permissions)}, true
+}
+
+// copy is a deep copy of the groups and users.
+func (d data) copy() data {
+	c := data{
+		Default: d.Default,
+		Groups:  make(map[string]*Group, len(d.Groups)),
+		Users:   make(map[uuid.UUID]*User, len(d.Users)),
+	}
+	for name, g := range d.Groups {
+		c.Groups[name] = &Group{Parents: append([]string(nil), g.Parents...), Permissions: copyNodes(g.Permissions)}
+	}
+	for id, u := range d.Users {
+		c.Users[id] = &User{Name: u.Name, Groups: append([]string(nil), u.Groups...), Permissions: copyNodes(u.Permissions)}
+	}
+	return c
+}
+
+func copyNodes(nodes map[string]bool) map[string]bool {
+	c := make(map[string]bool, len(nodes))
+	for k, v := range nodes {
+		c[k] = v
+	}
+	return c
+}
+
+func appendUnique(list []string, value string) []string {
+	for _, v := range list {
+		if v == value {
+			return list
+		}
+	}
+	return append(list, value)
+}
+
+func remove(list []string, value string) []string {
+	kept := list[:0]
+	for _, v := range list {
+		if v != value {
+			kept = append(kept, v)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,222 @@
+package permission
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+var (
+	steve = uuid.MustParse("069a79f4-44e9-4726-a5be-fca90e38aaf5")
+	alex  = uuid.MustParse("61699b2e-d327-4a01-9f1e-0ea8c3f06bc6")
+	herob = uuid.MustParse("f84c6a79-0a4e-45e0-879b-cd49ebd4c4e2")
+	notch = uuid.MustParse("069a79f4-44e9-4726-a5be-fca90e38aaf6")
+)
+
+const testData = `{
+  "default": "default",
+  "groups": {
+    "default": {"permissions": {"proxy.command.help": true, "proxy.command.list": true}},
+    "mod": {"parents": ["default"], "permissions": {"proxy.command.kick": true, "proxy.command.list": false}},
+    "admin": {"parents": ["mod"], "permissions": {"*": true, "proxy.*": false, "proxy.command.*": true}},
+    "left": {"parents": ["right"], "permissions": {"loop.left": true}},
+    "right": {"parents": ["left"], "permissions": {"loop.right": true}}
+  },
+  "users": {
+    "069a79f4-44e9-4726-a5be-fca90e38aaf5": {"name": "Steve", "groups": ["mod"], "permissions": {"Proxy.Command.Kick": false}},
+    "61699b2e-d327-4a01-9f1e-0ea8c3f06bc6": {"name": "Alex", "groups": ["admin"]},
+    "069a79f4-44e9-4726-a5be-fca90e38aaf6": {"name": "Notch", "groups": ["left"]},
+    "f84c6a79-0a4e-45e0-879b-cd49ebd4c4e2": {"name": "Herobrine", "groups": ["gone"]}
+  }
+}`
+
+func open(t *testing.T, content string) *Store {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "permissions.json")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestHas(t *testing.T) {
+	s := open(t, testData)
+
+	tests := []struct {
+		name string
+		id   uuid.UUID
+		node string
+		want bool
+	}{
+		{"default group", uuid.New(), "proxy.command.help", true},
+		{"not granted", uuid.New(), "proxy.command.kick", false},
+		{"group node", alex, "proxy.command.kick", true},
+		{"user node over group", steve, "proxy.command.kick", false},
+		{"user node lowered on load", steve, "PROXY.COMMAND.KICK", false},
+		{"group over parent", steve, "proxy.command.list", false},
+		{"parent", steve, "proxy.command.help", true},
+		{"a.b.* over a.*", alex, "proxy.command.stop", true},
+		{"a.* over *", alex, "proxy.plugin.reload", false},
+		{"*", alex, "anything.else", true},
+		{"exact over wildcard", alex, "proxy.command.list", true},
+		{"cycle", notch, "loop.right", true},
+		{"cycle without the node", notch, "proxy.command.help", false},
+		{"missing group", herob, "proxy.command.help", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := s.Has(tt.id, tt.node); got != tt.want {
+				t.Errorf("Has(%s, %q) = %v, want %v", tt.id, tt.node, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHasCycleOnly(t *testing.T) {
+	s := open(t, testData)
+	if err := s.SetDefault("left"); err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Has(uuid.New(), "proxy.command.help") {
+		t.Error("a node outside the cycle is granted")
+	}
+	if !s.Has(uuid.New(), "loop.right") {
+		t.Error("the node of the parent in the cycle is not granted")
+	}
+}
+
+func TestDeleteGroup(t *testing.T) {
+	s := open(t, testData)
+
+	if err := s.DeleteGroup("default"); err == nil {
+		t.Error("the default group was deleted")
+	}
+	if err := s.DeleteGroup("mod"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := s.Group("mod"); ok {
+		t.Error("the group is still there")
+	}
+	if g, _ := s.Group("admin"); len(g.Parents) != 0 {
+		t.Errorf("admin keeps the parents %v", g.Parents)
+	}
+	if u, _ := s.User(steve); len(u.Groups) != 0 {
+		t.Errorf("Steve keeps the groups %v", u.Groups)
+	}
+	// without a group Steve is in the default group again
+	if !s.Has(steve, "proxy.command.list") {
+		t.Error("the default group does not apply")
+	}
+}
+
+func TestLowerNodes(t *testing.T) {
+	tests := []struct {
+		name  string
+		nodes map[string]bool
+		want  map[string]bool
+	}{
+		{"nil", nil, nil},
+		{"lowered", map[string]bool{"A.B": true}, map[string]bool{"a.b": true}},
+		{"lower case wins", map[string]bool{"A.B": true, "a.b": false}, map[string]bool{"a.b": false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := lowerNodes(tt.nodes)
+			if (got == nil) != (tt.want == nil) || len(got) != len(tt.want) {
+				t.Fatalf("lowerNodes(%v) = %v, want %v", tt.nodes, got, tt.want)
+			}
+			for node, v := range tt.want {
+				if got[node] != v {
+					t.Errorf("lowerNodes(%v) = %v, want %v", tt.nodes, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestUpdateRollback(t *testing.T) {
+	s := open(t, testData)
+	before, err := os.ReadFile(s.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the temporary file being a directory makes every save fail
+	if err := os.Mkdir(s.path+".tmp", 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	changes := []struct {
+		name   string
+		change func() error
+	}{
+		{"user permission", func() error { return s.SetUserPermission(steve, "Steve", "proxy.command.kick", true) }},
+		{"new user", func() error { return s.SetUserPermission(uuid.New(), "Alex", "proxy.command.stop", true) }},
+		{"user group", func() error { return s.AddUserGroup(steve, "Steve", "admin") }},
+		{"group permission", func() error { return s.SetGroupPermission("default", "proxy.command.stop", true) }},
+		{"create group", func() error { return s.CreateGroup("vip") }},
+		{"delete group", func() error { return s.DeleteGroup("mod") }},
+		{"parent", func() error { return s.RemoveParent("admin", "mod") }},
+		{"default", func() error { return s.SetDefault("admin") }},
+	}
+	for _, c := range changes {
+		t.Run(c.name, func(t *testing.T) {
+			if err := c.change(); err == nil {
+				t.Fatal("the save did not fail")
+			}
+		})
+	}
+
+	if s.Has(steve, "proxy.command.kick") || s.Has(steve, "proxy.command.stop") {
+		t.Error("a failed change is kept")
+	}
+	if _, ok := s.Group("vip"); ok {
+		t.Error("a failed group is kept")
+	}
+	if g, ok := s.Group("mod"); !ok || len(g.Permissions) != 2 {
+		t.Errorf("the deleted group is not restored: %v", g)
+	}
+	if g, _ := s.Group("admin"); len(g.Parents) != 1 {
+		t.Errorf("the removed parent is not restored: %v", g.Parents)
+	}
+	if s.Default() != "default" {
+		t.Errorf("the default group is %q", s.Default())
+	}
+
+	after, err := os.ReadFile(s.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(after) != string(before) {
+		t.Error("the file changed")
+	}
+}
+
+func TestUpdateRollbackOnError(t *testing.T) {
+	s := open(t, testData)
+
+	err := s.update(func() error {
+		s.user(herob, "Herobrine").Permissions["proxy.command.stop"] = true
+		s.data.Groups["default"].Parents = append(s.data.Groups["default"].Parents, "admin")
+		return os.ErrInvalid
+	})
+	if err != os.ErrInvalid {
+		t.Fatalf("update = %v", err)
+	}
+
+	if s.Has(herob, "proxy.command.stop") {
+		t.Error("the user change is kept")
+	}
+	if g, _ := s.Group("default"); len(g.Parents) != 0 {
+		t.Errorf("the group change is kept: %v", g.Parents)
+	}
+}
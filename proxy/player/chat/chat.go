@@ -3,6 +3,7 @@ package chat
 import (
 	"fmt"
 	"strings"
+	"unicode"
 
 	"github.com/fatih/color"
 )
@@ -93,6 +94,7 @@ var codeToCode = map[ChatColor]*ColorCode{
 	Green: {
 		Chat: `§a`,
 		Motd: `\u00A7a`,
+		Json: `green`,
 		Dec:  `5635925`,
 		Hex:  `55FF55`,
 	},
@@ -309,32 +311,42 @@ func (code *ChatColor) On(text string) string {
 	return fmt.Sprintf("%p%s%v", code, text, Reset)
 }
 
+// Translate converts the & codes of the text to § codes, &#RRGGBB to §x§R§R§G§G§B§B.
 func Translate(text string) string {
-
 	build := strings.Builder{}
 	chars := []rune(text)
 
 	for i := 0; i < len(chars); i++ {
-
 		r := chars[i]
-
 		if r != ColorAChar || i+1 >= len(chars) {
 			build.WriteRune(r)
-		} else {
-			c := codeToCode[charToCode[chars[i+1]]]
+			continue
+		}
 
-			if c == nil {
-				build.WriteRune(r)
-			} else {
-				build.WriteString(c.Chat)
-				i++
+		next := unicode.ToLower(chars[i+1])
+		if next == '#' && i+8 <= len(chars) {
+			if c, err := ParseRGB(string(chars[i+2 : i+8])); err == nil {
+				build.WriteString(c.Legacy())
+				i += 7
+				continue
 			}
 		}
+
+		if _, ok := charToCode[next]; ok || next == HexChar {
+			build.WriteRune(ColorCChar)
+			build.WriteRune(next)
+			i++
+			continue
+		}
+
+		build.WriteRune(r)
 	}
 
 	return build.String()
 }
 
+// TranslateConsole converts the & and § codes of the text to ANSI escapes. RGB colors
+// are written as 24-bit colors when TrueColor is on, as the nearest legacy color otherwise.
 func TranslateConsole(text string) string {
 	text = Translate(text)
 
@@ -351,8 +363,17 @@ func TranslateConsole(text string) string {
 			continue
 		}
 
-		f, con := codeToForm[charToCode[chars[i+1]]]
-		if !con {
+		var next []color.Attribute
+		if c, size, ok := hexAt(chars, i); ok {
+			if TrueColor {
+				next = []color.Attribute{38, 2, color.Attribute(c.R), color.Attribute(c.G), color.Attribute(c.B)}
+			} else {
+				next = []color.Attribute{codeToForm[c.Nearest()]}
+			}
+			i += size - 2
+		} else if code, ok := codeOf(chars[i+1]); ok {
+			next = []color.Attribute{codeToForm[code]}
+		} else {
 			temps.WriteRune(r)
 			continue
 		}
@@ -363,11 +384,10 @@ func TranslateConsole(text string) string {
 		}
 
 		i++
-		if f <= color.CrossedOut {
-			forms = append(forms, f)
+		if len(next) == 1 && next[0] <= color.CrossedOut && next[0] != color.Reset {
+			forms = append(forms, next[0])
 		} else {
-			forms = make([]color.Attribute, 0)
-			forms = append(forms, f)
+			forms = append(make([]color.Attribute, 0), next...)
 		}
 	}
 
@@ -377,3 +397,27 @@ func TranslateConsole(text string) string {
 
 	return build.String()
 }
+
+// Strip removes the color and format codes, both the & and the § forms.
+func Strip(text string) string {
+	text = Translate(text)
+
+	build := strings.Builder{}
+	chars := []rune(text)
+
+	for i := 0; i < len(chars); i++ {
+		if chars[i] == ColorCChar && i+1 < len(chars) {
+			if _, size, ok := hexAt(chars, i); ok {
+				i += size - 1
+				continue
+			}
+			if _, ok := codeOf(chars[i+1]); ok {
+				i++
+				continue
+			}
+		}
+		build.WriteRune(chars[i])
+	}
+
+	return build.String()
+}
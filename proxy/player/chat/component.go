@@ -0,0 +1,245 @@
+package chat
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+
+	pk "github.com/Tnze/go-mc/net/packet"
+)
+
+// Component is a JSON text component, the rich text of chat messages, kick reasons
+// and the MOTD. Its content is one of Text, Translate, Score, Selector or Keybind,
+// followed by the Extra components which inherit its style.
+type Component struct {
+	Text string
+	// Translate is a key of the language table, formatted with the With components.
+	Translate string
+	With      []Component
+	Score     *Score
+	// Selector is a target selector like "@p", resolved by the server.
+	Selector string
+	// Keybind is a control like "key.jump", shown as the key bound to it.
+	Keybind string
+	Extra   []Component
+
+	// Color is a color name like "gold" or an RGB color like "#FFAA00", empty
+	// inherits the color of the parent.
+	Color string
+	// Decorations are nil to inherit the ones of the parent.
+	Bold          *bool
+	Italic        *bool
+	Underlined    *bool
+	Strikethrough *bool
+	Obfuscated    *bool
+	// Font is a resource location like "minecraft:uniform", empty for the default font.
+	Font string
+	// Insertion is put into the chat input when the text is shift-clicked.
+	Insertion  string
+	ClickEvent *ClickEvent
+	HoverEvent *HoverEvent
+}
+
+// Score shows the score of a scoreboard entry.
+type Score struct {
+	Name      string `json:"name"`
+	Objective string `json:"objective"`
+	// Value is shown instead of the score when set.
+	Value string `json:"value,omitempty"`
+}
+
+// Click event actions.
+const (
+	OpenURL         = "open_url"
+	RunCommand      = "run_command"
+	SuggestCommand  = "suggest_command"
+	ChangePage      = "change_page"
+	CopyToClipboard = "copy_to_clipboard"
+)
+
+type ClickEvent struct {
+	Action string `json:"action"`
+	Value  string `json:"value"`
+}
+
+// Hover event actions.
+const (
+	ShowText   = "show_text"
+	ShowItem   = "show_item"
+	ShowEntity = "show_entity"
+)
+
+type HoverEvent struct {
+	Action string
+	// Text is the shown component of show_text.
+	Text *Component
+	// Contents is the raw JSON of the other actions, like the item of show_item.
+	Contents json.RawMessage
+}
+
+// Text creates a text component.
+func Text(text string) Component {
+	return Component{Text: text}
+}
+
+// Translatable creates a translate component formatted with the arguments.
+func Translatable(key string, with ...Component) Component {
+	return Component{Translate: key, With: with}
+}
+
+// Append adds the components to the extra ones.
+func (c Component) Append(extra ...Component) Component {
+	c.Extra = append(append([]Component(nil), c.Extra...), extra...)
+	return c
+}
+
+func flag(b bool) *bool {
+	return &b
+}
+
+// jsonComponent is the JSON layout of a Component.
+type jsonComponent struct {
+	Text          *string     `json:"text,omitempty"`
+	Translate     string      `json:"translate,omitempty"`
+	With          []Component `json:"with,omitempty"`
+	Score         *Score      `json:"score,omitempty"`
+	Selector      string      `json:"selector,omitempty"`
+	Keybind       string      `json:"keybind,omitempty"`
+	Color         string      `json:"color,omitempty"`
+	Bold          *bool       `json:"bold,omitempty"`
+	Italic        *bool       `json:"italic,omitempty"`
+	Underlined    *bool       `json:"underlined,omitempty"`
+	Strikethrough *bool       `json:"strikethrough,omitempty"`
+	Obfuscated    *bool       `json:"obfuscated,omitempty"`
+	Font          string      `json:"font,omitempty"`
+	Insertion     string      `json:"insertion,omitempty"`
+	ClickEvent    *ClickEvent `json:"clickEvent,omitempty"`
+	HoverEvent    *HoverEvent `json:"hoverEvent,omitempty"`
+	Extra         []Component `json:"extra,omitempty"`
+}
+
+func (c Component) MarshalJSON() ([]byte, error) {
+	j := jsonComponent{
+		Translate: c.Translate, With: c.With, Score: c.Score, Selector: c.Selector, Keybind: c.Keybind,
+		Color: c.Color, Bold: c.Bold, Italic: c.Italic, Underlined: c.Underlined,
+		Strikethrough: c.Strikethrough, Obfuscated: c.Obfuscated,
+		Font: c.Font, Insertion: c.Insertion, ClickEvent: c.ClickEvent, HoverEvent: c.HoverEvent,
+		Extra: c.Extra,
+	}
+	// a component needs a content, text is the one of an empty component
+	if c.Text != "" || (c.Translate == "" && c.Score == nil && c.Selector == "" && c.Keybind == "") {
+		j.Text = &c.Text
+	}
+	return json.Marshal(j)
+}
+
+// UnmarshalJSON reads a component object, a string as a text component, or an
+// array as the first component followed by the others as its extra.
+func (c *Component) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return errors.New("empty text component")
+	}
+
+	switch data[0] {
+	case '"':
+		*c = Component{}
+		return json.Unmarshal(data, &c.Text)
+	case '[':
+		var list []Component
+		if err := json.Unmarshal(data, &list); err != nil {
+			return err
+		}
+		if len(list) == 0 {
+			return errors.New("empty text component array")
+		}
+		*c = list[0].Append(list[1:]...)
+		return nil
+	case '{':
+	default:
+		// numbers and booleans are shown as text
+		*c = Component{Text: string(data)}
+		return nil
+	}
+
+	var j jsonComponent
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+
+	*c = Component{
+		Translate: j.Translate, With: j.With, Score: j.Score, Selector: j.Selector, Keybind: j.Keybind,
+		Color: j.Color, Bold: j.Bold, Italic: j.Italic, Underlined: j.Underlined,
+		Strikethrough: j.Strikethrough, Obfuscated: j.Obfuscated,
+		Font: j.Font, Insertion: j.Insertion, ClickEvent: j.ClickEvent, HoverEvent: j.HoverEvent,
+		Extra: j.Extra,
+	}
+	if j.Text != nil {
+		c.Text = *j.Text
+	}
+	return nil
+}
+
+func (h HoverEvent) MarshalJSON() ([]byte, error) {
+	j := struct {
+		Action   string      `json:"action"`
+		Contents interface{} `json:"contents,omitempty"`
+	}{Action: h.Action}
+
+	switch {
+	case h.Text != nil:
+		j.Contents = h.Text
+	case len(h.Contents) > 0:
+		j.Contents = h.Contents
+	}
+	return json.Marshal(j)
+}
+
+// UnmarshalJSON reads the contents of a hover event, or the value of the layout before 1.16.
+func (h *HoverEvent) UnmarshalJSON(data []byte) error {
+	var j struct {
+		Action   string          `json:"action"`
+		Contents json.RawMessage `json:"contents"`
+		Value    json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+
+	contents := j.Contents
+	if len(contents) == 0 {
+		contents = j.Value
+	}
+
+	*h = HoverEvent{Action: j.Action}
+	if j.Action == ShowText && len(contents) > 0 {
+		var text Component
+		if err := json.Unmarshal(contents, &text); err != nil {
+			return err
+		}
+		h.Text = &text
+		return nil
+	}
+	h.Contents = contents
+	return nil
+}
+
+// ReadFrom reads the component of a packet, so it can be passed to Packet.Scan.
+func (c *Component) ReadFrom(r io.Reader) (int64, error) {
+	var text pk.String
+	n, err := text.ReadFrom(r)
+	if err != nil {
+		return n, err
+	}
+	return n, json.Unmarshal([]byte(text), c)
+}
+
+// WriteTo writes the component to a packet, so it can be passed to pk.Marshal.
+func (c Component) WriteTo(w io.Writer) (int64, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return 0, err
+	}
+	return pk.String(data).WriteTo(w)
+}
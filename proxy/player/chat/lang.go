@@ -0,0 +1,58 @@
+package chat
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	mcChat "github.com/Tnze/go-mc/chat"
+	en_us "github.com/Tnze/go-mc/data/lang/en-us"
+)
+
+// English is the bundled vanilla en_us language table, the default one and the
+// fallback of the keys missing from a loaded language.
+var English = en_us.Map
+
+// LoadLanguage reads a language file of the vanilla assets, like lang/de_de.json,
+// or the key=value form of a .lang file before 1.13.
+func LoadLanguage(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	table := make(map[string]string)
+	if filepath.Ext(path) != ".lang" {
+		if err := json.Unmarshal(data, &table); err != nil {
+			return nil, fmt.Errorf("invalid language file %s: %w", path, err)
+		}
+		return table, nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if key, value, ok := strings.Cut(line, "="); ok {
+			table[key] = value
+		}
+	}
+	return table, scanner.Err()
+}
+
+// FromMessage converts a go-mc chat message, like the ones of the network events,
+// to a component.
+func FromMessage(msg mcChat.Message) Component {
+	var c Component
+	data, err := json.Marshal(msg)
+	if err != nil || json.Unmarshal(data, &c) != nil {
+		return Text(msg.ClearString())
+	}
+	return c
+}
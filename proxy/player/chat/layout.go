@@ -0,0 +1,252 @@
+package chat
+
+import (
+	"strings"
+)
+
+// Widths in pixels of the text areas of the client at the default GUI scale.
+const (
+	// ChatWidth is the width of the chat box with the default chat settings.
+	ChatWidth = 320
+	// MOTDWidth is the width of the MOTD in the server list, beside the server icon.
+	MOTDWidth = 270
+)
+
+// Ellipsis ends the text cut by Truncate.
+const Ellipsis = "..."
+
+// advances are the widths in pixels of the characters of the default font which are
+// not 6 pixels wide, the pixel between two characters included.
+var advances = map[rune]int{
+	' ': 4, '!': 2, '"': 5, '\'': 3, '(': 5, ')': 5, '*': 5, ',': 2, '.': 2, ':': 2, ';': 2,
+	'<': 5, '>': 5, '@': 7, 'I': 4, '[': 4, ']': 4, '`': 3, 'f': 5, 'i': 2, 'k': 5, 'l': 3,
+	't': 4, '{': 5, '|': 2, '}': 5, '~': 7,
+}
+
+// advance is the width of a character, bold characters are a pixel wider.
+func advance(r rune, bold bool) int {
+	width, ok := advances[r]
+	if !ok {
+		width = 6
+	}
+	if bold {
+		width++
+	}
+	return width
+}
+
+// layout is the style of legacy text at some point, kept as the codes restoring it.
+type layout struct {
+	color   string
+	formats string
+	bold    bool
+}
+
+// codes restore the style at the start of another line.
+func (l layout) codes() string {
+	return l.color + l.formats
+}
+
+// code reads the § code at chars[i] into the style, it returns the number of runes of
+// the code or zero for a shown character.
+func (l *layout) code(chars []rune, i int) int {
+	if chars[i] != ColorCChar || i+1 >= len(chars) {
+		return 0
+	}
+	if c, size, ok := hexAt(chars, i); ok {
+		*l = layout{color: c.Legacy()}
+		return size
+	}
+
+	code, ok := codeOf(chars[i+1])
+	if !ok {
+		return 0
+	}
+	switch {
+	case code == Reset:
+		*l = layout{}
+	case code >= Obfuscated:
+		l.formats += code.String()
+		l.bold = l.bold || code == Bold
+	default:
+		*l = layout{color: code.String()}
+	}
+	return 2
+}
+
+// Width measures text with § codes in pixels of the default font. The codes take no
+// space and bold characters are a pixel wider.
+func Width(text string) int {
+	var l layout
+	return l.measure(text)
+}
+
+// Width measures the component like its legacy form.
+func (c Component) Width() int {
+	return Width(c.Legacy())
+}
+
+// measure measures the text starting in the style, which it leaves at the end of the text.
+func (l *layout) measure(text string) int {
+	width := 0
+	chars := []rune(text)
+	for i := 0; i < len(chars); i++ {
+		if size := l.code(chars, i); size > 0 {
+			i += size - 1
+			continue
+		}
+		width += advance(chars[i], l.bold)
+	}
+	return width
+}
+
+// spaces are the spaces closest to the width in pixels.
+func spaces(width int) string {
+	if width <= 0 {
+		return ""
+	}
+	return strings.Repeat(" ", (width+advances[' ']/2)/advances[' '])
+}
+
+// Center puts spaces before every line of the text to center it in width pixels, like
+// ChatWidth or MOTDWidth. Lines wider than that are left as they are.
+func Center(text string, width int) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = spaces((width-Width(line))/2) + line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Pad puts spaces after the text to make it width pixels wide, for columns. The spaces
+// are reset so they are not underlined or struck through.
+func Pad(text string, width int) string {
+	padding := spaces(width - Width(text))
+	if padding == "" {
+		return text
+	}
+	return text + Reset.String() + padding
+}
+
+// Truncate cuts the text to width pixels, ending it with the Ellipsis in the style of the
+// last character kept. Text which fits is left as it is, and the Ellipsis is cut too when
+// not even it fits.
+func Truncate(text string, width int) string {
+	if Width(text) <= width {
+		return text
+	}
+
+	var (
+		b    strings.Builder
+		l    layout
+		kept layout
+		used int
+		// codes are the codes after the last character kept, left out when no other is
+		// kept so they do not change the style of the ellipsis
+		codes strings.Builder
+	)
+	chars := []rune(text)
+	for i := 0; i < len(chars); i++ {
+		if size := l.code(chars, i); size > 0 {
+			codes.WriteString(string(chars[i : i+size]))
+			i += size - 1
+			continue
+		}
+
+		ellipsis := l
+		if used+advance(chars[i], l.bold)+ellipsis.measure(Ellipsis) > width {
+			break
+		}
+		used += advance(chars[i], l.bold)
+		b.WriteString(codes.String())
+		codes.Reset()
+		b.WriteRune(chars[i])
+		kept = l
+	}
+
+	ellipsis := []rune(Ellipsis)
+	for len(ellipsis) > 0 {
+		if end := kept; used+end.measure(string(ellipsis)) <= width {
+			break
+		}
+		ellipsis = ellipsis[:len(ellipsis)-1]
+	}
+	return b.String() + string(ellipsis)
+}
+
+// Wrap breaks the text into lines of at most width pixels at the spaces, and inside the
+// words wider than a line. Every line starts with the codes of the color and the formats
+// the previous one ended with, so the style carries over. Newlines start a line too.
+func Wrap(text string, width int) []string {
+	var (
+		lines []string
+		l     layout
+	)
+	for _, paragraph := range strings.Split(text, "\n") {
+		var (
+			line strings.Builder
+			used int
+		)
+		line.WriteString(l.codes())
+		empty := true
+
+		for _, word := range strings.Split(paragraph, " ") {
+			start := l
+			size := l.measure(word)
+			space := advance(' ', start.bold)
+
+			if !empty && used+space+size <= width {
+				line.WriteByte(' ')
+				line.WriteString(word)
+				used += space + size
+				continue
+			}
+			if !empty {
+				lines = append(lines, line.String())
+				line.Reset()
+				line.WriteString(start.codes())
+				used = 0
+			}
+
+			if size <= width {
+				line.WriteString(word)
+				used, empty = size, false
+				continue
+			}
+
+			// a word wider than a line is broken anywhere
+			l = start
+			chars := []rune(word)
+			for i := 0; i < len(chars); i++ {
+				if n := l.code(chars, i); n > 0 {
+					line.WriteString(string(chars[i : i+n]))
+					i += n - 1
+					continue
+				}
+				if w := advance(chars[i], l.bold); used+w > width && used > 0 {
+					lines = append(lines, line.String())
+					line.Reset()
+					line.WriteString(l.codes())
+					used = 0
+				}
+				line.WriteRune(chars[i])
+				used += advance(chars[i], l.bold)
+			}
+			empty = false
+		}
+		lines = append(lines, line.String())
+	}
+	return lines
+}
+
+// MOTD fits the text into the two lines of the server list, wrapping it at MOTDWidth and
+// cutting the second line with the Ellipsis.
+func MOTD(text string) string {
+	lines := Wrap(text, MOTDWidth)
+	if len(lines) <= 2 {
+		return strings.Join(lines, "\n")
+	}
+
+	// the rest is joined to the second line, so the ellipsis shows something is left out
+	return lines[0] + "\n" + Truncate(strings.Join(lines[1:], " "), MOTDWidth)
+}
@@ -0,0 +1,103 @@
+package chat
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWidth(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"hello", 24},
+		{"Hi!", 10},
+		{"I i l t", 25},
+		{"§lhi", 10},
+		{"§lA§cB", 13},
+		{"§l§oab§rcd", 26},
+		{"§x§f§f§a§a§0§0ab", 12},
+		{"a§", 12},
+		{"§zq", 18},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := Width(tt.text); got != tt.want {
+				t.Errorf("Width(%q) = %d, want %d", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		width int
+		want  string
+		// pixels is the width of the result
+		pixels int
+	}{
+		{"fits", "hello", 24, "hello", 24},
+		{"cut", "hello", 23, "hel...", 21},
+		{"bold", "§lhello", 28, "§lhel...", 27},
+		{"codes kept", "§cred §atext", 20, "§cre...", 18},
+		{"bold ellipsis", "§lab§rcd", 20, "§la...", 16},
+		{"codes after the last character", "ab§lcd", 20, "ab...", 18},
+		{"narrower than the ellipsis", "hello", 5, "..", 4},
+		{"bold narrower than the ellipsis", "§lhello", 5, "..", 4},
+		{"one pixel", "hello", 1, "", 0},
+		{"zero", "hello", 0, "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Truncate(tt.text, tt.width)
+			if got != tt.want {
+				t.Errorf("Truncate(%q, %d) = %q, want %q", tt.text, tt.width, got, tt.want)
+			}
+			if pixels := Width(got); pixels != tt.pixels || pixels > tt.width {
+				t.Errorf("Truncate(%q, %d) is %d pixels wide, want %d", tt.text, tt.width, pixels, tt.pixels)
+			}
+		})
+	}
+}
+
+func TestWrap(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		width int
+		want  []string
+		// pixels are the widths of the lines
+		pixels []int
+	}{
+		{"empty", "", 10, []string{""}, []int{0}},
+		{"one line", "a b c", 100, []string{"a b c"}, []int{26}},
+		{"spaces kept", "a  b", 100, []string{"a  b"}, []int{20}},
+		{"at the space", "hello world", 30, []string{"hello", "world"}, []int{24, 27}},
+		{"exact", "hello hello", 52, []string{"hello hello"}, []int{52}},
+		{"a pixel short", "hello hello", 51, []string{"hello", "hello"}, []int{24, 24}},
+		{"bold space", "§lab cd", 33, []string{"§lab cd"}, []int{33}},
+		{"bold space short", "§lab cd", 32, []string{"§lab", "§lcd"}, []int{14, 14}},
+		{"codes carried", "§chello §lworld", 30, []string{"§chello", "§c§lworl", "§c§ld"}, []int{24, 25, 7}},
+		{"hex color carried", "hex: §x§f§f§0§0§0§0colored words", 60,
+			[]string{"hex:", "§x§f§f§0§0§0§0colored", "§x§f§f§0§0§0§0words"}, []int{20, 39, 30}},
+		{"newline", "§ared\nnext", 100, []string{"§ared", "§anext"}, []int{18, 22}},
+		{"word wider than a line", "aaaaaaaaaa", 20, []string{"aaa", "aaa", "aaa", "a"}, []int{18, 18, 18, 6}},
+		{"character wider than a line", "ab", 3, []string{"a", "b"}, []int{6, 6}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Wrap(tt.text, tt.width)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Wrap(%q, %d) = %q, want %q", tt.text, tt.width, got, tt.want)
+			}
+			for i, line := range got {
+				if pixels := Width(line); pixels != tt.pixels[i] {
+					t.Errorf("line %d %q is %d pixels wide, want %d", i, line, pixels, tt.pixels[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,432 @@
+package chat
+
+import (
+	"strings"
+)
+
+// Placeholders are the values of the <name> tags of a markup, like the name of a player,
+// keyed by their lower case name. Values are inserted as text, tags in them are not parsed.
+type Placeholders map[string]string
+
+// token is a piece of markup, either text or a tag.
+type token struct {
+	text string
+	// tag is the lower case name of a tag token, close tags start with "/"
+	tag  string
+	args []string
+	raw  string
+}
+
+// Parse converts tag markup to a component, like
+//
+//	<gold><bold>Hello</bold> <click:run_command:/spawn><hover:show_text:'Go home'>here</hover></click>
+//
+// Tags are colors (<red>, <#FFAA00>, <color:gold>), decorations (<bold>, <b>, <italic>, <i>,
+// <underlined>, <u>, <strikethrough>, <st>, <obfuscated>, <obf>, negated like <!bold>),
+// <click:action:value>, <hover:show_text:'text'>, <insert:text>, <font:name>,
+// <gradient:#color:#color...>, <key:key.jump>, <lang:key:'arg'...>, <newline> and <reset>.
+// A close tag like </bold> ends the tag and the tags opened inside it, </> ends the last one.
+// Arguments with colons or > are quoted with ' or ", \< is a literal < and unknown tags are
+// kept as text.
+func Parse(markup string, placeholders Placeholders) Component {
+	p := parser{placeholders: placeholders, stack: []frame{{}}}
+
+	for _, t := range tokenize(markup) {
+		switch {
+		case t.tag == "":
+			p.text(t.text)
+		case strings.HasPrefix(t.tag, "/"):
+			if !p.close(t.tag[1:]) {
+				p.text(t.raw)
+			}
+		default:
+			if !p.open(t) {
+				p.text(t.raw)
+			}
+		}
+	}
+
+	for len(p.stack) > 1 {
+		p.pop()
+	}
+	root := p.stack[0].component
+	if len(root.Extra) == 1 {
+		return root.Extra[0]
+	}
+	return root
+}
+
+// Escape makes text show as it is when it is part of a markup.
+func Escape(text string) string {
+	return strings.NewReplacer(`\`, `\\`, `<`, `\<`).Replace(text)
+}
+
+// Sanitize strips the tags of untrusted markup, like the chat of a player, keeping the
+// text and the unknown tags. The result is a markup showing them as they are.
+func Sanitize(markup string) string {
+	p := parser{}
+	var b strings.Builder
+	for _, t := range tokenize(markup) {
+		switch {
+		case t.tag == "":
+			b.WriteString(Escape(t.text))
+		case strings.HasPrefix(t.tag, "/"):
+			if !known(t.tag[1:]) {
+				b.WriteString(Escape(t.raw))
+			}
+		default:
+			if _, ok := p.build(t); !ok && t.tag != "reset" {
+				b.WriteString(Escape(t.raw))
+			}
+		}
+	}
+	return b.String()
+}
+
+// tokenize splits markup into text and tags, reading the escapes.
+func tokenize(markup string) []token {
+	var (
+		tokens []token
+		text   strings.Builder
+	)
+	chars := []rune(markup)
+
+	for i := 0; i < len(chars); i++ {
+		r := chars[i]
+		if r == '\\' && i+1 < len(chars) && (chars[i+1] == '<' || chars[i+1] == '\\') {
+			text.WriteRune(chars[i+1])
+			i++
+			continue
+		}
+		if r != '<' {
+			text.WriteRune(r)
+			continue
+		}
+
+		end, args := tagAt(chars, i)
+		if end < 0 || args[0] == "" {
+			text.WriteRune(r)
+			continue
+		}
+
+		if text.Len() > 0 {
+			tokens = append(tokens, token{text: text.String()})
+			text.Reset()
+		}
+		tokens = append(tokens, token{tag: strings.ToLower(args[0]), args: args[1:], raw: string(chars[i : end+1])})
+		i = end
+	}
+
+	if text.Len() > 0 {
+		tokens = append(tokens, token{text: text.String()})
+	}
+	return tokens
+}
+
+// tagAt reads the tag starting at chars[start], split by the colons outside of quotes.
+// It returns the index of the closing > or -1.
+func tagAt(chars []rune, start int) (int, []string) {
+	var (
+		args  []string
+		arg   strings.Builder
+		quote rune
+	)
+
+	for i := start + 1; i < len(chars); i++ {
+		r := chars[i]
+		switch {
+		case quote != 0:
+			if r == '\\' && i+1 < len(chars) && (chars[i+1] == quote || chars[i+1] == '\\') {
+				arg.WriteRune(chars[i+1])
+				i++
+			} else if r == quote {
+				quote = 0
+			} else {
+				arg.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == ':':
+			args = append(args, arg.String())
+			arg.Reset()
+		case r == '>':
+			args = append(args, arg.String())
+			if name := args[0]; len(args) == 1 && len(name) > 1 && strings.HasSuffix(name, "/") {
+				// self closing like <br/>
+				args[0] = strings.TrimSuffix(name, "/")
+			}
+			return i, args
+		case r == '<' || r == '\n':
+			return -1, nil
+		default:
+			arg.WriteRune(r)
+		}
+	}
+	return -1, nil
+}
+
+// frame is an open tag with the component holding what follows it.
+type frame struct {
+	name      string
+	component Component
+	// gradient are the stops colored when the tag is closed
+	gradient []RGB
+}
+
+type parser struct {
+	placeholders Placeholders
+	stack        []frame
+}
+
+func (p *parser) top() *Component {
+	return &p.stack[len(p.stack)-1].component
+}
+
+func (p *parser) add(c Component) {
+	top := p.top()
+	top.Extra = append(top.Extra, c)
+}
+
+func (p *parser) text(text string) {
+	top := p.top()
+	if last := len(top.Extra) - 1; last >= 0 && top.Extra[last].plain() {
+		top.Extra[last].Text += text
+		return
+	}
+	top.Extra = append(top.Extra, Text(text))
+}
+
+// plain reports whether the component is text without a style or an event.
+func (c Component) plain() bool {
+	return c.Translate == "" && c.Score == nil && c.Selector == "" && c.Keybind == "" && len(c.Extra) == 0 &&
+		c.Color == "" && c.Bold == nil && c.Italic == nil && c.Underlined == nil && c.Strikethrough == nil &&
+		c.Obfuscated == nil && c.Font == "" && c.Insertion == "" && c.ClickEvent == nil && c.HoverEvent == nil
+}
+
+// open starts a tag, or adds the component of a tag without content, false for unknown tags.
+func (p *parser) open(t token) bool {
+	if value, ok := p.placeholders[t.tag]; ok && len(t.args) == 0 {
+		p.add(Text(value))
+		return true
+	}
+	if t.tag == "reset" && len(t.args) == 0 {
+		for len(p.stack) > 1 {
+			p.pop()
+		}
+		return true
+	}
+
+	f, ok := p.build(t)
+	if !ok {
+		return false
+	}
+	if f.name == "" {
+		p.add(f.component)
+		return true
+	}
+	p.stack = append(p.stack, f)
+	return true
+}
+
+// decorations are the names of the decoration tags, mapped to their long name.
+var decorations = map[string]string{
+	"bold": "bold", "b": "bold",
+	"italic": "italic", "i": "italic", "em": "italic",
+	"underlined": "underlined", "u": "underlined",
+	"strikethrough": "strikethrough", "st": "strikethrough",
+	"obfuscated": "obfuscated", "obf": "obfuscated",
+}
+
+var clickActions = map[string]bool{
+	OpenURL: true, RunCommand: true, SuggestCommand: true, ChangePage: true, CopyToClipboard: true,
+}
+
+// known reports whether the name is the one of a tag, whatever its arguments.
+func known(name string) bool {
+	name = strings.TrimPrefix(name, "!")
+	if _, ok := decorations[name]; ok {
+		return true
+	}
+	if _, ok := colorTag(name, nil); ok {
+		return true
+	}
+	switch name {
+	case "", "color", "colour", "c", "click", "hover", "insert", "font", "gradient",
+		"key", "lang", "tr", "newline", "br", "reset":
+		return true
+	}
+	return false
+}
+
+// build creates the frame of a tag, its name is empty for the tags without content.
+func (p *parser) build(t token) (frame, bool) {
+	if c, ok := colorTag(t.tag, t.args); ok {
+		return frame{name: t.tag, component: Component{Color: c}}, true
+	}
+
+	if name, negated := strings.TrimPrefix(t.tag, "!"), strings.HasPrefix(t.tag, "!"); len(t.args) == 0 {
+		if long, ok := decorations[name]; ok {
+			on := flag(!negated)
+			c := Component{}
+			switch long {
+			case "bold":
+				c.Bold = on
+			case "italic":
+				c.Italic = on
+			case "underlined":
+				c.Underlined = on
+			case "strikethrough":
+				c.Strikethrough = on
+			case "obfuscated":
+				c.Obfuscated = on
+			}
+			return frame{name: t.tag, component: c}, true
+		}
+	}
+
+	value := strings.Join(t.args, ":")
+	switch t.tag {
+	case "newline", "br":
+		return frame{component: Text("\n")}, len(t.args) == 0
+	case "click":
+		if len(t.args) < 2 || !clickActions[strings.ToLower(t.args[0])] {
+			return frame{}, false
+		}
+		event := &ClickEvent{Action: strings.ToLower(t.args[0]), Value: strings.Join(t.args[1:], ":")}
+		return frame{name: t.tag, component: Component{ClickEvent: event}}, true
+	case "hover":
+		if len(t.args) < 2 || strings.ToLower(t.args[0]) != ShowText {
+			return frame{}, false
+		}
+		text := Parse(strings.Join(t.args[1:], ":"), p.placeholders)
+		return frame{name: t.tag, component: Component{HoverEvent: &HoverEvent{Action: ShowText, Text: &text}}}, true
+	case "insert":
+		return frame{name: t.tag, component: Component{Insertion: value}}, value != ""
+	case "font":
+		return frame{name: t.tag, component: Component{Font: value}}, value != ""
+	case "gradient":
+		var stops []RGB
+		for _, arg := range t.args {
+			c, ok := colorRGB(colorName(arg))
+			if !ok {
+				return frame{}, false
+			}
+			stops = append(stops, c)
+		}
+		return frame{name: t.tag, gradient: stops}, len(stops) > 0
+	case "key":
+		return frame{component: Component{Keybind: value}}, len(t.args) == 1 && value != ""
+	case "lang", "tr":
+		if len(t.args) == 0 || t.args[0] == "" {
+			return frame{}, false
+		}
+		c := Translatable(t.args[0])
+		for _, arg := range t.args[1:] {
+			c.With = append(c.With, Parse(arg, p.placeholders))
+		}
+		return frame{component: c}, true
+	}
+	return frame{}, false
+}
+
+// colorTag resolves the color of <gold>, <#FFAA00>, <color:gold> and <c:#FFAA00>.
+func colorTag(name string, args []string) (string, bool) {
+	if (name == "color" || name == "colour" || name == "c") && len(args) == 1 {
+		name, args = args[0], nil
+	}
+	if len(args) > 0 {
+		return "", false
+	}
+
+	name = colorName(name)
+	if _, ok := colorRGB(name); !ok {
+		return "", false
+	}
+	return name, true
+}
+
+// colorName is the JSON color of a tag color, taking purple for light_purple.
+func colorName(name string) string {
+	name = strings.ToLower(name)
+	if name == "purple" {
+		return "light_purple"
+	}
+	if strings.HasPrefix(name, "#") {
+		return strings.ToUpper(name)
+	}
+	return name
+}
+
+// close ends the latest open tag of the name and the tags opened after it, false when
+// no such tag is open.
+func (p *parser) close(name string) bool {
+	for i := len(p.stack) - 1; i > 0; i-- {
+		if name == "" || sameTag(p.stack[i], name) {
+			for len(p.stack) > i {
+				p.pop()
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// sameTag reports whether the close tag ends the frame, </b> ends <bold>, </color>
+// and </red> end <color:red>.
+func sameTag(f frame, name string) bool {
+	if f.name == name {
+		return true
+	}
+	if long, ok := decorations[strings.TrimPrefix(f.name, "!")]; ok {
+		return long == decorations[strings.TrimPrefix(name, "!")]
+	}
+	if f.component.Color != "" {
+		if color, ok := colorTag(name, nil); ok {
+			return color == f.component.Color
+		}
+		return name == "color" || name == "colour" || name == "c"
+	}
+	return false
+}
+
+// pop closes the top frame, adding its component to the frame below.
+func (p *parser) pop() {
+	f := p.stack[len(p.stack)-1]
+	p.stack = p.stack[:len(p.stack)-1]
+
+	c := &f.component
+	if len(c.Extra) == 1 && c.Extra[0].plain() {
+		c.Text, c.Extra = c.Extra[0].Text, nil
+	}
+	if f.gradient != nil {
+		at := 0
+		colorize(c, f.gradient, textLength(*c), &at)
+	}
+	p.add(*c)
+}
+
+func textLength(c Component) int {
+	n := len([]rune(c.Text))
+	for _, extra := range c.Extra {
+		n += textLength(extra)
+	}
+	return n
+}
+
+// colorize splits the text of the component into a component per character, colored at
+// its position along the gradient of total characters.
+func colorize(c *Component, stops []RGB, total int, at *int) {
+	var chars []Component
+	for _, r := range c.Text {
+		chars = append(chars, Component{Text: string(r), Color: gradientAt(stops, *at, total).String()})
+		*at++
+	}
+
+	for i := range c.Extra {
+		colorize(&c.Extra[i], stops, total, at)
+	}
+	if chars != nil {
+		c.Text = ""
+		c.Extra = append(chars, c.Extra...)
+	}
+}
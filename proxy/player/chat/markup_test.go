@@ -0,0 +1,136 @@
+package chat
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// marshal is the JSON of the component, with < and > as they are.
+func marshal(t *testing.T, c Component) string {
+	t.Helper()
+
+	data, err := json.Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.NewReplacer(`\u003c`, "<", `\u003e`, ">").Replace(string(data))
+}
+
+func TestParse(t *testing.T) {
+	placeholders := Placeholders{"player": "<red>Steve</red>", "message": `hi \<b> <click:run_command:/op Steve>`}
+
+	tests := []struct {
+		name   string
+		markup string
+		want   string
+	}{
+		{"text", "Hello", `{"text":"Hello"}`},
+		{"color", "<red>Hi</red> there", `{"text":"","extra":[{"text":"Hi","color":"red"},{"text":" there"}]}`},
+		{"hex color", "<#ffaa00>Hi", `{"text":"Hi","color":"#FFAA00"}`},
+		{"color tag", "<color:gold>Hi</color>", `{"text":"Hi","color":"gold"}`},
+		{"nested", "<red>a<bold>b</bold>c", `{"text":"","color":"red","extra":[{"text":"a"},{"text":"b","bold":true},{"text":"c"}]}`},
+		{"close ends the inner tags", "<bold><red>a</bold>b", `{"text":"","extra":[{"text":"","bold":true,"extra":[{"text":"a","color":"red"}]},{"text":"b"}]}`},
+		{"close by short name", "<b>a</bold>b", `{"text":"","extra":[{"text":"a","bold":true},{"text":"b"}]}`},
+		{"negated", "<bold>a<!bold>b", `{"text":"","bold":true,"extra":[{"text":"a"},{"text":"b","bold":false}]}`},
+		{"unclosed", "<red>a<bold>b", `{"text":"","color":"red","extra":[{"text":"a"},{"text":"b","bold":true}]}`},
+		{"close last", "<red><bold>a</>b</>c", `{"text":"","extra":[{"text":"","color":"red","extra":[{"text":"a","bold":true},{"text":"b"}]},{"text":"c"}]}`},
+		{"close without open", "</red>a</>", `{"text":"</red>a</>"}`},
+		{"reset", "<red><bold>a<reset>b", `{"text":"","extra":[{"text":"","color":"red","extra":[{"text":"a","bold":true}]},{"text":"b"}]}`},
+		{"quoted colon and >", `<hover:show_text:'a:b>c'>x</hover>`, `{"text":"x","hoverEvent":{"action":"show_text","contents":{"text":"a:b>c"}}}`},
+		{"double quoted", `<hover:show_text:"it's">x`, `{"text":"x","hoverEvent":{"action":"show_text","contents":{"text":"it's"}}}`},
+		{"escaped quote", `<hover:show_text:'it\'s'>x`, `{"text":"x","hoverEvent":{"action":"show_text","contents":{"text":"it's"}}}`},
+		{"click value with colons", "<click:run_command:/tp 1:2>go", `{"text":"go","clickEvent":{"action":"run_command","value":"/tp 1:2"}}`},
+		{"click without value", "<click:run_command>go", `{"text":"<click:run_command>go"}`},
+		{"escaped <", `\<red>a\\b`, `{"text":"<red>a\\b"}`},
+		{"lone <", "a < b <", `{"text":"a < b <"}`},
+		{"tag across lines", "<red\n>a", `{"text":"<red\n>a"}`},
+		{"unknown tag", "<foo>bar</foo>", `{"text":"<foo>bar</foo>"}`},
+		{"unknown tag in color", "<red><foo>a", `{"text":"<foo>a","color":"red"}`},
+		{"placeholder", "<gray><player></gray>", `{"text":"<red>Steve</red>","color":"gray"}`},
+		{"placeholder is not markup", "<message>!", `{"text":"hi \\<b> <click:run_command:/op Steve>!"}`},
+		{"placeholder with args", "<player:x>", `{"text":"<player:x>"}`},
+		{"newline", "a<br>b<newline/>c", `{"text":"","extra":[{"text":"a"},{"text":"\nb"},{"text":"\nc"}]}`},
+		{"key", "<key:key.jump>", `{"keybind":"key.jump"}`},
+		{"lang", "<lang:chat.type.text:'<red>Steve':hi>", `{"translate":"chat.type.text","with":[{"text":"Steve","color":"red"},{"text":"hi"}]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := marshal(t, Parse(tt.markup, placeholders)); got != tt.want {
+				t.Errorf("Parse(%q)\n got %s\nwant %s", tt.markup, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		name   string
+		markup string
+		want   string
+	}{
+		{"text", "hello", "hello"},
+		{"color", "<red>hi</red>", "hi"},
+		{"nested", "<red><b>hi</red> there</b>", "hi there"},
+		{"unclosed", "<gradient:red:blue>hi", "hi"},
+		{"close last", "<red>hi</>", "hi"},
+		{"reset", "a<reset>b", "ab"},
+		{"click", "<click:run_command:/op Steve>free diamonds", "free diamonds"},
+		{"hover with quoted tags", "<hover:show_text:'<red>x:y>z'>hi</hover>", "hi"},
+		{"unknown tag", "a <foo> b </foo>", `a \<foo> b \</foo>`},
+		{"placeholder", "<player> left", `\<player> left`},
+		{"invalid arguments", "<click:hack:x>hi", `\<click:hack:x>hi`},
+		{"escaped <", `\<red>hi`, `\<red>hi`},
+		{"backslashes", `a\b\\c`, `a\\b\\c`},
+		{"lone <", "1 < 2", `1 \< 2`},
+		{"unterminated", "<red", `\<red`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Sanitize(tt.markup)
+			if got != tt.want {
+				t.Errorf("Sanitize(%q) = %q, want %q", tt.markup, got, tt.want)
+			}
+
+			// the sanitized markup shows as plain text, even inside a template
+			c := Parse("<gray>"+got+"</gray>", nil)
+			if c.Color != "gray" || len(c.Extra) != 0 || c.ClickEvent != nil || c.HoverEvent != nil {
+				t.Errorf("Parse(Sanitize(%q)) is styled: %+v", tt.markup, c)
+			}
+		})
+	}
+}
+
+func TestTagAt(t *testing.T) {
+	tests := []struct {
+		chars string
+		end   int
+		args  []string
+	}{
+		{"<red>", 4, []string{"red"}},
+		{"<br/>", 4, []string{"br"}},
+		{"</>", 2, []string{"/"}},
+		{"<a:b:c>x", 6, []string{"a", "b", "c"}},
+		{"<a:'b:c>d'>", 10, []string{"a", "b:c>d"}},
+		{`<a:"b'c">`, 8, []string{"a", "b'c"}},
+		{`<a:'b\'c\\'>`, 11, []string{"a", `b'c\`}},
+		{`<a:b\'c>`, -1, nil},
+		{"<a:'open>", -1, nil},
+		{"<red", -1, nil},
+		{"<a<b>", -1, nil},
+		{"<a\n>", -1, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.chars, func(t *testing.T) {
+			end, args := tagAt([]rune(tt.chars), 0)
+			if end != tt.end || len(args) != len(tt.args) {
+				t.Fatalf("tagAt(%q) = %d %q, want %d %q", tt.chars, end, args, tt.end, tt.args)
+			}
+			for i := range args {
+				if args[i] != tt.args[i] {
+					t.Errorf("tagAt(%q) = %d %q, want %d %q", tt.chars, end, args, tt.end, tt.args)
+				}
+			}
+		})
+	}
+}
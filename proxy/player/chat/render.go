@@ -0,0 +1,328 @@
+package chat
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Style is the resolved look of a piece of text.
+type Style struct {
+	// Color is a color name like "gold" or an RGB color like "#FFAA00", empty for the default color.
+	Color         string
+	Bold          bool
+	Italic        bool
+	Underlined    bool
+	Strikethrough bool
+	Obfuscated    bool
+}
+
+// Span is a piece of text of a component with the style inherited from its parents.
+type Span struct {
+	Text  string
+	Style Style
+}
+
+// apply overrides the style by the properties the component sets.
+func (s Style) apply(c Component) Style {
+	if c.Color != "" {
+		s.Color = c.Color
+	}
+	if c.Bold != nil {
+		s.Bold = *c.Bold
+	}
+	if c.Italic != nil {
+		s.Italic = *c.Italic
+	}
+	if c.Underlined != nil {
+		s.Underlined = *c.Underlined
+	}
+	if c.Strikethrough != nil {
+		s.Strikethrough = *c.Strikethrough
+	}
+	if c.Obfuscated != nil {
+		s.Obfuscated = *c.Obfuscated
+	}
+	return s
+}
+
+// language is the table resolving the translate keys, see SetLanguage.
+var language = struct {
+	sync.RWMutex
+	table map[string]string
+}{table: English}
+
+// SetLanguage replaces the table resolving the translate keys of components, English by
+// default. Keys missing from it are resolved by English, or shown as they are.
+func SetLanguage(table map[string]string) {
+	language.Lock()
+	defer language.Unlock()
+
+	language.table = table
+}
+
+func translation(key string) (string, bool) {
+	language.RLock()
+	defer language.RUnlock()
+
+	if format, ok := language.table[key]; ok {
+		return format, true
+	}
+	format, ok := English[key]
+	return format, ok
+}
+
+// Spans flattens the component into pieces of text, resolving translations, scores and
+// keybinds. Adjacent pieces of the same style are joined.
+func (c Component) Spans() []Span {
+	var spans []Span
+	c.spans(Style{}, &spans)
+	return spans
+}
+
+func (c Component) spans(parent Style, spans *[]Span) {
+	style := parent.apply(c)
+	add := func(text string) {
+		if text == "" {
+			return
+		}
+		if last := len(*spans) - 1; last >= 0 && (*spans)[last].Style == style {
+			(*spans)[last].Text += text
+			return
+		}
+		*spans = append(*spans, Span{Text: text, Style: style})
+	}
+
+	switch {
+	case c.Translate != "":
+		format, ok := translation(c.Translate)
+		if !ok {
+			add(c.Translate)
+			break
+		}
+		formatted(format, func(text string) { add(text) }, func(i int) {
+			if i < len(c.With) {
+				c.With[i].spans(style, spans)
+			}
+		})
+	case c.Score != nil:
+		add(c.Score.Value)
+	case c.Selector != "":
+		add(c.Selector)
+	case c.Keybind != "":
+		if name, ok := translation(c.Keybind); ok {
+			add(name)
+		} else {
+			add(c.Keybind)
+		}
+	default:
+		add(c.Text)
+	}
+
+	for _, extra := range c.Extra {
+		extra.spans(style, spans)
+	}
+}
+
+// formatted walks a Java format string with %s and %1$s arguments and %% escapes,
+// calling text for the literal parts and arg for the arguments.
+func formatted(format string, text func(string), arg func(int)) {
+	next := 0
+	for {
+		i := strings.IndexByte(format, '%')
+		if i < 0 || i+1 >= len(format) {
+			text(format)
+			return
+		}
+		text(format[:i])
+		format = format[i+1:]
+
+		switch {
+		case format[0] == '%':
+			text("%")
+			format = format[1:]
+		case format[0] == 's' || format[0] == 'd':
+			arg(next)
+			next++
+			format = format[1:]
+		default:
+			// positional %1$s, or %[1]s of the bundled table
+			start, end := 0, strings.Index(format, "$")
+			if strings.HasPrefix(format, "[") {
+				start, end = 1, strings.Index(format, "]")
+			}
+			if end < 0 || end+1 >= len(format) {
+				text("%")
+				continue
+			}
+			n, err := strconv.Atoi(format[start:end])
+			if err != nil || (format[end+1] != 's' && format[end+1] != 'd') {
+				text("%")
+				continue
+			}
+			arg(n - 1)
+			format = format[end+2:]
+		}
+	}
+}
+
+// Plain is the text of the component without styles.
+func (c Component) Plain() string {
+	var b strings.Builder
+	for _, span := range c.Spans() {
+		b.WriteString(span.Text)
+	}
+	return b.String()
+}
+
+// Legacy converts the component to § color and format codes. Click and hover
+// events, insertions and fonts have no legacy form and are left out.
+func (c Component) Legacy() string {
+	var b strings.Builder
+	current := Style{}
+	for _, span := range c.Spans() {
+		b.WriteString(legacyCodes(current, span.Style))
+		b.WriteString(span.Text)
+		current = span.Style
+	}
+	return b.String()
+}
+
+// legacyCodes switches from one style to the other, adding the formats when the color
+// stays and starting over with the color or a reset otherwise, as a color code clears them.
+func legacyCodes(from, to Style) string {
+	if from == to {
+		return ""
+	}
+
+	var b strings.Builder
+	additive := from.Color == to.Color &&
+		(!from.Bold || to.Bold) && (!from.Italic || to.Italic) && (!from.Underlined || to.Underlined) &&
+		(!from.Strikethrough || to.Strikethrough) && (!from.Obfuscated || to.Obfuscated)
+	if !additive {
+		from = Style{}
+		b.WriteString(legacyColor(to.Color))
+	}
+
+	formats := []struct {
+		on, was bool
+		code    ChatColor
+	}{
+		{to.Obfuscated, from.Obfuscated, Obfuscated},
+		{to.Bold, from.Bold, Bold},
+		{to.Strikethrough, from.Strikethrough, Strikethrough},
+		{to.Underlined, from.Underlined, Underline},
+		{to.Italic, from.Italic, Italic},
+	}
+	for _, f := range formats {
+		if f.on && !f.was {
+			b.WriteString(f.code.String())
+		}
+	}
+	return b.String()
+}
+
+// legacyColor is the § code of a JSON color, a reset for the default color.
+func legacyColor(color string) string {
+	if code, ok := jsonToCode[color]; ok && code < Obfuscated {
+		return code.String()
+	}
+	if c, err := ParseRGB(color); err == nil && strings.HasPrefix(color, "#") {
+		return c.Legacy()
+	}
+	return Reset.String()
+}
+
+// ANSI renders the component for a terminal through TranslateConsole.
+func (c Component) ANSI() string {
+	return TranslateConsole(c.Legacy())
+}
+
+// String is the legacy form of the component.
+func (c Component) String() string {
+	return c.Legacy()
+}
+
+// FromLegacy converts text with & or § codes to a component holding a text
+// component per styled piece, so Legacy gives back the same look.
+func FromLegacy(text string) Component {
+	text = Translate(text)
+
+	var (
+		root  Component
+		style Style
+		piece strings.Builder
+	)
+	flush := func() {
+		if piece.Len() == 0 {
+			return
+		}
+		root.Extra = append(root.Extra, styled(piece.String(), style))
+		piece.Reset()
+	}
+
+	chars := []rune(text)
+	for i := 0; i < len(chars); i++ {
+		if chars[i] != ColorCChar || i+1 >= len(chars) {
+			piece.WriteRune(chars[i])
+			continue
+		}
+		if c, size, ok := hexAt(chars, i); ok {
+			flush()
+			style = Style{Color: c.String()}
+			i += size - 1
+			continue
+		}
+		code, ok := codeOf(chars[i+1])
+		if !ok {
+			piece.WriteRune(chars[i])
+			continue
+		}
+
+		flush()
+		i++
+		switch code {
+		case Reset:
+			style = Style{}
+		case Obfuscated:
+			style.Obfuscated = true
+		case Bold:
+			style.Bold = true
+		case Strikethrough:
+			style.Strikethrough = true
+		case Underline:
+			style.Underlined = true
+		case Italic:
+			style.Italic = true
+		default:
+			style = Style{Color: codeToCode[code].Json}
+		}
+	}
+	flush()
+
+	if len(root.Extra) == 1 {
+		return root.Extra[0]
+	}
+	return root
+}
+
+// styled creates a text component setting every property of the style which is on.
+func styled(text string, style Style) Component {
+	c := Component{Text: text, Color: style.Color}
+	if style.Bold {
+		c.Bold = flag(true)
+	}
+	if style.Italic {
+		c.Italic = flag(true)
+	}
+	if style.Underlined {
+		c.Underlined = flag(true)
+	}
+	if style.Strikethrough {
+		c.Strikethrough = flag(true)
+	}
+	if style.Obfuscated {
+		c.Obfuscated = flag(true)
+	}
+	return c
+}
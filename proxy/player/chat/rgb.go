@@ -0,0 +1,148 @@
+package chat
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// HexChar starts the legacy form of an RGB color, §x§R§R§G§G§B§B.
+const HexChar = 'x'
+
+// TrueColor makes TranslateConsole write RGB colors as 24-bit ANSI colors, instead of
+// the nearest legacy color. It is on when COLORTERM announces a 24-bit terminal.
+var TrueColor = os.Getenv("COLORTERM") == "truecolor" || os.Getenv("COLORTERM") == "24bit"
+
+// RGB is a 1.16+ chat color.
+type RGB struct {
+	R, G, B uint8
+}
+
+// ParseRGB reads a color like "#FFAA00", the # is optional.
+func ParseRGB(hex string) (RGB, error) {
+	hex = strings.TrimPrefix(hex, "#")
+	if len(hex) != 6 {
+		return RGB{}, fmt.Errorf("invalid color %q, use #RRGGBB", hex)
+	}
+
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return RGB{}, fmt.Errorf("invalid color %q, use #RRGGBB", hex)
+	}
+	return RGB{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v)}, nil
+}
+
+// String is the JSON form of the color, like "#FFAA00".
+func (c RGB) String() string {
+	return fmt.Sprintf("#%02X%02X%02X", c.R, c.G, c.B)
+}
+
+// Legacy is the § form of the color, §x§F§F§A§A§0§0.
+func (c RGB) Legacy() string {
+	var b strings.Builder
+	b.WriteRune(ColorCChar)
+	b.WriteRune(HexChar)
+	for _, r := range fmt.Sprintf("%02x%02x%02x", c.R, c.G, c.B) {
+		b.WriteRune(ColorCChar)
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// Nearest is the legacy color closest to the color.
+func (c RGB) Nearest() ChatColor {
+	best, distance := White, -1
+	for code := DarkRed; code <= Gray; code++ {
+		l := code.RGB()
+		dr, dg, db := int(c.R)-int(l.R), int(c.G)-int(l.G), int(c.B)-int(l.B)
+		if d := dr*dr + dg*dg + db*db; distance < 0 || d < distance {
+			best, distance = code, d
+		}
+	}
+	return best
+}
+
+// RGB is the color of a legacy color code, black for the format codes.
+func (code ChatColor) RGB() RGB {
+	c, _ := ParseRGB(codeToCode[code].Hex)
+	return c
+}
+
+// colorRGB resolves a JSON color, a color name or #RRGGBB.
+func colorRGB(color string) (RGB, bool) {
+	if code, ok := jsonToCode[color]; ok && code < Obfuscated {
+		return code.RGB(), true
+	}
+	if strings.HasPrefix(color, "#") {
+		c, err := ParseRGB(color)
+		return c, err == nil
+	}
+	return RGB{}, false
+}
+
+// codeOf resolves the character after §, upper case codes included.
+func codeOf(r rune) (ChatColor, bool) {
+	code, ok := charToCode[unicode.ToLower(r)]
+	return code, ok
+}
+
+// hexAt reads a §x§R§R§G§G§B§B color starting at chars[i], it returns the color and
+// the number of runes it takes.
+func hexAt(chars []rune, i int) (RGB, int, bool) {
+	const size = 14
+	if i+size > len(chars) || chars[i] != ColorCChar || unicode.ToLower(chars[i+1]) != HexChar {
+		return RGB{}, 0, false
+	}
+
+	digits := make([]rune, 0, 6)
+	for j := i + 2; j < i+size; j += 2 {
+		if chars[j] != ColorCChar {
+			return RGB{}, 0, false
+		}
+		digits = append(digits, chars[j+1])
+	}
+
+	c, err := ParseRGB(string(digits))
+	if err != nil {
+		return RGB{}, 0, false
+	}
+	return c, size, true
+}
+
+// Gradient colors every character of the text, blending evenly from one stop to the next.
+// Its Legacy form suits the MOTD and broadcasts.
+func Gradient(text string, stops ...RGB) Component {
+	chars := []rune(text)
+	if len(stops) == 0 {
+		return Text(text)
+	}
+
+	root := Component{}
+	for i, r := range chars {
+		root.Extra = append(root.Extra, Component{Text: string(r), Color: gradientAt(stops, i, len(chars)).String()})
+	}
+	return root
+}
+
+// gradientAt is the color of the character at index of a gradient of total characters.
+func gradientAt(stops []RGB, index, total int) RGB {
+	if len(stops) == 1 || total < 2 {
+		return stops[0]
+	}
+	// position of the character along the stops
+	at := float64(index) / float64(total-1) * float64(len(stops)-1)
+	from := int(at)
+	if from >= len(stops)-1 {
+		from = len(stops) - 2
+	}
+	return blend(stops[from], stops[from+1], at-float64(from))
+}
+
+func blend(a, b RGB, t float64) RGB {
+	mix := func(x, y uint8) uint8 {
+		return uint8(float64(x) + (float64(y)-float64(x))*t + 0.5)
+	}
+	return RGB{R: mix(a.R, b.R), G: mix(a.G, b.G), B: mix(a.B, b.B)}
+}
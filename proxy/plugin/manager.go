@@ -0,0 +1,208 @@
+package plugin
+
+import (
+	"fmt"
+
+	"github.com/OCharnyshevich/proxycraft/proxy/chatlog"
+	"github.com/OCharnyshevich/proxycraft/proxy/command"
+	"github.com/OCharnyshevich/proxycraft/proxy/helper"
+	"github.com/OCharnyshevich/proxycraft/proxy/log"
+	"github.com/OCharnyshevich/proxycraft/proxy/network"
+	"github.com/OCharnyshevich/proxycraft/proxy/player/chat"
+)
+
+type entry struct {
+	plugin  Plugin
+	context *Context
+}
+
+type Manager struct {
+	logger   *log.Logging
+	network  helper.Network
+	commands *command.Registry
+	logs     *log.Ring
+	chat     *chatlog.Archive
+	configs  map[string]Config
+
+	registered []Plugin
+	loaded     []entry
+}
+
+// NewManager creates the plugin manager, logs is the log buffer and archive the chat archive
+// given to the plugins, both may be nil.
+func NewManager(network helper.Network, commands *command.Registry, logs *log.Ring, archive *chatlog.Archive, configs map[string]Config) *Manager {
+	return &Manager{
+		logger:   log.New("plugins"),
+		network:  network,
+		commands: commands,
+		logs:     logs,
+		chat:     archive,
+		configs:  configs,
+	}
+}
+
+func (m *Manager) Register(plugins ...Plugin) {
+	m.registered = append(m.registered, plugins...)
+}
+
+// Plugins lists the loaded plugins in load order.
+func (m *Manager) Plugins() []Plugin {
+	list := make([]Plugin, len(m.loaded))
+	for i, e := range m.loaded {
+		list[i] = e.plugin
+	}
+	return list
+}
+
+// Plugin returns the loaded plugin of the name.
+func (m *Manager) Plugin(name string) (Plugin, bool) {
+	for _, e := range m.loaded {
+		if e.plugin.Info().Name == name {
+			return e.plugin, true
+		}
+	}
+	return nil, false
+}
+
+func (m *Manager) Load() {
+	ordered, errs := order(m.registered)
+	for _, err := range errs {
+		m.logger.Fail(err)
+	}
+
+	loaded := make(map[string]bool, len(ordered))
+
+	for _, p := range ordered {
+		info := p.Info()
+		if missing := missingDependency(info, loaded); missing != "" {
+			m.logger.FailF("unable to load %s: dependency %s is not loaded", info.Name, missing)
+			continue
+		}
+
+		ctx := &Context{
+			Logger:   log.New("plugins." + info.Name),
+			Network:  m.network,
+			Events:   m.network.Events().(*network.Events),
+			Config:   m.configs[info.Name],
+			Logs:     m.logs,
+			Chat:     m.chat,
+			name:     "plugin:" + info.Name,
+			commands: m.commands,
+			manager:  m,
+		}
+
+		err := helper.Attempt(func() {
+			p.Attach(ctx)
+			p.Load()
+		})
+		if err != nil {
+			ctx.release()
+			m.logger.FailF("unable to load %s: %v", info.Name, err)
+			continue
+		}
+
+		loaded[info.Name] = true
+		m.loaded = append(m.loaded, entry{plugin: p, context: ctx})
+		m.logger.Info("loaded ", chat.Gold, info.Name, chat.Reset, " ", info.Version)
+	}
+}
+
+// Kill unloads the plugins in the reverse load order.
+func (m *Manager) Kill() {
+	for i := len(m.loaded) - 1; i >= 0; i-- {
+		e := m.loaded[i]
+		name := e.plugin.Info().Name
+
+		if err := helper.Attempt(e.plugin.Kill); err != nil {
+			m.logger.FailF("unable to kill %s: %v", name, err)
+		}
+		e.context.release()
+
+		m.logger.InfoF("unloaded %s", name)
+	}
+
+	m.loaded = nil
+}
+
+func missingDependency(info Info, loaded map[string]bool) string {
+	for _, name := range info.Depends {
+		if !loaded[name] {
+			return name
+		}
+	}
+	return ""
+}
+
+// order sorts the plugins so that every plugin comes after its dependencies.
+// Plugins with a missing or cyclic dependency are left out together with their dependents,
+// and so is a plugin with the name of one registered before.
+func order(plugins []Plugin) ([]Plugin, []error) {
+	const (
+		unvisited = iota
+		visiting
+		done
+		failed
+	)
+
+	var errs []error
+
+	byName := make(map[string]Plugin, len(plugins))
+	marks := make(map[string]int, len(plugins))
+	unique := make([]Plugin, 0, len(plugins))
+	for _, p := range plugins {
+		info := p.Info()
+		if first, ok := byName[info.Name]; ok {
+			errs = append(errs, fmt.Errorf(
+				"plugin %s %s (%T) is left out, the name is taken by %s %s (%T)",
+				info.Name, info.Version, p, info.Name, first.Info().Version, first,
+			))
+			continue
+		}
+		byName[info.Name] = p
+		unique = append(unique, p)
+	}
+
+	ordered := make([]Plugin, 0, len(plugins))
+
+	var visit func(p Plugin) bool
+	visit = func(p Plugin) bool {
+		info := p.Info()
+		switch marks[info.Name] {
+		case visiting:
+			errs = append(errs, fmt.Errorf("plugin %s has a dependency cycle", info.Name))
+			marks[info.Name] = failed
+			return false
+		case done:
+			return true
+		case failed:
+			return false
+		}
+
+		marks[info.Name] = visiting
+		for _, name := range info.Depends {
+			dep, ok := byName[name]
+			if !ok {
+				errs = append(errs, fmt.Errorf("plugin %s depends on missing plugin %s", info.Name, name))
+				marks[info.Name] = failed
+				return false
+			}
+			if !visit(dep) {
+				if marks[info.Name] != failed {
+					errs = append(errs, fmt.Errorf("plugin %s depends on plugin %s which can not be loaded", info.Name, name))
+				}
+				marks[info.Name] = failed
+				return false
+			}
+		}
+		marks[info.Name] = done
+
+		ordered = append(ordered, p)
+		return true
+	}
+
+	for _, p := range unique {
+		visit(p)
+	}
+
+	return ordered, errs
+}
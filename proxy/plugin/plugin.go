@@ -0,0 +1,132 @@
+package plugin
+
+import (
+	"encoding/json"
+
+	"github.com/OCharnyshevich/proxycraft/proxy/chatlog"
+	"github.com/OCharnyshevich/proxycraft/proxy/command"
+	"github.com/OCharnyshevich/proxycraft/proxy/helper"
+	"github.com/OCharnyshevich/proxycraft/proxy/log"
+	"github.com/OCharnyshevich/proxycraft/proxy/network"
+)
+
+// Plugin extends the proxy. Attach is called once before Load, and a plugin is
+// loaded after the plugins it depends on and killed before them.
+type Plugin interface {
+	helper.State
+	Info() Info
+	Attach(ctx *Context)
+}
+
+type Info struct {
+	Name    string
+	Version string
+	Depends []string
+}
+
+// Config is the plugin's own section of the proxy config.
+type Config map[string]interface{}
+
+// Decode fills v, usually a pointer to a struct, from the config section.
+func (c Config) Decode(v interface{}) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+// Context gives a plugin access to the proxy.
+type Context struct {
+	Logger  *log.Logging
+	Network helper.Network
+	Events  *network.Events
+	Config  Config
+	// Logs keeps the latest log entries of the proxy, nil when the buffer is off.
+	Logs *log.Ring
+	// Chat is the chat archive of the proxy, nil when it is off.
+	Chat *chatlog.Archive
+
+	name     string
+	commands *command.Registry
+	manager  *Manager
+	owned    []string
+}
+
+// Listen adds packet handlers, they are removed when the plugin is killed.
+func (c *Context) Listen(handlers ...network.PacketHandler) {
+	c.Events.AddListener(c.own(handlers)...)
+}
+
+// ListenGeneric adds handlers called for every packet, they are removed when the plugin is killed.
+func (c *Context) ListenGeneric(handlers ...network.PacketHandler) {
+	c.Events.AddGeneric(c.own(handlers)...)
+}
+
+// ListenGenericServerbound adds handlers called for every packet sent by the clients,
+// they are removed when the plugin is killed.
+func (c *Context) ListenGenericServerbound(handlers ...network.PacketHandler) {
+	c.Events.AddGenericServerbound(c.own(handlers)...)
+}
+
+// ListenSessions adds session lifecycle handlers, they are removed when the plugin is killed.
+func (c *Context) ListenSessions(handlers ...network.SessionHandler) {
+	for _, h := range handlers {
+		h.Owner = c.name
+		c.Events.AddSessionListener(h)
+	}
+}
+
+func (c *Context) own(handlers []network.PacketHandler) []network.PacketHandler {
+	owned := make([]network.PacketHandler, len(handlers))
+	for i, h := range handlers {
+		h.Owner = c.name
+		owned[i] = h
+	}
+	return owned
+}
+
+// RegisterCommand adds console and in-game commands, they are removed when the plugin is killed.
+func (c *Context) RegisterCommand(commands ...command.Command) error {
+	if err := c.commands.Register(commands...); err != nil {
+		return err
+	}
+
+	for _, cmd := range commands {
+		c.owned = append(c.owned, cmd.Name)
+	}
+
+	return nil
+}
+
+// Execute runs a command line on behalf of the sender, like a line typed into the console.
+func (c *Context) Execute(sender command.Sender, line string) error {
+	return c.commands.Execute(sender, line)
+}
+
+// Permitted reports whether the sender holds the permission node, like the ones of commands.
+func (c *Context) Permitted(sender command.Sender, node string) bool {
+	return c.commands.Permitted(sender, node)
+}
+
+// Plugin returns another loaded plugin by name, for the plugins working together.
+func (c *Context) Plugin(name string) (Plugin, bool) {
+	return c.manager.Plugin(name)
+}
+
+// ArchiveChat appends the entry to the chat archive, nothing happens when it is off.
+func (c *Context) ArchiveChat(entry chatlog.Entry) {
+	if c.Chat == nil {
+		return
+	}
+	if err := c.Chat.Append(entry); err != nil {
+		c.Logger.FailF("unable to archive a chat message: %v", err)
+	}
+}
+
+func (c *Context) release() {
+	c.Events.RemoveListeners(c.name)
+	c.commands.Unregister(c.owned...)
+	c.owned = nil
+}
@@ -0,0 +1,35 @@
+package rcon
+
+import "time"
+
+// limiter is a token bucket refilled by rate tokens per second up to burst.
+type limiter struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newLimiter(rate float64, burst int) *limiter {
+	return &limiter{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// allow takes a token, or returns how long to wait for the next one.
+func (l *limiter) allow(now time.Time) (bool, time.Duration) {
+	if l.rate <= 0 {
+		return true, 0
+	}
+
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+
+	if l.tokens < 1 {
+		return false, time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+	}
+
+	l.tokens--
+	return true, 0
+}
@@ -0,0 +1,97 @@
+package rcon
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"unicode/utf8"
+)
+
+// Packet types of the Source RCON protocol. Command and AuthResponse share the value,
+// the direction tells them apart.
+const (
+	Response     int32 = 0
+	Command      int32 = 2
+	AuthResponse int32 = 2
+	Auth         int32 = 3
+)
+
+const (
+	// maxRequest limits the size of a request, the Minecraft server accepts no more either.
+	maxRequest = 1460
+	// maxBody is the largest body of a response packet, longer output is split over several packets.
+	maxBody = 4096
+)
+
+// authFailed is the request ID answering a wrong password.
+const authFailed int32 = -1
+
+type Packet struct {
+	ID   int32
+	Type int32
+	Body string
+}
+
+// readPacket reads a packet framed as its little endian length, ID, type and
+// the body followed by two null bytes.
+func readPacket(r io.Reader) (Packet, error) {
+	var length int32
+	if err := binary.Read(r, binary.LittleEndian, &length); err != nil {
+		return Packet{}, err
+	}
+	if length < 10 || length > maxRequest {
+		return Packet{}, fmt.Errorf("invalid packet length %d", length)
+	}
+
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return Packet{}, err
+	}
+
+	if data[length-1] != 0 || data[length-2] != 0 {
+		return Packet{}, errors.New("packet is not null terminated")
+	}
+
+	return Packet{
+		ID:   int32(binary.LittleEndian.Uint32(data[0:4])),
+		Type: int32(binary.LittleEndian.Uint32(data[4:8])),
+		Body: string(data[8 : length-2]),
+	}, nil
+}
+
+func writePacket(w io.Writer, p Packet) error {
+	var buf bytes.Buffer
+	_ = binary.Write(&buf, binary.LittleEndian, int32(len(p.Body)+10))
+	_ = binary.Write(&buf, binary.LittleEndian, p.ID)
+	_ = binary.Write(&buf, binary.LittleEndian, p.Type)
+	buf.WriteString(p.Body)
+	buf.Write([]byte{0, 0})
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// writeResponse sends the output of a command, split into packets of maxBody bytes.
+func writeResponse(w io.Writer, id int32, body string) error {
+	for {
+		part := body
+		if len(part) > maxBody {
+			cut := maxBody
+			// keep multi-byte characters in one packet
+			for cut > 0 && !utf8.RuneStart(body[cut]) {
+				cut--
+			}
+			part = part[:cut]
+		}
+		body = body[len(part):]
+
+		if err := writePacket(w, Packet{ID: id, Type: Response, Body: part}); err != nil {
+			return err
+		}
+		if body == "" {
+			return nil
+		}
+	}
+}
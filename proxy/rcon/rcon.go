@@ -0,0 +1,282 @@
+// Package rcon runs proxy commands for Source RCON clients, like mcrcon, and
+// answers with their output.
+//
+// A client authenticates with the configured password before its commands are
+// run, a wrong password closes the connection. Commands run with the permissions of the console, every request counts
+// against the rate limit of the connection and every command is written to the
+// audit log.
+package rcon
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OCharnyshevich/proxycraft/proxy/helper"
+	"github.com/OCharnyshevich/proxycraft/proxy/player/chat"
+	"github.com/OCharnyshevich/proxycraft/proxy/plugin"
+)
+
+type Config struct {
+	Address string `json:"address"`
+	// Password enables the server, RCON is off while it is empty.
+	Password string `json:"password"`
+	// Rate is the number of requests per second a connection may send, with bursts up to Burst.
+	Rate  float64 `json:"rate"`
+	Burst int     `json:"burst"`
+	// Audit is the file every command is appended to, nothing is written when empty.
+	Audit string `json:"audit"`
+}
+
+// Server is the plugin serving RCON.
+type Server struct {
+	ctx    *plugin.Context
+	config Config
+
+	listener net.Listener
+
+	mu      sync.Mutex
+	conns   map[net.Conn]struct{}
+	audit   *os.File
+	auditMu sync.Mutex
+}
+
+func New() *Server {
+	return &Server{
+		config: Config{
+			Address: "127.0.0.1:25576",
+			Rate:    2,
+			Burst:   10,
+			Audit:   "rcon-audit.log",
+		},
+		conns: make(map[net.Conn]struct{}),
+	}
+}
+
+func (s *Server) Info() plugin.Info {
+	return plugin.Info{Name: "rcon", Version: "1.0.0"}
+}
+
+func (s *Server) Attach(ctx *plugin.Context) {
+	s.ctx = ctx
+}
+
+func (s *Server) Load() {
+	if err := s.ctx.Config.Decode(&s.config); err != nil {
+		s.ctx.Logger.FailF("invalid config: %v", err)
+		return
+	}
+
+	if s.config.Password == "" {
+		s.ctx.Logger.Info("rcon is disabled, set a password to enable it")
+		return
+	}
+
+	if s.config.Audit != "" {
+		audit, err := os.OpenFile(s.config.Audit, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+		if err != nil {
+			s.ctx.Logger.FailF("unable to open the audit log %s: %v", s.config.Audit, err)
+			return
+		}
+		s.audit = audit
+	}
+
+	listener, err := net.Listen("tcp", s.config.Address)
+	if err != nil {
+		s.ctx.Logger.FailF("unable to listen on %s: %v", s.config.Address, err)
+		s.closeAudit()
+		return
+	}
+	s.listener = listener
+
+	go s.accept()
+
+	s.ctx.Logger.InfoF("rcon listening on %s", s.config.Address)
+}
+
+func (s *Server) Kill() {
+	if s.listener == nil {
+		return
+	}
+	_ = s.listener.Close()
+	s.listener = nil
+
+	s.mu.Lock()
+	for conn := range s.conns {
+		_ = conn.Close()
+	}
+	s.conns = make(map[net.Conn]struct{})
+	s.mu.Unlock()
+
+	s.closeAudit()
+}
+
+func (s *Server) closeAudit() {
+	s.auditMu.Lock()
+	defer s.auditMu.Unlock()
+
+	if s.audit != nil {
+		_ = s.audit.Close()
+		s.audit = nil
+	}
+}
+
+func (s *Server) accept() {
+	listener := s.listener
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				s.ctx.Logger.Warn(err)
+			}
+			return
+		}
+
+		s.mu.Lock()
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+
+		go func() {
+			s.serve(conn)
+
+			s.mu.Lock()
+			delete(s.conns, conn)
+			s.mu.Unlock()
+			_ = conn.Close()
+		}()
+	}
+}
+
+// serve answers the requests of a client until the connection is closed.
+func (s *Server) serve(conn net.Conn) {
+	address := conn.RemoteAddr().String()
+	limit := newLimiter(s.config.Rate, s.config.Burst)
+	authenticated := false
+
+	for {
+		request, err := readPacket(conn)
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+				s.ctx.Logger.WarnF("rcon client %s: %v", address, err)
+			}
+			return
+		}
+
+		if ok, wait := limit.allow(time.Now()); !ok {
+			line := request.Body
+			if request.Type != Command {
+				line = "" // never log a password
+			}
+			s.record(address, "rate limited", line)
+			if err := writeResponse(conn, request.ID, fmt.Sprintf("Too many requests, try again in %v", wait.Round(time.Millisecond))); err != nil {
+				return
+			}
+			continue
+		}
+
+		switch {
+		case request.Type == Auth:
+			authenticated = subtle.ConstantTimeCompare([]byte(request.Body), []byte(s.config.Password)) == 1
+			if !authenticated {
+				// the rate limit is per connection, another password needs another connection
+				s.record(address, "wrong password", "")
+				_ = writePacket(conn, Packet{ID: authFailed, Type: AuthResponse})
+				return
+			}
+			s.record(address, "authenticated", "")
+			if err := writePacket(conn, Packet{ID: request.ID, Type: AuthResponse}); err != nil {
+				return
+			}
+		case !authenticated:
+			if err := writePacket(conn, Packet{ID: authFailed, Type: AuthResponse}); err != nil {
+				return
+			}
+		case request.Type == Command:
+			output := s.run(address, request.Body)
+			if err := writeResponse(conn, request.ID, output); err != nil {
+				return
+			}
+		case request.Type == Response:
+			// an empty request after a command marks the end of a split response
+			if err := writePacket(conn, Packet{ID: request.ID, Type: Response}); err != nil {
+				return
+			}
+		default:
+			if err := writeResponse(conn, request.ID, fmt.Sprintf("Unknown request %x", request.Type)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// run executes a command line and returns what it sent to the sender.
+func (s *Server) run(address, line string) string {
+	line = strings.TrimPrefix(strings.TrimSpace(line), "/")
+	sender := &sender{name: "rcon@" + address}
+
+	var err error
+	if attempt := helper.Attempt(func() { err = s.ctx.Execute(sender, line) }); attempt != nil {
+		err = attempt
+	}
+
+	result := "ok"
+	if err != nil {
+		sender.SendMessage(err.Error())
+		result = "error: " + err.Error()
+	}
+	s.record(address, result, line)
+
+	return sender.output()
+}
+
+// record writes an entry to the log and the audit file.
+func (s *Server) record(address, result, line string) {
+	if line != "" {
+		s.ctx.Logger.InfoF("rcon %s issued command: %s (%s)", address, line, result)
+	} else {
+		s.ctx.Logger.InfoF("rcon %s %s", address, result)
+	}
+
+	s.auditMu.Lock()
+	defer s.auditMu.Unlock()
+
+	if s.audit == nil {
+		return
+	}
+	entry := fmt.Sprintf("%s %s %q %s\n", time.Now().Format(time.RFC3339), address, line, result)
+	if _, err := s.audit.WriteString(entry); err != nil {
+		s.ctx.Logger.WarnF("unable to write the audit log: %v", err)
+	}
+}
+
+// sender collects the messages of a command as the response of a request.
+type sender struct {
+	name string
+
+	mu    sync.Mutex
+	lines []string
+}
+
+func (s *sender) Name() string {
+	return s.name
+}
+
+func (s *sender) SendMessage(message ...interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lines = append(s.lines, chat.Strip(helper.ConvertToString(message...)))
+}
+
+func (s *sender) output() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return strings.Join(s.lines, "\n")
+}
@@ -0,0 +1,164 @@
+package script
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/OCharnyshevich/proxycraft/proxy/command"
+	"github.com/OCharnyshevich/proxycraft/proxy/network"
+	"github.com/OCharnyshevich/proxycraft/proxy/player/chat"
+	"github.com/OCharnyshevich/proxycraft/proxy/plugin"
+)
+
+type Config struct {
+	// Directory holds the *.lua scripts, loaded in file name order.
+	Directory string `json:"directory"`
+}
+
+// Engine is the plugin running the Lua scripts of the scripts directory.
+type Engine struct {
+	ctx    *plugin.Context
+	config Config
+
+	mu      sync.Mutex
+	scripts []*Script
+}
+
+func New() *Engine {
+	return &Engine{
+		config: Config{Directory: "scripts"},
+	}
+}
+
+func (e *Engine) Info() plugin.Info {
+	return plugin.Info{Name: "scripts", Version: "1.0.0"}
+}
+
+func (e *Engine) Attach(ctx *plugin.Context) {
+	e.ctx = ctx
+}
+
+func (e *Engine) Load() {
+	if err := e.ctx.Config.Decode(&e.config); err != nil {
+		e.ctx.Logger.FailF("invalid config: %v", err)
+	}
+
+	err := e.ctx.RegisterCommand(command.Command{
+		Name:        "scripts",
+		Description: "lists or reloads the Lua scripts",
+		Scope:       command.ConsoleOnly,
+		Params: []command.Param{
+			{Name: "reload|list", Optional: true, Complete: command.Values("reload", "list")},
+		},
+		Run: e.run,
+	})
+	if err != nil {
+		e.ctx.Logger.Fail(err)
+	}
+
+	if _, err := e.Reload(); err != nil {
+		e.ctx.Logger.Fail(err)
+	}
+}
+
+func (e *Engine) Kill() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.unload()
+}
+
+// Reload unloads every script and loads the scripts directory again.
+// Scripts which fail to load are reported and skipped.
+func (e *Engine) Reload() (int, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.unload()
+
+	paths, err := filepath.Glob(filepath.Join(e.config.Directory, "*.lua"))
+	if err != nil {
+		return 0, err
+	}
+	if len(paths) == 0 {
+		if _, err := os.Stat(e.config.Directory); os.IsNotExist(err) {
+			e.ctx.Logger.InfoF("no scripts directory %s", e.config.Directory)
+		}
+		return 0, nil
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		name := strings.TrimSuffix(filepath.Base(path), ".lua")
+
+		s, err := load(name, path, e.ctx.Logger, e.ctx.Network)
+		if err != nil {
+			e.ctx.Logger.FailF("unable to load script %s: %v", name, err)
+			continue
+		}
+
+		e.ctx.Events.AddListener(owned(s)...)
+		e.scripts = append(e.scripts, s)
+
+		e.ctx.Logger.Info("loaded script ", chat.Gold, name, chat.Reset)
+	}
+
+	return len(e.scripts), nil
+}
+
+func (e *Engine) unload() {
+	for _, s := range e.scripts {
+		e.ctx.Events.RemoveListeners(owner(s))
+		s.close()
+	}
+	e.scripts = nil
+}
+
+// Scripts lists the names of the loaded scripts.
+func (e *Engine) Scripts() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	names := make([]string, len(e.scripts))
+	for i, s := range e.scripts {
+		names[i] = s.Name
+	}
+	return names
+}
+
+func (e *Engine) run(sender command.Sender, args command.Args) error {
+	action := args.String("reload|list")
+	if action == "" || action == "list" {
+		sender.SendMessage("scripts: ", strings.Join(e.Scripts(), ", "))
+		return nil
+	}
+
+	if action != "reload" {
+		return fmt.Errorf("unknown action %q", action)
+	}
+
+	count, err := e.Reload()
+	if err != nil {
+		return err
+	}
+
+	sender.SendMessage(fmt.Sprintf("reloaded %d scripts", count))
+	return nil
+}
+
+func owner(s *Script) string {
+	return "script:" + s.Name
+}
+
+func owned(s *Script) []network.PacketHandler {
+	handlers := make([]network.PacketHandler, len(s.handlers))
+	for i, h := range s.handlers {
+		h.Owner = owner(s)
+		handlers[i] = h
+	}
+	return handlers
+}
@@ -0,0 +1,140 @@
+package script
+
+import (
+	"fmt"
+
+	"github.com/OCharnyshevich/proxycraft/proxy/player/chat"
+	pk "github.com/Tnze/go-mc/net/packet"
+	"github.com/google/uuid"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// field is a packet field type which scripts name in packet:read and session:write.
+type field struct {
+	decoder func() (pk.FieldDecoder, func() lua.LValue)
+	encoder func(v lua.LValue) pk.FieldEncoder
+}
+
+func number(v lua.LValue) float64 {
+	return float64(lua.LVAsNumber(v))
+}
+
+var fields = map[string]field{
+	"Boolean": {
+		decoder: func() (pk.FieldDecoder, func() lua.LValue) {
+			var v pk.Boolean
+			return &v, func() lua.LValue { return lua.LBool(v) }
+		},
+		encoder: func(v lua.LValue) pk.FieldEncoder { return pk.Boolean(lua.LVAsBool(v)) },
+	},
+	"Byte": {
+		decoder: func() (pk.FieldDecoder, func() lua.LValue) {
+			var v pk.Byte
+			return &v, func() lua.LValue { return lua.LNumber(v) }
+		},
+		encoder: func(v lua.LValue) pk.FieldEncoder { return pk.Byte(number(v)) },
+	},
+	"UnsignedByte": {
+		decoder: func() (pk.FieldDecoder, func() lua.LValue) {
+			var v pk.UnsignedByte
+			return &v, func() lua.LValue { return lua.LNumber(v) }
+		},
+		encoder: func(v lua.LValue) pk.FieldEncoder { return pk.UnsignedByte(number(v)) },
+	},
+	"Short": {
+		decoder: func() (pk.FieldDecoder, func() lua.LValue) {
+			var v pk.Short
+			return &v, func() lua.LValue { return lua.LNumber(v) }
+		},
+		encoder: func(v lua.LValue) pk.FieldEncoder { return pk.Short(number(v)) },
+	},
+	"UnsignedShort": {
+		decoder: func() (pk.FieldDecoder, func() lua.LValue) {
+			var v pk.UnsignedShort
+			return &v, func() lua.LValue { return lua.LNumber(v) }
+		},
+		encoder: func(v lua.LValue) pk.FieldEncoder { return pk.UnsignedShort(number(v)) },
+	},
+	"Int": {
+		decoder: func() (pk.FieldDecoder, func() lua.LValue) {
+			var v pk.Int
+			return &v, func() lua.LValue { return lua.LNumber(v) }
+		},
+		encoder: func(v lua.LValue) pk.FieldEncoder { return pk.Int(number(v)) },
+	},
+	"Long": {
+		decoder: func() (pk.FieldDecoder, func() lua.LValue) {
+			var v pk.Long
+			return &v, func() lua.LValue { return lua.LNumber(v) }
+		},
+		encoder: func(v lua.LValue) pk.FieldEncoder { return pk.Long(number(v)) },
+	},
+	"Float": {
+		decoder: func() (pk.FieldDecoder, func() lua.LValue) {
+			var v pk.Float
+			return &v, func() lua.LValue { return lua.LNumber(v) }
+		},
+		encoder: func(v lua.LValue) pk.FieldEncoder { return pk.Float(number(v)) },
+	},
+	"Double": {
+		decoder: func() (pk.FieldDecoder, func() lua.LValue) {
+			var v pk.Double
+			return &v, func() lua.LValue { return lua.LNumber(v) }
+		},
+		encoder: func(v lua.LValue) pk.FieldEncoder { return pk.Double(number(v)) },
+	},
+	"VarInt": {
+		decoder: func() (pk.FieldDecoder, func() lua.LValue) {
+			var v pk.VarInt
+			return &v, func() lua.LValue { return lua.LNumber(v) }
+		},
+		encoder: func(v lua.LValue) pk.FieldEncoder { return pk.VarInt(number(v)) },
+	},
+	"VarLong": {
+		decoder: func() (pk.FieldDecoder, func() lua.LValue) {
+			var v pk.VarLong
+			return &v, func() lua.LValue { return lua.LNumber(v) }
+		},
+		encoder: func(v lua.LValue) pk.FieldEncoder { return pk.VarLong(number(v)) },
+	},
+	"String": {
+		decoder: func() (pk.FieldDecoder, func() lua.LValue) {
+			var v pk.String
+			return &v, func() lua.LValue { return lua.LString(v) }
+		},
+		encoder: func(v lua.LValue) pk.FieldEncoder { return pk.String(lua.LVAsString(v)) },
+	},
+	"Identifier": {
+		decoder: func() (pk.FieldDecoder, func() lua.LValue) {
+			var v pk.Identifier
+			return &v, func() lua.LValue { return lua.LString(v) }
+		},
+		encoder: func(v lua.LValue) pk.FieldEncoder { return pk.Identifier(lua.LVAsString(v)) },
+	},
+	// Chat reads the plain text of a chat component and writes a string as a text component.
+	"Chat": {
+		decoder: func() (pk.FieldDecoder, func() lua.LValue) {
+			var v chat.Component
+			return &v, func() lua.LValue { return lua.LString(v.Plain()) }
+		},
+		encoder: func(v lua.LValue) pk.FieldEncoder { return chat.Text(lua.LVAsString(v)) },
+	},
+	"UUID": {
+		decoder: func() (pk.FieldDecoder, func() lua.LValue) {
+			var v pk.UUID
+			return &v, func() lua.LValue { return lua.LString(uuid.UUID(v).String()) }
+		},
+		encoder: func(v lua.LValue) pk.FieldEncoder {
+			id, _ := uuid.Parse(lua.LVAsString(v))
+			return pk.UUID(id)
+		},
+	},
+}
+
+func lookupField(name string) (field, error) {
+	f, ok := fields[name]
+	if !ok {
+		return f, fmt.Errorf("unknown field type %q", name)
+	}
+	return f, nil
+}
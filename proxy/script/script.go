@@ -0,0 +1,347 @@
+package script
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/OCharnyshevich/proxycraft/proxy/helper"
+	"github.com/OCharnyshevich/proxycraft/proxy/log"
+	"github.com/OCharnyshevich/proxycraft/proxy/network"
+	"github.com/OCharnyshevich/proxycraft/proxy/network/protocol"
+	"github.com/OCharnyshevich/proxycraft/proxy/player/chat"
+	mcNet "github.com/Tnze/go-mc/net"
+	pk "github.com/Tnze/go-mc/net/packet"
+	"github.com/google/uuid"
+	lua "github.com/yuin/gopher-lua"
+)
+
+const (
+	packetType  = "packet"
+	sessionType = "session"
+)
+
+// Script is a single Lua file with its own interpreter. The interpreter is not
+// safe for concurrent use, so callbacks of all sessions are run one at a time.
+type Script struct {
+	Name string
+
+	logger   *log.Logging
+	network  helper.Network
+	handlers []network.PacketHandler
+
+	mu    sync.Mutex
+	state *lua.LState
+}
+
+func load(name, path string, logger *log.Logging, n helper.Network) (*Script, error) {
+	s := &Script{
+		Name:    name,
+		logger:  logger,
+		network: n,
+		state:   lua.NewState(),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.register()
+	if err := s.state.DoFile(path); err != nil {
+		s.state.Close()
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *Script) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.state.Close()
+	s.state = nil
+}
+
+// call runs a Lua callback, args builds the arguments once the interpreter is locked.
+// The callback drops the packet by returning false or "drop".
+func (s *Script) call(fn *lua.LFunction, args func(L *lua.LState) []lua.LValue) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// the script was unloaded while the packet was dispatched
+	if s.state == nil {
+		return nil
+	}
+
+	if err := s.state.CallByParam(lua.P{Fn: fn, NRet: 1, Protect: true}, args(s.state)...); err != nil {
+		return err
+	}
+
+	result := s.state.Get(-1)
+	s.state.Pop(1)
+	if result == lua.LFalse || result == lua.LString("drop") {
+		return network.Drop
+	}
+	return nil
+}
+
+func (s *Script) register() {
+	L := s.state
+
+	packet := L.NewTypeMetatable(packetType)
+	L.SetField(packet, "__index", L.NewFunction(s.packetIndex))
+
+	session := L.NewTypeMetatable(sessionType)
+	L.SetField(session, "__index", L.SetFuncs(L.NewTable(), map[string]lua.LGFunction{
+		"send":  s.sessionSend,
+		"write": s.sessionWrite,
+		"close": s.sessionClose,
+	}))
+
+	L.SetGlobal("proxy", L.SetFuncs(L.NewTable(), map[string]lua.LGFunction{
+		"log":       s.log,
+		"on":        s.on,
+		"on_packet": s.onPacket,
+		"broadcast": s.broadcast,
+		"sessions":  s.sessions,
+	}))
+}
+
+// proxy.log(...) writes to the script's logger.
+func (s *Script) log(L *lua.LState) int {
+	args := make([]interface{}, L.GetTop())
+	for i := range args {
+		args[i] = L.Get(i + 1).String()
+	}
+	s.logger.Info(append([]interface{}{"[", s.Name, "] "}, args...)...)
+	return 0
+}
+
+// proxy.on_packet(name, function(packet) end[, priority]) registers a PacketHandler,
+// the packet is dropped when the function returns false or "drop".
+func (s *Script) onPacket(L *lua.LState) int {
+	name := protocol.Name(L.CheckString(1))
+	fn := L.CheckFunction(2)
+	priority := L.OptInt(3, 64)
+
+	s.handlers = append(s.handlers, network.PacketHandler{
+		Name:     name,
+		Priority: priority,
+		F: func(_ *mcNet.Conn, _ *mcNet.Conn, p network.Packet) error {
+			return s.call(fn, func(L *lua.LState) []lua.LValue {
+				return []lua.LValue{s.packet(L, p)}
+			})
+		},
+	})
+	return 0
+}
+
+// proxy.on(event, function(session, ...) end) registers an EventsListener style callback,
+// the events are game_start, chat, kick, health and death. Returning false or "drop"
+// drops the packet of the event.
+func (s *Script) on(L *lua.LState) int {
+	event := L.CheckString(1)
+	fn := L.CheckFunction(2)
+
+	var handler network.PacketHandler
+	switch event {
+	case "game_start":
+		handler = s.event(protocol.Login, fn, nil)
+	case "chat":
+		handler = s.event(protocol.ChatClientbound, fn, func(p network.Packet) ([]lua.LValue, bool, error) {
+			var (
+				msg    chat.Component
+				pos    pk.Byte
+				sender pk.UUID
+			)
+			err := p.Scan(&msg, &pos, &sender)
+			return []lua.LValue{lua.LString(msg.Plain()), lua.LNumber(pos), lua.LString(uuid.UUID(sender).String())}, true, err
+		})
+	case "kick":
+		handler = s.event(protocol.KickDisconnect, fn, func(p network.Packet) ([]lua.LValue, bool, error) {
+			var reason chat.Component
+			err := p.Scan(&reason)
+			return []lua.LValue{lua.LString(reason.Plain())}, true, err
+		})
+	case "health", "death":
+		handler = s.event(protocol.UpdateHealth, fn, func(p network.Packet) ([]lua.LValue, bool, error) {
+			var health pk.Float
+			err := p.Scan(&health)
+			if event == "death" {
+				return nil, health <= 0, err
+			}
+			return []lua.LValue{lua.LNumber(health)}, true, err
+		})
+	default:
+		L.ArgError(1, fmt.Sprintf("unknown event %q", event))
+		return 0
+	}
+
+	s.handlers = append(s.handlers, handler)
+	return 0
+}
+
+// event builds a handler which calls fn with the session and the values read by decode,
+// fn is skipped when decode returns false.
+func (s *Script) event(name protocol.Name, fn *lua.LFunction, decode func(p network.Packet) ([]lua.LValue, bool, error)) network.PacketHandler {
+	return network.PacketHandler{
+		Name:     name,
+		Priority: 64,
+		F: func(_ *mcNet.Conn, _ *mcNet.Conn, p network.Packet) error {
+			var values []lua.LValue
+			if decode != nil {
+				var (
+					ok  bool
+					err error
+				)
+				if values, ok, err = decode(p); err != nil {
+					return network.PacketHandlerError{ID: p.ID, Name: p.Name, Err: err}
+				} else if !ok {
+					return nil
+				}
+			}
+
+			return s.call(fn, func(L *lua.LState) []lua.LValue {
+				return append([]lua.LValue{s.session(L, p.Session)}, values...)
+			})
+		},
+	}
+}
+
+// proxy.broadcast(...) sends a chat message to every session.
+func (s *Script) broadcast(L *lua.LState) int {
+	message := s.message(L, 1)
+	for _, session := range s.network.Sessions() {
+		session.SendMessage(message)
+	}
+	return 0
+}
+
+// proxy.sessions() lists the open sessions.
+func (s *Script) sessions(L *lua.LState) int {
+	list := L.NewTable()
+	for _, session := range s.network.Sessions() {
+		list.Append(s.session(L, session))
+	}
+	L.Push(list)
+	return 1
+}
+
+func (s *Script) message(L *lua.LState, from int) string {
+	parts := make([]interface{}, 0, L.GetTop())
+	for i := from; i <= L.GetTop(); i++ {
+		parts = append(parts, L.Get(i).String())
+	}
+	return helper.ConvertToString(parts...)
+}
+
+func (s *Script) packet(L *lua.LState, p network.Packet) *lua.LUserData {
+	ud := L.NewUserData()
+	ud.Value = p
+	L.SetMetatable(ud, L.GetTypeMetatable(packetType))
+	return ud
+}
+
+// packetIndex resolves packet.name, packet.id, packet.session and packet:read(types...).
+func (s *Script) packetIndex(L *lua.LState) int {
+	p := L.CheckUserData(1).Value.(network.Packet)
+
+	switch L.CheckString(2) {
+	case "name":
+		L.Push(lua.LString(p.Name))
+	case "id":
+		L.Push(lua.LNumber(p.ID))
+	case "session":
+		L.Push(s.session(L, p.Session))
+	case "read":
+		L.Push(L.NewFunction(s.packetRead))
+	default:
+		L.Push(lua.LNil)
+	}
+	return 1
+}
+
+// packet:read("Long", "Long") decodes the leading fields of the packet.
+func (s *Script) packetRead(L *lua.LState) int {
+	p := L.CheckUserData(1).Value.(network.Packet)
+
+	decoders := make([]pk.FieldDecoder, 0, L.GetTop()-1)
+	values := make([]func() lua.LValue, 0, L.GetTop()-1)
+	for i := 2; i <= L.GetTop(); i++ {
+		f, err := lookupField(L.CheckString(i))
+		if err != nil {
+			L.ArgError(i, err.Error())
+			return 0
+		}
+		decoder, value := f.decoder()
+		decoders = append(decoders, decoder)
+		values = append(values, value)
+	}
+
+	if err := p.Scan(decoders...); err != nil {
+		L.RaiseError("unable to read %s: %v", p.Name, err)
+		return 0
+	}
+
+	for _, value := range values {
+		L.Push(value())
+	}
+	return len(values)
+}
+
+func (s *Script) session(L *lua.LState, session helper.Sessionable) *lua.LUserData {
+	ud := L.NewUserData()
+	ud.Value = session
+	L.SetMetatable(ud, L.GetTypeMetatable(sessionType))
+	return ud
+}
+
+func checkSession(L *lua.LState) helper.Sessionable {
+	session, ok := L.CheckUserData(1).Value.(helper.Sessionable)
+	if !ok {
+		L.ArgError(1, "session expected")
+	}
+	return session
+}
+
+// session:send(...) sends a chat message to the player.
+func (s *Script) sessionSend(L *lua.LState) int {
+	checkSession(L).SendMessage(s.message(L, 2))
+	return 0
+}
+
+// session:write(name, {types...}, values...) sends a packet in the backend's version to the player.
+func (s *Script) sessionWrite(L *lua.LState) int {
+	session := checkSession(L)
+	name := protocol.Name(L.CheckString(2))
+	types := L.CheckTable(3)
+
+	encoders := make([]pk.FieldEncoder, 0, types.Len())
+	for i := 1; i <= types.Len(); i++ {
+		f, err := lookupField(types.RawGetInt(i).String())
+		if err != nil {
+			L.ArgError(3, err.Error())
+			return 0
+		}
+		encoders = append(encoders, f.encoder(L.Get(3+i)))
+	}
+
+	version := session.Backend()
+	if version == nil {
+		version = protocol.Default()
+	}
+
+	packet, err := version.Marshal(name, encoders...)
+	if err == nil {
+		err = session.WritePacket(packet)
+	}
+	if err != nil {
+		L.RaiseError("unable to write %s: %v", name, err)
+	}
+	return 0
+}
+
+// session:close() disconnects the player.
+func (s *Script) sessionClose(L *lua.LState) int {
+	checkSession(L).Kill()
+	return 0
+}
@@ -0,0 +1,442 @@
+// Package stream publishes proxy events as newline delimited JSON to external
+// consumers over a Unix or TCP socket.
+//
+// Every line sent by the proxy is an Event or a Response. Consumers send Request
+// lines, a "subscribe" request sets the event types and players a consumer is
+// interested in, "chat", "broadcast" and "kick" requests act on the players,
+// "logs" searches the latest log entries and "chat_history" the chat archive.
+// A new connection receives every event until it subscribes.
+//
+// The requests other than "subscribe" carry the token of the config, they are
+// refused while it is empty. The Unix socket is only open to the user of the proxy.
+package stream
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OCharnyshevich/proxycraft/proxy/chatlog"
+	"github.com/OCharnyshevich/proxycraft/proxy/helper"
+	"github.com/OCharnyshevich/proxycraft/proxy/log"
+	"github.com/OCharnyshevich/proxycraft/proxy/network"
+	"github.com/OCharnyshevich/proxycraft/proxy/network/protocol"
+	"github.com/OCharnyshevich/proxycraft/proxy/player/chat"
+	"github.com/OCharnyshevich/proxycraft/proxy/plugin"
+	mcNet "github.com/Tnze/go-mc/net"
+	pk "github.com/Tnze/go-mc/net/packet"
+	"github.com/google/uuid"
+)
+
+// Event types streamed to the consumers.
+const (
+	SessionOpened = "session.opened"
+	SessionJoined = "session.joined"
+	SessionClosed = "session.closed"
+	Chat          = "chat"
+	Kick          = "kick"
+	Death         = "death"
+	Packet        = "packet"
+)
+
+type Event struct {
+	Type    string                 `json:"type"`
+	Time    time.Time              `json:"time"`
+	Session int32                  `json:"session,omitempty"`
+	Player  string                 `json:"player,omitempty"`
+	UUID    string                 `json:"uuid,omitempty"`
+	Data    map[string]interface{} `json:"data,omitempty"`
+}
+
+type Request struct {
+	ID   string `json:"id,omitempty"`
+	Type string `json:"type"`
+	// Token authorizes the requests, subscribe needs it only off the unix socket.
+	Token string `json:"token,omitempty"`
+
+	// subscribe, empty lists match everything
+	Events  []string `json:"events,omitempty"`
+	Players []string `json:"players,omitempty"`
+
+	// chat, broadcast and kick
+	Player  string `json:"player,omitempty"`
+	Message string `json:"message,omitempty"`
+
+	// logs searches the log buffer and chat_history the chat archive of the player,
+	// every field is optional
+	Logger string     `json:"logger,omitempty"`
+	Level  string     `json:"level,omitempty"`
+	Since  *time.Time `json:"since,omitempty"`
+	Until  *time.Time `json:"until,omitempty"`
+	Text   string     `json:"text,omitempty"`
+	Limit  int        `json:"limit,omitempty"`
+}
+
+type Response struct {
+	Type  string `json:"type"`
+	ID    string `json:"id,omitempty"`
+	Error string `json:"error,omitempty"`
+	// Logs are the entries found by a logs request, in the json log encoding.
+	Logs []json.RawMessage `json:"logs,omitempty"`
+	// Chat are the messages found by a chat_history request.
+	Chat []chatlog.Entry `json:"chat,omitempty"`
+}
+
+type Config struct {
+	// Network is "unix" or "tcp", which needs a Token.
+	Network string `json:"network"`
+	Address string `json:"address"`
+	// Packets are the names of the packets streamed as "packet" events.
+	Packets []string `json:"packets"`
+	// Token enables the chat, broadcast, kick, logs and chat_history requests, which
+	// have to carry it, as subscribe has to off the unix socket. Only subscribe on the
+	// unix socket is allowed while it is empty.
+	Token string `json:"token"`
+}
+
+// Server is the plugin serving the event stream.
+type Server struct {
+	ctx    *plugin.Context
+	config Config
+
+	listener net.Listener
+
+	mu          sync.RWMutex
+	subscribers map[*subscriber]struct{}
+}
+
+func New() *Server {
+	return &Server{
+		config: Config{
+			Network: "unix",
+			Address: "proxycraft.sock",
+		},
+		subscribers: make(map[*subscriber]struct{}),
+	}
+}
+
+func (s *Server) Info() plugin.Info {
+	return plugin.Info{Name: "stream", Version: "1.0.0"}
+}
+
+func (s *Server) Attach(ctx *plugin.Context) {
+	s.ctx = ctx
+}
+
+func (s *Server) Load() {
+	if err := s.ctx.Config.Decode(&s.config); err != nil {
+		s.ctx.Logger.FailF("invalid config: %v", err)
+		return
+	}
+
+	if s.config.Network != "unix" && s.config.Token == "" {
+		s.ctx.Logger.FailF("unable to listen on %s %s: a token is needed off the unix socket", s.config.Network, s.config.Address)
+		return
+	}
+
+	if s.config.Network == "unix" {
+		// a socket left behind by a previous run blocks the address
+		if info, err := os.Lstat(s.config.Address); err == nil {
+			if info.Mode()&os.ModeSocket == 0 {
+				s.ctx.Logger.FailF("unable to listen on %s: the file exists and is not a socket", s.config.Address)
+				return
+			}
+			_ = os.Remove(s.config.Address)
+		}
+	}
+
+	listener, err := net.Listen(s.config.Network, s.config.Address)
+	if err != nil {
+		s.ctx.Logger.FailF("unable to listen on %s %s: %v", s.config.Network, s.config.Address, err)
+		return
+	}
+	if s.config.Network == "unix" {
+		if err := os.Chmod(s.config.Address, 0600); err != nil {
+			_ = listener.Close()
+			s.ctx.Logger.FailF("unable to restrict the socket %s: %v", s.config.Address, err)
+			return
+		}
+	}
+	s.listener = listener
+
+	s.attach()
+	go s.accept()
+
+	s.ctx.Logger.InfoF("streaming events on %s %s", s.config.Network, s.config.Address)
+	if s.config.Token == "" {
+		s.ctx.Logger.Info("stream requests other than subscribe are disabled, set a token to enable them")
+	}
+}
+
+func (s *Server) Kill() {
+	if s.listener == nil {
+		return
+	}
+	_ = s.listener.Close()
+
+	s.mu.Lock()
+	for sub := range s.subscribers {
+		sub.close()
+	}
+	s.subscribers = make(map[*subscriber]struct{})
+	s.mu.Unlock()
+}
+
+func (s *Server) accept() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				s.ctx.Logger.Warn(err)
+			}
+			return
+		}
+
+		sub := newSubscriber(conn)
+		s.mu.Lock()
+		s.subscribers[sub] = struct{}{}
+		s.mu.Unlock()
+
+		go sub.write()
+		go func() {
+			s.read(sub)
+
+			s.mu.Lock()
+			delete(s.subscribers, sub)
+			s.mu.Unlock()
+			sub.close()
+		}()
+	}
+}
+
+// read handles the requests of a subscriber until the connection is closed.
+func (s *Server) read(sub *subscriber) {
+	decoder := json.NewDecoder(sub.conn)
+	for {
+		var r Request
+		if err := decoder.Decode(&r); err != nil {
+			return
+		}
+
+		response := Response{Type: "response", ID: r.ID}
+		if err := s.handle(sub, r, &response); err != nil {
+			response.Error = err.Error()
+		}
+		sub.send(response)
+	}
+}
+
+// errUnauthorized answers the requests without the token of the config.
+var errUnauthorized = errors.New("the request needs the token of the stream config")
+
+func (s *Server) handle(sub *subscriber, r Request, response *Response) error {
+	// the unix socket is only open to the user running the proxy, anyone may reach the others
+	if (r.Type != "subscribe" || s.config.Network != "unix") && !s.authorized(r) {
+		return errUnauthorized
+	}
+
+	switch r.Type {
+	case "subscribe":
+		sub.subscribe(r.Events, r.Players)
+	case "chat":
+		session, err := s.find(r.Player)
+		if err != nil {
+			return err
+		}
+		session.SendMessage(r.Message)
+	case "broadcast":
+		for _, session := range s.ctx.Network.Sessions() {
+			session.SendMessage(r.Message)
+		}
+	case "kick":
+		session, err := s.find(r.Player)
+		if err != nil {
+			return err
+		}
+		s.ctx.Logger.InfoF("kicking %s: %s", session.Name(), r.Message)
+		session.Disconnect(r.Message)
+	case "logs":
+		entries, err := s.logs(r)
+		if err != nil {
+			return err
+		}
+		response.Logs = entries
+	case "chat_history":
+		entries, err := s.chatHistory(r)
+		if err != nil {
+			return err
+		}
+		response.Chat = entries
+	default:
+		return errors.New("unknown request type " + r.Type)
+	}
+	return nil
+}
+
+// authorized tells if the request carries the token, it never does while the token is empty.
+func (s *Server) authorized(r Request) bool {
+	return s.config.Token != "" && subtle.ConstantTimeCompare([]byte(r.Token), []byte(s.config.Token)) == 1
+}
+
+// logs searches the log buffer of the proxy, data entries are left out unless the level asks for them.
+func (s *Server) logs(r Request) ([]json.RawMessage, error) {
+	if s.ctx.Logs == nil {
+		return nil, errors.New("the log buffer is off")
+	}
+
+	query := log.Query{Logger: r.Logger, Text: r.Text, Limit: r.Limit}
+	if r.Level != "" {
+		level, err := log.ParseLevel(r.Level)
+		if err != nil {
+			return nil, err
+		}
+		query.Level = level
+	}
+	if r.Since != nil {
+		query.Since = *r.Since
+	}
+	if r.Until != nil {
+		query.Until = *r.Until
+	}
+
+	entries := s.ctx.Logs.Search(query)
+	lines := make([]json.RawMessage, len(entries))
+	for i, entry := range entries {
+		lines[i] = bytes.TrimSuffix(log.JSON.Encode(entry), []byte("\n"))
+	}
+	return lines, nil
+}
+
+// chatHistory searches the chat archive of the proxy.
+func (s *Server) chatHistory(r Request) ([]chatlog.Entry, error) {
+	if s.ctx.Chat == nil {
+		return nil, errors.New("the chat archive is off")
+	}
+
+	query := chatlog.Query{Player: r.Player, Text: r.Text, Limit: r.Limit}
+	if r.Since != nil {
+		query.Since = *r.Since
+	}
+	if r.Until != nil {
+		query.Until = *r.Until
+	}
+	return s.ctx.Chat.Search(query)
+}
+
+// find looks a player up by the name or the UUID.
+func (s *Server) find(player string) (helper.Sessionable, error) {
+	for _, session := range s.ctx.Network.Sessions() {
+		if strings.EqualFold(session.Name(), player) || session.UUID().String() == player {
+			return session, nil
+		}
+	}
+	return nil, errors.New("player " + player + " is not online")
+}
+
+func (s *Server) publish(e Event) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for sub := range s.subscribers {
+		if sub.matches(e) {
+			sub.send(e)
+		}
+	}
+}
+
+func event(kind string, session helper.Sessionable, data map[string]interface{}) Event {
+	e := Event{Type: kind, Time: time.Now().UTC(), Data: data}
+	if session != nil {
+		e.Session = session.ID()
+		e.Player = session.Name()
+		if id := session.UUID(); id != uuid.Nil {
+			e.UUID = id.String()
+		}
+	}
+	return e
+}
+
+// attach subscribes to the network events.
+func (s *Server) attach() {
+	s.ctx.ListenSessions(network.SessionHandler{F: func(e network.SessionEvent, session helper.Sessionable) {
+		kinds := map[network.SessionEvent]string{
+			network.SessionOpened: SessionOpened,
+			network.SessionJoined: SessionJoined,
+			network.SessionClosed: SessionClosed,
+		}
+		s.publish(event(kinds[e], session, map[string]interface{}{"address": session.Address()}))
+	}})
+
+	s.ctx.Listen(
+		network.PacketHandler{Priority: 64, Name: protocol.ChatClientbound, F: s.onChat},
+		network.PacketHandler{Priority: 64, Name: protocol.KickDisconnect, F: s.onKick},
+		network.PacketHandler{Priority: 64, Name: protocol.UpdateHealth, F: s.onHealth},
+	)
+
+	for _, name := range s.config.Packets {
+		s.ctx.Listen(network.PacketHandler{Priority: 64, Name: protocol.Name(name), F: s.onPacket})
+	}
+}
+
+func (s *Server) onChat(_ *mcNet.Conn, _ *mcNet.Conn, p network.Packet) error {
+	var (
+		msg    chat.Component
+		pos    pk.Byte
+		sender pk.UUID
+	)
+	if err := p.Scan(&msg, &pos, &sender); err != nil {
+		return err
+	}
+
+	s.publish(event(Chat, p.Session, map[string]interface{}{
+		"message":  msg.Plain(),
+		"position": pos,
+		"sender":   uuid.UUID(sender).String(),
+	}))
+	return nil
+}
+
+func (s *Server) onKick(_ *mcNet.Conn, _ *mcNet.Conn, p network.Packet) error {
+	var reason chat.Component
+	if err := p.Scan(&reason); err != nil {
+		return err
+	}
+
+	s.publish(event(Kick, p.Session, map[string]interface{}{"reason": reason.Plain()}))
+	return nil
+}
+
+func (s *Server) onHealth(_ *mcNet.Conn, _ *mcNet.Conn, p network.Packet) error {
+	var health pk.Float
+	if err := p.Scan(&health); err != nil {
+		return err
+	}
+
+	if health <= 0 {
+		s.publish(event(Death, p.Session, nil))
+	}
+	return nil
+}
+
+func (s *Server) onPacket(_ *mcNet.Conn, _ *mcNet.Conn, p network.Packet) error {
+	data := map[string]interface{}{
+		"name": p.Name,
+		"id":   p.ID,
+		"size": len(p.Data),
+	}
+
+	if fields, ok := network.Decode(p); ok {
+		data["fields"] = fields
+	} else {
+		data["raw"] = p.Data
+	}
+
+	s.publish(event(Packet, p.Session, data))
+	return nil
+}
@@ -0,0 +1,89 @@
+package stream
+
+import (
+	"encoding/json"
+	"net"
+	"strings"
+	"sync"
+)
+
+// subscriber is a connected consumer. Events are queued, so a slow consumer
+// never blocks a session, and are dropped while the queue is full.
+type subscriber struct {
+	conn  net.Conn
+	queue chan interface{}
+	once  sync.Once
+
+	mu      sync.RWMutex
+	events  map[string]bool
+	players map[string]bool
+}
+
+func newSubscriber(conn net.Conn) *subscriber {
+	return &subscriber{
+		conn:  conn,
+		queue: make(chan interface{}, 256),
+	}
+}
+
+func (s *subscriber) subscribe(events, players []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.events = set(events)
+	s.players = set(players)
+}
+
+func (s *subscriber) matches(e Event) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.events != nil && !s.events[strings.ToLower(e.Type)] {
+		return false
+	}
+	if s.players != nil && !s.players[strings.ToLower(e.Player)] && !s.players[e.UUID] {
+		return false
+	}
+	return true
+}
+
+func (s *subscriber) send(v interface{}) {
+	defer func() {
+		_ = recover() // ignore sending to a closed subscriber
+	}()
+
+	select {
+	case s.queue <- v:
+	default:
+	}
+}
+
+func (s *subscriber) write() {
+	encoder := json.NewEncoder(s.conn)
+	for v := range s.queue {
+		if err := encoder.Encode(v); err != nil {
+			_ = s.conn.Close()
+			return
+		}
+	}
+}
+
+func (s *subscriber) close() {
+	s.once.Do(func() {
+		close(s.queue)
+		_ = s.conn.Close()
+	})
+}
+
+// set builds a lookup of lower-cased values, nil matches everything.
+func set(values []string) map[string]bool {
+	if len(values) == 0 {
+		return nil
+	}
+
+	m := make(map[string]bool, len(values))
+	for _, v := range values {
+		m[strings.ToLower(v)] = true
+	}
+	return m
+}
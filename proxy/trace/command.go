@@ -0,0 +1,220 @@
+package trace
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/OCharnyshevich/proxycraft/proxy/command"
+	"github.com/OCharnyshevich/proxycraft/proxy/helper"
+	"github.com/OCharnyshevich/proxycraft/proxy/log"
+)
+
+const usage = "trace | trace on|off <player|session|*> | trace direction <both|clientbound|serverbound> | " +
+	"trace allow|deny <packet...>|clear | trace format <hex|fields>"
+
+func (t *Tracer) command() command.Command {
+	return command.Command{
+		Name:        "trace",
+		Description: "logs the packets of a session at the data level, trace without arguments shows the filters",
+		Scope:       command.ConsoleOnly,
+		Complete:    t.complete,
+		Run: func(sender command.Sender, args command.Args) error {
+			return t.run(sender, args.Raw)
+		},
+	}
+}
+
+func (t *Tracer) run(sender command.Sender, args []string) error {
+	if len(args) == 0 {
+		t.status(sender)
+		return nil
+	}
+
+	switch args[0] {
+	case "on", "off":
+		if len(args) != 2 {
+			return errors.New("usage: " + usage)
+		}
+		return t.toggle(sender, args[1], args[0] == "on")
+	case "direction":
+		if len(args) != 2 {
+			return errors.New("usage: " + usage)
+		}
+		if err := t.setDirection(args[1]); err != nil {
+			return err
+		}
+		sender.SendMessage("tracing ", args[1], " packets")
+		return nil
+	case "format":
+		if len(args) != 2 {
+			return errors.New("usage: " + usage)
+		}
+		if err := t.setFormat(args[1]); err != nil {
+			return err
+		}
+		sender.SendMessage("packets are logged as ", args[1])
+		return nil
+	case "allow", "deny":
+		if len(args) < 2 {
+			return errors.New("usage: " + usage)
+		}
+		return t.edit(sender, args[0], args[1:])
+	}
+
+	return errors.New("usage: " + usage)
+}
+
+func (t *Tracer) toggle(sender command.Sender, target string, on bool) error {
+	state := "off"
+	if on {
+		state = "on"
+	}
+
+	if target == "*" {
+		t.mu.Lock()
+		t.all = on
+		t.sessions = make(map[int32]bool)
+		if !on {
+			// the players of the config are traced again from their next login
+			for _, session := range t.ctx.Network.Sessions() {
+				t.sessions[session.ID()] = false
+			}
+		}
+		t.mu.Unlock()
+
+		sender.SendMessage("tracing every session ", state)
+		t.hint(sender, on)
+		return nil
+	}
+
+	session, err := t.session(target)
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	t.sessions[session.ID()] = on
+	t.mu.Unlock()
+
+	sender.SendMessage(fmt.Sprintf("tracing session %d (%s) %s", session.ID(), session.Name(), state))
+	t.hint(sender, on)
+	return nil
+}
+
+// hint tells how to show the trace when the logger hides the data level.
+func (t *Tracer) hint(sender command.Sender, on bool) {
+	if on && !t.ctx.Logger.Enabled(log.Data) {
+		sender.SendMessage("packets are logged at the data level, run: loglevel ", t.ctx.Logger.Name(), " data")
+	}
+}
+
+// session finds a session by its ID or the name of its player.
+func (t *Tracer) session(target string) (helper.Sessionable, error) {
+	id, err := strconv.Atoi(target)
+	for _, session := range t.ctx.Network.Sessions() {
+		if (err == nil && session.ID() == int32(id)) || strings.EqualFold(session.Name(), target) {
+			return session, nil
+		}
+	}
+	return nil, fmt.Errorf("no session %s", target)
+}
+
+func (t *Tracer) edit(sender command.Sender, list string, packets []string) error {
+	var added filter
+	if len(packets) != 1 || packets[0] != "clear" {
+		var err error
+		if added, err = parseFilter(packets); err != nil {
+			return err
+		}
+	}
+
+	t.mu.Lock()
+	f := &t.allow
+	if list == "deny" {
+		f = &t.deny
+	}
+	if added == nil {
+		*f = make(filter)
+	}
+	for key := range added {
+		(*f)[key] = true
+	}
+	current := f.String()
+	t.mu.Unlock()
+
+	sender.SendMessage(list, ": ", current)
+	return nil
+}
+
+func (t *Tracer) status(sender command.Sender) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	var traced []string
+	if t.all {
+		traced = append(traced, "every session")
+	}
+	ids := make([]int, 0, len(t.sessions))
+	for id := range t.sessions {
+		ids = append(ids, int(id))
+	}
+	sort.Ints(ids)
+	var untraced []string
+	for _, id := range ids {
+		if t.sessions[int32(id)] {
+			traced = append(traced, "session "+strconv.Itoa(id))
+		} else {
+			untraced = append(untraced, "session "+strconv.Itoa(id))
+		}
+	}
+	players := make([]string, 0, len(t.players))
+	for name := range t.players {
+		players = append(players, name)
+	}
+	sort.Strings(players)
+	traced = append(traced, players...)
+	if len(traced) == 0 {
+		traced = []string{"nothing"}
+	}
+
+	sender.SendMessage("tracing: ", strings.Join(traced, ", "))
+	if len(untraced) > 0 {
+		sender.SendMessage("not tracing: ", strings.Join(untraced, ", "))
+	}
+	sender.SendMessage("direction: ", t.config.Direction, ", format: ", t.config.Format)
+	sender.SendMessage("allow: ", t.allow, ", deny: ", t.deny)
+}
+
+func (t *Tracer) complete(_ command.Sender, args []string, _ string) []string {
+	var values []string
+	switch {
+	case len(args) == 0:
+		values = []string{"on", "off", "direction", "allow", "deny", "format"}
+	case args[0] == "on" || args[0] == "off":
+		if len(args) == 1 {
+			values = []string{"*"}
+			for _, session := range t.ctx.Network.Sessions() {
+				if session.Name() != "" {
+					values = append(values, session.Name())
+				}
+			}
+		}
+	case args[0] == "direction":
+		if len(args) == 1 {
+			values = []string{Both, Clientbound, Serverbound}
+		}
+	case args[0] == "format":
+		if len(args) == 1 {
+			values = []string{Hex, Fields}
+		}
+	case args[0] == "allow" || args[0] == "deny":
+		values = packetNames()
+		if len(args) == 1 {
+			values = append(values, "clear")
+		}
+	}
+	return values
+}
@@ -0,0 +1,327 @@
+// Package trace logs the packets of chosen sessions at the data level, for
+// debugging what the clients and the backends send.
+//
+// Sessions are traced with the trace console command or by the player names of
+// the config. Packets are filtered by direction and by allow and deny lists of
+// packet names or IDs, and logged with their size and either a hex dump or the
+// decoded fields.
+package trace
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OCharnyshevich/proxycraft/proxy/helper"
+	"github.com/OCharnyshevich/proxycraft/proxy/log"
+	"github.com/OCharnyshevich/proxycraft/proxy/network"
+	"github.com/OCharnyshevich/proxycraft/proxy/network/protocol"
+	"github.com/OCharnyshevich/proxycraft/proxy/plugin"
+	mcNet "github.com/Tnze/go-mc/net"
+)
+
+// Directions of the traced packets.
+const (
+	Both        = "both"
+	Clientbound = "clientbound"
+	Serverbound = "serverbound"
+)
+
+// Formats of the packet data.
+const (
+	// Hex dumps the data, up to MaxDump bytes.
+	Hex = "hex"
+	// Fields writes the decoded fields of the known packets, the hex dump of the others.
+	Fields = "fields"
+)
+
+type Config struct {
+	// Direction is both, clientbound or serverbound.
+	Direction string `json:"direction"`
+	// Allow traces only the listed packets, by name or by ID like "0x22", all packets when empty.
+	Allow []string `json:"allow"`
+	// Deny skips the listed packets, by name or by ID.
+	Deny []string `json:"deny"`
+	// Players are traced from their login, by name.
+	Players []string `json:"players"`
+	// Format of the packet data, hex or fields.
+	Format string `json:"format"`
+	// MaxDump is the number of bytes of the hex dump.
+	MaxDump int `json:"max_dump"`
+}
+
+// Tracer is the plugin logging the packets.
+type Tracer struct {
+	ctx    *plugin.Context
+	config Config
+
+	mu  sync.RWMutex
+	all bool
+	// sessions are turned on or off by the command, over all and the players of the config
+	sessions map[int32]bool
+	players  map[string]bool
+	allow    filter
+	deny     filter
+}
+
+func New() *Tracer {
+	return &Tracer{
+		config: Config{
+			Direction: Both,
+			// the packets flooding every session
+			Deny: []string{
+				string(protocol.KeepAliveClientbound), string(protocol.KeepAliveServerbound),
+				string(protocol.MapChunk), string(protocol.UpdateLight),
+				string(protocol.RelEntityMove), string(protocol.EntityMoveLook), string(protocol.EntityLook),
+				string(protocol.EntityHeadRotation), string(protocol.EntityVelocity), string(protocol.EntityTeleport),
+			},
+			Format:  Hex,
+			MaxDump: 64,
+		},
+		sessions: make(map[int32]bool),
+		players:  make(map[string]bool),
+	}
+}
+
+func (t *Tracer) Info() plugin.Info {
+	return plugin.Info{Name: "trace", Version: "1.0.0"}
+}
+
+func (t *Tracer) Attach(ctx *plugin.Context) {
+	t.ctx = ctx
+}
+
+func (t *Tracer) Load() {
+	if err := t.ctx.Config.Decode(&t.config); err != nil {
+		t.ctx.Logger.FailF("invalid config: %v", err)
+		return
+	}
+	if err := t.setDirection(t.config.Direction); err != nil {
+		t.ctx.Logger.Fail(err)
+		return
+	}
+	if err := t.setFormat(t.config.Format); err != nil {
+		t.ctx.Logger.Fail(err)
+		return
+	}
+
+	allow, err := parseFilter(t.config.Allow)
+	if err != nil {
+		t.ctx.Logger.Fail(err)
+		return
+	}
+	deny, err := parseFilter(t.config.Deny)
+	if err != nil {
+		t.ctx.Logger.Fail(err)
+		return
+	}
+
+	t.mu.Lock()
+	t.allow, t.deny = allow, deny
+	t.sessions = make(map[int32]bool)
+	t.players = make(map[string]bool, len(t.config.Players))
+	for _, name := range t.config.Players {
+		t.players[strings.ToLower(name)] = true
+	}
+	t.mu.Unlock()
+
+	t.ctx.ListenGeneric(network.PacketHandler{Priority: 0, F: t.handler(true)})
+	t.ctx.ListenGenericServerbound(network.PacketHandler{Priority: 0, F: t.handler(false)})
+	t.ctx.ListenSessions(network.SessionHandler{F: func(e network.SessionEvent, session helper.Sessionable) {
+		if e == network.SessionClosed {
+			t.mu.Lock()
+			delete(t.sessions, session.ID())
+			t.mu.Unlock()
+		}
+	}})
+
+	if err := t.ctx.RegisterCommand(t.command()); err != nil {
+		t.ctx.Logger.Fail(err)
+	}
+}
+
+func (t *Tracer) Kill() {
+	t.mu.Lock()
+	t.all = false
+	t.sessions = make(map[int32]bool)
+	t.mu.Unlock()
+}
+
+// handler returns a generic listener logging the traced packets of a direction.
+func (t *Tracer) handler(clientbound bool) func(*mcNet.Conn, *mcNet.Conn, network.Packet) error {
+	arrow := "→"
+	if clientbound {
+		arrow = "←"
+	}
+
+	return func(_ *mcNet.Conn, _ *mcNet.Conn, packet network.Packet) error {
+		if !t.ctx.Logger.Enabled(log.Data) || !t.traces(packet, clientbound) {
+			return nil
+		}
+
+		name := string(packet.Name)
+		if name == "" {
+			name = "unknown"
+		}
+
+		t.ctx.Logger.With(log.Session(packet.Session.ID()), log.Player(packet.Session.Name()), log.Packet(packet.ID)).DataF(
+			"%s %s %s %d bytes %s",
+			time.Now().Format("15:04:05.000"), arrow, name, len(packet.Data), t.body(packet),
+		)
+		return nil
+	}
+}
+
+// traces reports whether the packet passes the filters and its session is traced.
+func (t *Tracer) traces(packet network.Packet, clientbound bool) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	switch t.config.Direction {
+	case Clientbound:
+		if !clientbound {
+			return false
+		}
+	case Serverbound:
+		if clientbound {
+			return false
+		}
+	}
+
+	if len(t.allow) > 0 && !t.allow.matches(packet) {
+		return false
+	}
+	if t.deny.matches(packet) {
+		return false
+	}
+
+	session := packet.Session
+	if on, ok := t.sessions[session.ID()]; ok {
+		return on
+	}
+	return t.all || t.players[strings.ToLower(session.Name())]
+}
+
+// body is the decoded fields or the hex dump of the packet data.
+func (t *Tracer) body(packet network.Packet) string {
+	t.mu.RLock()
+	format, max := t.config.Format, t.config.MaxDump
+	t.mu.RUnlock()
+
+	if format == Fields {
+		if fields, ok := network.Decode(packet); ok {
+			keys := make([]string, 0, len(fields))
+			for key := range fields {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+
+			pairs := make([]string, len(keys))
+			for i, key := range keys {
+				pairs[i] = fmt.Sprintf("%s=%v", key, fields[key])
+			}
+			return strings.Join(pairs, " ")
+		}
+	}
+
+	data := packet.Data
+	if max > 0 && len(data) > max {
+		return fmt.Sprintf("% x …", data[:max])
+	}
+	return fmt.Sprintf("% x", data)
+}
+
+func (t *Tracer) setDirection(direction string) error {
+	switch direction {
+	case Both, Clientbound, Serverbound:
+	default:
+		return fmt.Errorf("unknown direction %q, use both, clientbound or serverbound", direction)
+	}
+
+	t.mu.Lock()
+	t.config.Direction = direction
+	t.mu.Unlock()
+	return nil
+}
+
+func (t *Tracer) setFormat(format string) error {
+	if format != Hex && format != Fields {
+		return fmt.Errorf("unknown format %q, use hex or fields", format)
+	}
+
+	t.mu.Lock()
+	t.config.Format = format
+	t.mu.Unlock()
+	return nil
+}
+
+// filter holds packets by name and by ID.
+type filter map[string]bool
+
+// parseFilter reads packet names and IDs like 0x22, unknown names are rejected.
+func parseFilter(packets []string) (filter, error) {
+	f := make(filter, len(packets))
+	for _, p := range packets {
+		key, err := filterKey(p)
+		if err != nil {
+			return nil, err
+		}
+		f[key] = true
+	}
+	return f, nil
+}
+
+func filterKey(packet string) (string, error) {
+	if strings.HasPrefix(strings.ToLower(packet), "0x") {
+		id, err := strconv.ParseInt(packet[2:], 16, 32)
+		if err != nil {
+			return "", fmt.Errorf("bad packet ID %q", packet)
+		}
+		return fmt.Sprintf("0x%02X", id), nil
+	}
+
+	for _, name := range packetNames() {
+		if strings.EqualFold(name, packet) {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("unknown packet %q", packet)
+}
+
+func (f filter) matches(packet network.Packet) bool {
+	return f[string(packet.Name)] || f[fmt.Sprintf("0x%02X", packet.ID)]
+}
+
+func (f filter) String() string {
+	if len(f) == 0 {
+		return "none"
+	}
+
+	keys := make([]string, 0, len(f))
+	for key := range f {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ", ")
+}
+
+// packetNames lists the play packets of every supported version.
+func packetNames() []string {
+	seen := make(map[protocol.Name]bool)
+	var names []string
+	for _, v := range protocol.Supported() {
+		for _, d := range []protocol.Direction{protocol.Clientbound, protocol.Serverbound} {
+			for _, name := range v.Names(d) {
+				if !seen[name] {
+					seen[name] = true
+					names = append(names, string(name))
+				}
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}